@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/provisioner"
 	"github.com/golang/glog"
 )
@@ -14,6 +17,10 @@ import (
 var listenIp = flag.String("listen_ip", "", "The IP to listen on for connections")
 var port = flag.Int("port", 8080, "The port to listen on for connections")
 var defaultInstanceType = flag.String("default_instance_type", "n1-standard-1", "The default instance type to create")
+var cloudProvider = flag.String("cloud_provider", "gce", "The cloud provider to create instances with, e.g. gce or fake")
+var cloudConfigFile = flag.String("cloud_config", "", "The path to the cloud provider configuration file. Empty string for no configuration file.")
+var operationTTL = flag.Duration("operation_ttl", 10*time.Minute, "How long completed operations are kept for")
+var allocatorStateFile = flag.String("allocator_state_file", "", "The file the allocated instance indices are persisted to. Empty string to only keep them in memory.")
 
 // Parse the request from the HTTP body.
 func getAddInstancesRequest(body io.ReadCloser) (provisioner.AddInstancesRequest, error) {
@@ -29,12 +36,18 @@ func getAddInstancesRequest(body io.ReadCloser) (provisioner.AddInstancesRequest
 
 // Write the specified responce to the HTTP output stream.
 func writeResult(res interface{}, w http.ResponseWriter) error {
+	return writeResultWithStatus(res, http.StatusOK, w)
+}
+
+// Write the specified responce to the HTTP output stream with the specified status code.
+func writeResultWithStatus(res interface{}, status int, w http.ResponseWriter) error {
 	out, err := json.Marshal(res)
 	if err != nil {
 		return fmt.Errorf("failed to marshall response %+v with error: %s", res, err)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	w.Write(out)
 	return nil
 }
@@ -42,12 +55,30 @@ func writeResult(res interface{}, w http.ResponseWriter) error {
 func main() {
 	flag.Parse()
 
-	prov, err := provisioner.New(*defaultInstanceType)
+	cloud := cloudprovider.InitCloudProvider(*cloudProvider, *cloudConfigFile)
+	prov, err := provisioner.New(cloud, *defaultInstanceType, *allocatorStateFile, *operationTTL)
 	if err != nil {
 		glog.Fatal(err)
 	}
 
 	http.HandleFunc("/instances", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			glog.V(1).Infof("[GET /instances]")
+			instances, err := prov.ListInstances()
+			if err != nil {
+				http.Error(w, err.Error(), 500)
+				return
+			}
+			writeResult(instances, w)
+			return
+		case "POST":
+			break
+		default:
+			http.Error(w, fmt.Sprintf("unsupported method %s", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+
 		request, err := getAddInstancesRequest(r.Body)
 		if err != nil {
 			http.Error(w, err.Error(), 500)
@@ -55,14 +86,71 @@ func main() {
 		}
 
 		glog.V(1).Infof("[/instances]: %v", request.InstanceTypes)
-		newInstances, err := prov.AddInstances(request)
+		op, err := prov.StartAddInstances(request)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writeResultWithStatus(op, http.StatusAccepted, w)
+		return
+	})
+
+	http.HandleFunc("/operations", func(w http.ResponseWriter, r *http.Request) {
+		glog.V(1).Infof("[/operations]")
+		writeResult(prov.ListOperations(), w)
+		return
+	})
+
+	http.HandleFunc("/operations/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/operations/")
+		glog.V(1).Infof("[/operations/%s]", id)
+		op, found := prov.GetOperation(id)
+		if !found {
+			http.Error(w, fmt.Sprintf("operation %q not found", id), http.StatusNotFound)
+			return
+		}
+
+		writeResult(op, w)
+		return
+	})
+
+	http.HandleFunc("/instances/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/instances/")
+		if name == "" || strings.Contains(name, "/") {
+			http.Error(w, fmt.Sprintf("invalid instance name %q", name), http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case "GET":
+			glog.V(1).Infof("[GET /instances/%s]", name)
+			instance, found, err := prov.GetInstance(name)
+			if err != nil {
+				http.Error(w, err.Error(), 500)
+				return
+			}
+			if !found {
+				http.Error(w, fmt.Sprintf("instance %q not found", name), http.StatusNotFound)
+				return
+			}
+			writeResult(instance, w)
+			return
+		case "DELETE":
+			break
+		default:
+			http.Error(w, fmt.Sprintf("unsupported method %s", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+
+		glog.V(1).Infof("[DELETE /instances/%s]", name)
+		removedInstances, err := prov.RemoveInstances(provisioner.RemoveInstancesRequest{Names: []string{name}})
 		if err != nil {
-			// TODO(vmarmol): Write the created instances.
 			http.Error(w, err.Error(), 500)
 			return
 		}
 
-		writeResult(newInstances, w)
+		writeResult(removedInstances, w)
 		return
 	})
 
@@ -2,9 +2,21 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/record"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/aggregator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/apiclient"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/golang/glog"
 )
 
@@ -19,12 +31,177 @@ var argActuatorHostPort = flag.String("actuator_hostport", "localhost:8080", "Ac
 
 var argAggregatorHostPort = flag.String("aggregator_hostport", "localhost:8085", "Aggregator Host:Port.")
 
+var argRequestTimeout = flag.Duration("request_timeout", 30*time.Second, "Timeout of the requests to the actuator and the aggregator.")
+
+var argRequestRetries = flag.Int("request_retries", 3, "Number of times failed idempotent requests to the actuator and the aggregator are retried.")
+
+var argRequestBackoff = flag.Duration("request_backoff", 1*time.Second, "Delay before retrying a failed request to the actuator or the aggregator. Doubles on every retry.")
+
+var argScaleDownThreshold = flag.Uint("scale_down_threshold", 0, "Percentage of node resource usage below which the node is drained and removed. Scale down is disabled if 0.")
+
+var argScaleDownPolicy = flag.String("scale_down_policy", "hour", "Nodes will be removed if their peak usage for the last minute, hour or day is below the scale down threshold. Choose between 'minute', 'hour' and 'day'.")
+
+var argUnschedulablePodAge = flag.Duration("unschedulable_pod_age", 1*time.Minute, "Nodes are added for pods that could not be scheduled for this long.")
+
+var argPredictiveLeadTime = flag.Duration("predictive_lead_time", 0, "Nodes are added ahead of the cluster usage forecast from its daily pattern this long ahead, and not removed if the forecast needs them. The usage history is read from --record_file if set. Disabled if 0.")
+
+var argShapeCostsFile = flag.String("shape_costs_file", "", "JSON file mapping node shape names to the cost of a node of that shape. Shapes not listed cost as much as their number of cores.")
+
+var argNodeCreationTimeout = flag.Duration("node_creation_timeout", 10*time.Minute, "Nodes that have not joined the cluster this long after their creation was requested are given up on.")
+
+var argRetryNodeCreation = flag.Bool("retry_node_creation", false, "Retry node creations that timed out once, on a different node shape.")
+
+var argRecordFile = flag.String("record_file", "", "File to append the cluster stats reported by the aggregator to, for replay in simulation mode.")
+
+var argPolicyConfigFile = flag.String("policy_config_file", "", "JSON file listing the scaling policies in the order they are applied. If not set, the policies are configured by the cluster, scale down and unschedulable pod flags.")
+
+var argMinNodes = flag.Int("min_nodes", 0, "Nodes are added to reach this many nodes, and never removed below it.")
+
+var argMaxNodes = flag.Int("max_nodes", 0, "Nodes are never added beyond this many nodes. No limit if 0.")
+
+var argMaxNodesPerShape = flag.String("max_nodes_per_shape", "", "Comma separated list of node shape limits, e.g. 'n1-standard-4=5,n1-highmem-8=2'.")
+
+var argScaleUpCooldown = flag.Duration("scale_up_cooldown", 3*time.Minute, "Nodes are not added for this long after nodes were last added.")
+
+var argScaleDownCooldown = flag.Duration("scale_down_cooldown", 10*time.Minute, "Nodes are not removed for this long after nodes were last added or removed.")
+
+var argScaleDownMaxUsage = flag.Uint("scale_down_max_usage", 0, "Nodes are not removed if the peak hourly usage of the cluster would exceed this percentage of the remaining capacity. Must be below --cluster_threshold. Defaults to three quarters of --cluster_threshold if 0.")
+
+var argStatusIp = flag.String("status_ip", "", "The IP to serve the status of the scaler on.")
+
+var argStatusPort = flag.Int("status_port", 8086, "The port to serve the status of the scaler on. The status is not served if 0.")
+
+var clientConfig = &client.Config{}
+
+func init() {
+	client.BindClientConfigFlags(flag.CommandLine, clientConfig)
+}
+
+// Returns the policy chain configured by the command line flags.
+func getPolicyConfig() (scaler.PolicyConfig, error) {
+	if *argPolicyConfigFile != "" {
+		return scaler.LoadPolicyConfig(*argPolicyConfigFile)
+	}
+	config := scaler.PolicyConfig{
+		Policies: []scaler.PolicySpec{
+			{Type: "ClusterUsage", Threshold: *argThreshold, Window: *argClusterScalingPolicy},
+			{Type: "UnschedulablePods", MinPendingAge: argUnschedulablePodAge.String()},
+		},
+	}
+	// Scale down is disabled unless a threshold is set.
+	if *argScaleDownThreshold > 0 {
+		config.Policies = append(config.Policies, scaler.PolicySpec{Type: "ScaleDown", Threshold: *argScaleDownThreshold, Window: *argScaleDownPolicy})
+	}
+	// Comes last to hold the removals the forecast needs.
+	if *argPredictiveLeadTime > 0 {
+		config.Policies = append(config.Policies, scaler.PolicySpec{Type: "Predictive", Threshold: *argThreshold, LeadTime: argPredictiveLeadTime.String(), HistoryFile: *argRecordFile})
+	}
+	return config, nil
+}
+
+// Parses node shape limits of the form 'shape=count,...'.
+func parseShapeLimits(value string) (map[string]int, error) {
+	limits := make(map[string]int)
+	if value == "" {
+		return limits, nil
+	}
+	for _, limit := range strings.Split(value, ",") {
+		parts := strings.Split(limit, "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid node shape limit %q", limit)
+		}
+		count, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid node shape limit %q - %q", limit, err)
+		}
+		limits[parts[0]] = count
+	}
+	return limits, nil
+}
+
+// Returns the cluster limits configured by the command line flags.
+func getLimits() (scaler.Limits, error) {
+	shapeLimits, err := parseShapeLimits(*argMaxNodesPerShape)
+	if err != nil {
+		return scaler.Limits{}, err
+	}
+	limits := scaler.Limits{
+		MinNodes:          *argMinNodes,
+		MaxNodes:          *argMaxNodes,
+		MaxNodesPerShape:  shapeLimits,
+		ScaleUpCooldown:   *argScaleUpCooldown,
+		ScaleDownCooldown: *argScaleDownCooldown,
+		ScaleDownMaxUsage: *argScaleDownMaxUsage,
+	}
+	// Removals stop well below the usage at which nodes are added, so that the cluster does not flap.
+	if limits.ScaleDownMaxUsage == 0 {
+		limits.ScaleDownMaxUsage = *argThreshold * 3 / 4
+	}
+	return limits, nil
+}
+
+// Returns a scaler configured by the command line flags.
+func getClientConfig(hostPort string) apiclient.Config {
+	return apiclient.Config{
+		HostPort:   hostPort,
+		Timeout:    *argRequestTimeout,
+		MaxRetries: *argRequestRetries,
+		Backoff:    *argRequestBackoff,
+	}
+}
+
+func newScaler(myActuator actuator.Actuator, myAggregator aggregator.Aggregator, kubeClient client.Interface, clock util.Clock) (scaler.Scaler, error) {
+	policyConfig, err := getPolicyConfig()
+	if err != nil {
+		return nil, err
+	}
+	limits, err := getLimits()
+	if err != nil {
+		return nil, err
+	}
+	return scaler.New(*argHousekeepingTick, myActuator, myAggregator, policyConfig, limits, kubeClient, *argShapeCostsFile, *argNodeCreationTimeout, *argRetryNodeCreation, clock)
+}
+
 func main() {
 	flag.Parse()
-	autoScaler, err := scaler.New(*argHousekeepingTick, *argActuatorHostPort, *argAggregatorHostPort, *argClusterScalingPolicy, *argThreshold)
+	if *argSimulationFile != "" {
+		if err := simulate(os.Stdout); err != nil {
+			glog.Fatal(err)
+		}
+		return
+	}
+	kubeClient, err := client.New(clientConfig)
+	if err != nil {
+		glog.Fatalf("Invalid API configuration: %v", err)
+	}
+	record.StartRecording(kubeClient.Events(""), api.EventSource{Component: "scaler"})
+	provisionerClient, err := apiclient.NewProvisioner(getClientConfig(*argActuatorHostPort))
+	if err != nil {
+		glog.Fatalf("Failed to create actuator %q", err)
+	}
+	myActuator := actuator.New(provisionerClient, util.RealClock{})
+	statsCollectorClient, err := apiclient.NewStatsCollector(getClientConfig(*argAggregatorHostPort))
+	if err != nil {
+		glog.Fatalf("Failed to create aggregator %q", err)
+	}
+	myAggregator := aggregator.New(statsCollectorClient)
+	if *argRecordFile != "" {
+		out, err := os.OpenFile(*argRecordFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			glog.Fatalf("Failed to open record file %q", err)
+		}
+		defer out.Close()
+		myAggregator = aggregator.NewRecorder(myAggregator, out)
+	}
+	autoScaler, err := newScaler(myActuator, myAggregator, kubeClient, util.RealClock{})
 	if err != nil {
 		glog.Fatal(err)
 	}
+	if *argStatusPort != 0 {
+		go func() {
+			glog.Fatal(http.ListenAndServe(fmt.Sprintf("%s:%d", *argStatusIp, *argStatusPort), scaler.NewStatusServer(autoScaler)))
+		}()
+	}
 	if err = autoScaler.AutoScale(); err != nil {
 		glog.Fatal(err)
 	}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/record"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/aggregator"
+	"github.com/golang/glog"
+)
+
+var argSimulationFile = flag.String("simulation_file", "", "Run in simulation mode, replaying the cluster stats recorded with --record_file. A timeline of the scaling decisions is written to stdout.")
+
+var argSimulationInstanceTypesFile = flag.String("simulation_instance_types_file", "", "JSON file with the instance types available in simulation mode, as returned by the /instance_types endpoint of the provisioner.")
+
+var argSimulationDefaultInstanceType = flag.String("simulation_default_instance_type", "n1-standard-1", "The default instance type in simulation mode.")
+
+var argSimulationNodeCreationDelay = flag.Duration("simulation_node_creation_delay", 2*time.Minute, "Time for a new node to join the cluster in simulation mode.")
+
+// Returns the node shapes listed in the instance types file.
+func readNodeShapes(path string) (actuator.NodeShapes, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return actuator.NodeShapes{}, fmt.Errorf("failed to read instance types - %q", err)
+	}
+	var instanceTypes map[string]api.NodeResources
+	if err := json.Unmarshal(data, &instanceTypes); err != nil {
+		return actuator.NodeShapes{}, fmt.Errorf("failed to parse instance types in %s - %q", path, err)
+	}
+	if len(instanceTypes) == 0 {
+		return actuator.NodeShapes{}, fmt.Errorf("no instance types in %s", path)
+	}
+	return actuator.NewNodeShapesFromInstanceTypes(instanceTypes), nil
+}
+
+// Replays the recorded cluster stats against a scaler configured by the command line flags.
+func simulate(out io.Writer) error {
+	in, err := os.Open(*argSimulationFile)
+	if err != nil {
+		return fmt.Errorf("failed to open simulation file %q", err)
+	}
+	defer in.Close()
+	snapshots, err := aggregator.ReadSnapshots(in)
+	if err != nil {
+		return err
+	}
+	shapes, err := readNodeShapes(*argSimulationInstanceTypesFile)
+	if err != nil {
+		return err
+	}
+	simulation, err := scaler.NewSimulation(snapshots, shapes, *argSimulationDefaultInstanceType, *argSimulationNodeCreationDelay)
+	if err != nil {
+		return err
+	}
+	record.StartLogging(glog.Infof)
+	autoScaler, err := newScaler(simulation.Actuator(), simulation.Aggregator(), simulation.KubeClient(), simulation.Clock())
+	if err != nil {
+		return err
+	}
+	return simulation.Run(autoScaler, *argHousekeepingTick, out)
+}
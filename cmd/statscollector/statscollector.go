@@ -17,10 +17,10 @@ limitations under the License.
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/statscollector"
 	"github.com/golang/glog"
@@ -31,16 +31,13 @@ var port = flag.Int("port", 8085, "The port to listen on for connections.")
 var fake = flag.Bool("fake", false, "Use fake services.")
 var kubeMaster = flag.String("kubernetes_master_readonly", "", "IP for kubernetes master read-only API.")
 var kubeletPort = flag.Int("kubelet_port", 10250, "Kubelet port")
-
-func writeResult(res interface{}, w http.ResponseWriter) error {
-	out, err := json.Marshal(res)
-	if err != nil {
-		return fmt.Errorf("failed to marshal response %+v with error: %s", res, err)
-	}
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(out)
-	return nil
-}
+var pollInterval = flag.Duration("poll_interval", 1*time.Minute, "Interval between polling nodes for stats. Hour and day usage are derived from samples collected at this interval.")
+var pollTimeout = flag.Duration("poll_timeout", 20*time.Second, "Time allowed for a node to respond to a stats request. Must not exceed the poll interval.")
+var pollWorkers = flag.Int("poll_workers", 20, "Maximum number of nodes polled concurrently.")
+var percentiles = flag.String("percentiles", "50,95,99", "Comma separated list of percentiles to report in addition to mean, max and 90th percentile.")
+var checkpointFile = flag.String("checkpoint_file", "", "File to checkpoint the usage history to, so that it survives restarts. Checkpointing is disabled if empty.")
+var checkpointInterval = flag.Duration("checkpoint_interval", 5*time.Minute, "Interval between checkpoints of the usage history.")
+var checkpointMaxAge = flag.Duration("checkpoint_max_age", 1*time.Hour, "Checkpoints older than this are discarded on startup.")
 
 func main() {
 	flag.Parse()
@@ -70,38 +67,27 @@ func main() {
 		if err != nil {
 			glog.Fatal(err)
 		}
-		nodeApi, err = statscollector.NewKubeNodeApi(*kubeletPort)
+		nodeApi, err = statscollector.NewKubeNodeApi(*kubeletPort, *pollTimeout)
 		if err != nil {
 			glog.Fatal(err)
 		}
 	}
-	statscollector, err := statscollector.New(nodeApi, clusterApi, *pollInterval)
+	reportedPercentiles, err := statscollector.ParsePercentiles(*percentiles)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	aggregator, err := statscollector.New(nodeApi, clusterApi, *pollInterval, *pollTimeout, *pollWorkers, reportedPercentiles, *checkpointFile, *checkpointInterval, *checkpointMaxAge)
 	if err != nil {
 		glog.Fatal(err)
 	}
 
-	err = statscollector.Start()
+	err = aggregator.Start()
 	if err != nil {
 		glog.Fatal(err)
 	}
-	defer statscollector.Stop()
+	defer aggregator.Stop()
 
-	http.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
-		// TODO(jnagal): Add a request to return specific data.
-		nodeData, err := statscollector.GetNodeStats()
-		if err != nil {
-			glog.Info("Failed to get data from statscollector: %s", err)
-			http.Error(w, err.Error(), 500)
-			return
-		}
-		err = writeResult(nodeData, w)
-		if err != nil {
-			glog.Errorf("Failed to write node data %+v", nodeData)
-			http.Error(w, err.Error(), 500)
-			return
-		}
-		return
-	})
+	http.Handle("/", statscollector.NewServer(aggregator))
 
 	glog.Fatal(http.ListenAndServe(fmt.Sprintf("%s:%d", *address, *port), nil))
 }
@@ -29,6 +29,8 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/validation"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/registrytest"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	schedulerapi "github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler/api"
+	schedulervalidation "github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler/api/validation"
 	"github.com/golang/glog"
 )
 
@@ -203,3 +205,17 @@ func TestReadme(t *testing.T) {
 		}
 	}
 }
+
+func TestSchedulerPolicyExample(t *testing.T) {
+	data, err := ioutil.ReadFile("../examples/scheduler-policy-config.json")
+	if err != nil {
+		t.Fatalf("Unable to read the scheduler policy example: %v", err)
+	}
+	policy, err := schedulerapi.ParsePolicy(data)
+	if err != nil {
+		t.Fatalf("Scheduler policy example did not decode correctly: %v", err)
+	}
+	if err := schedulervalidation.ValidatePolicy(policy); err != nil {
+		t.Errorf("Scheduler policy example did not validate correctly: %v", err)
+	}
+}
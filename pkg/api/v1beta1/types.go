@@ -395,6 +395,42 @@ type RestartPolicy struct {
 	Never     *RestartPolicyNever     `json:"never,omitempty" description:"never restart the container"`
 }
 
+// Affinity constrains the placement of a pod relative to the pods already scheduled.
+type Affinity struct {
+	// PodAffinity places the pod near the pods it selects.
+	PodAffinity *PodAffinity `json:"podAffinity,omitempty" description:"rules placing the pod near the pods they select"`
+	// PodAntiAffinity keeps the pod away from the pods it selects.
+	PodAntiAffinity *PodAffinity `json:"podAntiAffinity,omitempty" description:"rules keeping the pod away from the pods they select"`
+}
+
+// PodAffinity holds the terms of pod affinity or anti-affinity rules.
+type PodAffinity struct {
+	// Required terms must all be satisfied for the pod to be scheduled on a node.
+	Required []PodAffinityTerm `json:"required,omitempty" description:"terms that must all be satisfied for the pod to be scheduled on a node"`
+	// Preferred terms favor the nodes satisfying them, in proportion to their weight.
+	Preferred []PodAffinityTerm `json:"preferred,omitempty" description:"terms favoring the nodes that satisfy them, in proportion to their weight"`
+}
+
+// PodAffinityTerm selects a set of pods in the namespace of the pod, and defines what
+// being near them means.
+type PodAffinityTerm struct {
+	// Selector is a label query over the pods in the namespace of the pod.
+	Selector map[string]string `json:"selector" description:"label keys and values that select the pods the term applies to, in the namespace of the pod"`
+	// TopologyKey is a node label. Pods are near each other if they run on nodes with the
+	// same value of the label. If empty, pods are near each other only on the same node.
+	TopologyKey string `json:"topologyKey,omitempty" description:"node label whose value defines which nodes are near each other; the same node if empty"`
+	// Weight of a preferred term, between 1 and 100. Must be zero for required terms.
+	Weight int `json:"weight,omitempty" description:"weight of a preferred term, between 1 and 100; zero for required terms"`
+}
+
+// PodGroup names a group of pods that are bound together once enough of them fit, or not at all.
+type PodGroup struct {
+	// Name of the group, shared by its members in the namespace of the pod.
+	Name string `json:"name" description:"name of the group, shared by its members in the namespace of the pod"`
+	// MinMembers is the number of members that must fit before any of them is bound.
+	MinMembers int `json:"minMembers" description:"number of members that must fit before any of them is bound"`
+}
+
 // PodState is the state of a pod, used as either input (desired state) or output (current state).
 type PodState struct {
 	Manifest ContainerManifest `json:"manifest,omitempty" description:"manifest of containers and volumes comprising the pod"`
@@ -425,6 +461,13 @@ type Pod struct {
 	CurrentState PodState          `json:"currentState,omitempty" description:"current state of the pod"`
 	// NodeSelector is a selector which must be true for the pod to fit on a node
 	NodeSelector map[string]string `json:"nodeSelector,omitempty" description:"selector which must match a node's labels for the pod to be scheduled on that node"`
+	// Optional: Affinity constrains the placement of the pod relative to other pods.
+	Affinity *Affinity `json:"affinity,omitempty" description:"rules placing the pod near or away from other pods"`
+	// Optional: Priority of the pod. When no node fits the pod, pods with a lower priority
+	// may be preempted to make room for it. Defaults to 0.
+	Priority int `json:"priority,omitempty" description:"priority of the pod; pods with a lower priority may be preempted to make room for it when no node fits; defaults to 0"`
+	// Optional: Group makes the pod a member of a group of pods that are scheduled together.
+	Group *PodGroup `json:"group,omitempty" description:"group of pods the pod is scheduled together with"`
 }
 
 // ReplicationControllerState is the state of a replication controller, either input (create, update) or as output (list, get).
@@ -452,6 +495,12 @@ type ReplicationController struct {
 type PodTemplate struct {
 	DesiredState PodState          `json:"desiredState,omitempty" description:"specification of the desired state of pods created from this template"`
 	Labels       map[string]string `json:"labels,omitempty" description:"map of string keys and values that can be used to organize and categorize the pods created from the template; must match the selector of the replication controller to which the template belongs; may match selectors of services"`
+	// Optional: Affinity constrains the placement of the pods created from this template relative to other pods.
+	Affinity *Affinity `json:"affinity,omitempty" description:"rules placing the pods created from the template near or away from other pods"`
+	// Optional: Priority of the pods created from this template. Defaults to 0.
+	Priority int `json:"priority,omitempty" description:"priority of the pods created from the template; defaults to 0"`
+	// Optional: Group makes the pods created from this template members of a group of pods that are scheduled together.
+	Group *PodGroup `json:"group,omitempty" description:"group of pods the pods created from the template are scheduled together with"`
 }
 
 // Session Affinity Type string
@@ -855,6 +904,13 @@ type PodSpec struct {
 	DNSPolicy DNSPolicy `json:"dnsPolicy,omitempty" description:"DNS policy for containers within the pod; one of 'ClusterFirst' or 'Default'"`
 	// NodeSelector is a selector which must be true for the pod to fit on a node
 	NodeSelector map[string]string `json:"nodeSelector,omitempty" description:"selector which must match a node's labels for the pod to be scheduled on that node"`
+	// Optional: Affinity constrains the placement of the pod relative to other pods.
+	Affinity *Affinity `json:"affinity,omitempty" description:"rules placing the pod near or away from other pods"`
+	// Optional: Priority of the pod. When no node fits the pod, pods with a lower priority
+	// may be preempted to make room for it. Defaults to 0.
+	Priority int `json:"priority,omitempty" description:"priority of the pod; pods with a lower priority may be preempted to make room for it when no node fits; defaults to 0"`
+	// Optional: Group makes the pod a member of a group of pods that are scheduled together.
+	Group *PodGroup `json:"group,omitempty" description:"group of pods the pod is scheduled together with"`
 
 	// Host is a request to schedule this pod onto a specific host.  If it is non-empty,
 	// the the scheduler simply schedules this pod onto that host, assuming that it fits
@@ -168,6 +168,13 @@ func init() {
 			if err := s.Convert(&in.Spec.NodeSelector, &out.NodeSelector, 0); err != nil {
 				return err
 			}
+			if err := s.Convert(&in.Spec.Affinity, &out.Affinity, 0); err != nil {
+				return err
+			}
+			out.Priority = in.Spec.Priority
+			if err := s.Convert(&in.Spec.Group, &out.Group, 0); err != nil {
+				return err
+			}
 			return nil
 		},
 		func(in *Pod, out *newer.Pod, s conversion.Scope) error {
@@ -190,6 +197,13 @@ func init() {
 			if err := s.Convert(&in.NodeSelector, &out.Spec.NodeSelector, 0); err != nil {
 				return err
 			}
+			if err := s.Convert(&in.Affinity, &out.Spec.Affinity, 0); err != nil {
+				return err
+			}
+			out.Spec.Priority = in.Priority
+			if err := s.Convert(&in.Group, &out.Spec.Group, 0); err != nil {
+				return err
+			}
 			return nil
 		},
 
@@ -263,6 +277,13 @@ func init() {
 			if err := s.Convert(&in.ObjectMeta.Labels, &out.Labels, 0); err != nil {
 				return err
 			}
+			if err := s.Convert(&in.Spec.Affinity, &out.Affinity, 0); err != nil {
+				return err
+			}
+			out.Priority = in.Spec.Priority
+			if err := s.Convert(&in.Spec.Group, &out.Group, 0); err != nil {
+				return err
+			}
 			return nil
 		},
 		func(in *PodTemplate, out *newer.PodTemplateSpec, s conversion.Scope) error {
@@ -273,6 +294,13 @@ func init() {
 			if err := s.Convert(&in.Labels, &out.ObjectMeta.Labels, 0); err != nil {
 				return err
 			}
+			if err := s.Convert(&in.Affinity, &out.Spec.Affinity, 0); err != nil {
+				return err
+			}
+			out.Spec.Priority = in.Priority
+			if err := s.Convert(&in.Group, &out.Spec.Group, 0); err != nil {
+				return err
+			}
 			return nil
 		},
 
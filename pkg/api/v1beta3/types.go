@@ -480,6 +480,13 @@ type PodSpec struct {
 	DNSPolicy DNSPolicy `json:"dnsPolicy,omitempty"`
 	// NodeSelector is a selector which must be true for the pod to fit on a node
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Optional: Affinity constrains the placement of the pod relative to other pods.
+	Affinity *Affinity `json:"affinity,omitempty"`
+	// Optional: Priority of the pod. When no node fits the pod, pods with a lower priority
+	// may be preempted to make room for it. Defaults to 0.
+	Priority int `json:"priority,omitempty"`
+	// Optional: Group makes the pod a member of a group of pods that are scheduled together.
+	Group *PodGroup `json:"group,omitempty"`
 
 	// Host is a request to schedule this pod onto a specific host.  If it is non-empty,
 	// the the scheduler simply schedules this pod onto that host, assuming that it fits
@@ -487,6 +494,42 @@ type PodSpec struct {
 	Host string `json:"host,omitempty" description:"host requested for this pod"`
 }
 
+// Affinity constrains the placement of a pod relative to the pods already scheduled.
+type Affinity struct {
+	// PodAffinity places the pod near the pods it selects.
+	PodAffinity *PodAffinity `json:"podAffinity,omitempty"`
+	// PodAntiAffinity keeps the pod away from the pods it selects.
+	PodAntiAffinity *PodAffinity `json:"podAntiAffinity,omitempty"`
+}
+
+// PodAffinity holds the terms of pod affinity or anti-affinity rules.
+type PodAffinity struct {
+	// Required terms must all be satisfied for the pod to be scheduled on a node.
+	Required []PodAffinityTerm `json:"required,omitempty"`
+	// Preferred terms favor the nodes satisfying them, in proportion to their weight.
+	Preferred []PodAffinityTerm `json:"preferred,omitempty"`
+}
+
+// PodAffinityTerm selects a set of pods in the namespace of the pod, and defines what
+// being near them means.
+type PodAffinityTerm struct {
+	// Selector is a label query over the pods in the namespace of the pod.
+	Selector map[string]string `json:"selector"`
+	// TopologyKey is a node label. Pods are near each other if they run on nodes with the
+	// same value of the label. If empty, pods are near each other only on the same node.
+	TopologyKey string `json:"topologyKey,omitempty"`
+	// Weight of a preferred term, between 1 and 100. Must be zero for required terms.
+	Weight int `json:"weight,omitempty"`
+}
+
+// PodGroup names a group of pods that are bound together once enough of them fit, or not at all.
+type PodGroup struct {
+	// Name of the group, shared by its members in the namespace of the pod.
+	Name string `json:"name"`
+	// MinMembers is the number of members that must fit before any of them is bound.
+	MinMembers int `json:"minMembers"`
+}
+
 // PodStatus represents information about the status of a pod. Status may trail the actual
 // state of a system.
 type PodStatus struct {
@@ -396,6 +396,67 @@ func ValidatePodSpec(spec *api.PodSpec) errs.ValidationErrorList {
 	allErrs = append(allErrs, validateRestartPolicy(&spec.RestartPolicy).Prefix("restartPolicy")...)
 	allErrs = append(allErrs, validateDNSPolicy(&spec.DNSPolicy).Prefix("dnsPolicy")...)
 	allErrs = append(allErrs, validateLabels(spec.NodeSelector, "nodeSelector")...)
+	if spec.Affinity != nil {
+		allErrs = append(allErrs, validateAffinity(spec.Affinity).Prefix("affinity")...)
+	}
+	if spec.Group != nil {
+		allErrs = append(allErrs, validatePodGroup(spec.Group).Prefix("group")...)
+	}
+	return allErrs
+}
+
+func validatePodGroup(group *api.PodGroup) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if group.Name == "" {
+		allErrs = append(allErrs, errs.NewFieldRequired("name", group.Name))
+	} else if !util.IsDNSSubdomain(group.Name) {
+		allErrs = append(allErrs, errs.NewFieldInvalid("name", group.Name, ""))
+	}
+	if group.MinMembers < 1 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("minMembers", group.MinMembers, "must be at least 1"))
+	}
+	return allErrs
+}
+
+func validateAffinity(affinity *api.Affinity) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if affinity.PodAffinity != nil {
+		allErrs = append(allErrs, validatePodAffinity(affinity.PodAffinity).Prefix("podAffinity")...)
+	}
+	if affinity.PodAntiAffinity != nil {
+		allErrs = append(allErrs, validatePodAffinity(affinity.PodAntiAffinity).Prefix("podAntiAffinity")...)
+	}
+	return allErrs
+}
+
+func validatePodAffinity(podAffinity *api.PodAffinity) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	for i, term := range podAffinity.Required {
+		termErrs := validatePodAffinityTerm(&term)
+		if term.Weight != 0 {
+			termErrs = append(termErrs, errs.NewFieldInvalid("weight", term.Weight, "must be zero for required terms"))
+		}
+		allErrs = append(allErrs, termErrs.PrefixIndex(i).Prefix("required")...)
+	}
+	for i, term := range podAffinity.Preferred {
+		termErrs := validatePodAffinityTerm(&term)
+		if term.Weight < 1 || term.Weight > 100 {
+			termErrs = append(termErrs, errs.NewFieldInvalid("weight", term.Weight, "must be between 1 and 100"))
+		}
+		allErrs = append(allErrs, termErrs.PrefixIndex(i).Prefix("preferred")...)
+	}
+	return allErrs
+}
+
+func validatePodAffinityTerm(term *api.PodAffinityTerm) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if len(term.Selector) == 0 {
+		allErrs = append(allErrs, errs.NewFieldRequired("selector", term.Selector))
+	}
+	allErrs = append(allErrs, validateLabels(term.Selector, "selector")...)
+	if term.TopologyKey != "" && !util.IsQualifiedName(term.TopologyKey) {
+		allErrs = append(allErrs, errs.NewFieldInvalid("topologyKey", term.TopologyKey, ""))
+	}
 	return allErrs
 }
 
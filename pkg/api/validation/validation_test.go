@@ -437,6 +437,17 @@ func TestValidatePodSpec(t *testing.T) {
 				"key": "value",
 			},
 			Host: "foobar",
+			Affinity: &api.Affinity{
+				PodAffinity: &api.PodAffinity{
+					Required:  []api.PodAffinityTerm{{Selector: map[string]string{"app": "db"}, TopologyKey: "zone"}},
+					Preferred: []api.PodAffinityTerm{{Selector: map[string]string{"app": "cache"}, Weight: 10}},
+				},
+				PodAntiAffinity: &api.PodAffinity{
+					Required: []api.PodAffinityTerm{{Selector: map[string]string{"app": "web"}}},
+				},
+			},
+			Priority: 10,
+			Group:    &api.PodGroup{Name: "training", MinMembers: 4},
 		},
 	}
 	for i := range successCases {
@@ -455,6 +466,24 @@ func TestValidatePodSpec(t *testing.T) {
 		"bad DNS policy": {
 			DNSPolicy: api.DNSPolicy("invalid"),
 		},
+		"empty affinity selector": {
+			Affinity: &api.Affinity{PodAffinity: &api.PodAffinity{Required: []api.PodAffinityTerm{{TopologyKey: "zone"}}}},
+		},
+		"bad affinity topology key": {
+			Affinity: &api.Affinity{PodAffinity: &api.PodAffinity{Required: []api.PodAffinityTerm{{Selector: map[string]string{"app": "db"}, TopologyKey: "-zone"}}}},
+		},
+		"weighted required anti-affinity": {
+			Affinity: &api.Affinity{PodAntiAffinity: &api.PodAffinity{Required: []api.PodAffinityTerm{{Selector: map[string]string{"app": "web"}, Weight: 1}}}},
+		},
+		"unweighted preferred anti-affinity": {
+			Affinity: &api.Affinity{PodAntiAffinity: &api.PodAffinity{Preferred: []api.PodAffinityTerm{{Selector: map[string]string{"app": "web"}}}}},
+		},
+		"unnamed group": {
+			Group: &api.PodGroup{MinMembers: 2},
+		},
+		"group without members": {
+			Group: &api.PodGroup{Name: "training"},
+		},
 	}
 	for k, v := range failureCases {
 		if errs := ValidatePodSpec(&v); len(errs) == 0 {
@@ -193,6 +193,10 @@ func (v *AWSCloud) Add(name, ipRange, instanceType string) error {
 	return fmt.Errorf("Add() unimplemented")
 }
 
+func (v *AWSCloud) Delete(name string) error {
+	return fmt.Errorf("Delete() unimplemented")
+}
+
 func (v *AWSCloud) InstanceTypes() (map[string]api.NodeResources, error) {
 	return nil, fmt.Errorf("InstanceTypes() unimplemented")
 }
@@ -65,6 +65,8 @@ type Instances interface {
 	GetNodeResources(name string) (*api.NodeResources, error)
 	// Add an instance of the specified type. The type must be as returned from InstanceTypes().
 	Add(name, ipRange, instanceType string) error
+	// Delete the specified instance along with the resources created for it by Add().
+	Delete(name string) error
 	// Returns the set of supported instance types and their size.
 	InstanceTypes() (map[string]api.NodeResources, error)
 }
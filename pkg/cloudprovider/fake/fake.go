@@ -139,6 +139,11 @@ func (f *FakeCloud) Add(name, ipRange, instanceType string) error {
 	return f.Err
 }
 
+func (f *FakeCloud) Delete(name string) error {
+	f.addCall("delete-instance")
+	return f.Err
+}
+
 func (f *FakeCloud) InstanceTypes() (map[string]api.NodeResources, error) {
 	f.addCall("instance-types")
 	return f.InstanceTypesValue, f.Err
@@ -0,0 +1,209 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake_cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/resources"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+func init() {
+	cloudprovider.RegisterCloudProvider("fake", func(config io.Reader) (cloudprovider.Interface, error) { return newMemoryCloud(config) })
+}
+
+// An instance of the in-memory cloud.
+type memoryInstance struct {
+	ipRange      string
+	instanceType string
+	ip           net.IP
+}
+
+// MemoryCloud is an in-memory implementation of Interface and Instances. Instances
+// are only kept in memory, so that it can stand in for a real cloud provider in
+// tests and local setups.
+type MemoryCloud struct {
+	lock          sync.Mutex
+	instanceTypes map[string]api.NodeResources
+	// Map of instance name to instance.
+	instances map[string]memoryInstance
+	// Number of instances added so far, used to hand out IP addresses.
+	numAdded int
+}
+
+func makeMemoryResources(cores int, memoryMb int) api.NodeResources {
+	return api.NodeResources{
+		Capacity: api.ResourceList{
+			resources.CPU:    util.NewIntOrStringFromInt(cores * 1000),
+			resources.Memory: util.NewIntOrStringFromInt(memoryMb * 1024 * 1024),
+		},
+	}
+}
+
+// The instance types of an in-memory cloud without a configuration. They are
+// named after the GCE ones so that the defaults of the provisioner work with both.
+func defaultMemoryInstanceTypes() map[string]api.NodeResources {
+	return map[string]api.NodeResources{
+		"n1-standard-1": makeMemoryResources(1, 3840),
+		"n1-standard-2": makeMemoryResources(2, 7680),
+		"n1-standard-4": makeMemoryResources(4, 15360),
+	}
+}
+
+// Returns an in-memory cloud. The optional 'config' is a JSON map of instance
+// type name to api.NodeResources, and replaces the default instance types.
+func newMemoryCloud(config io.Reader) (*MemoryCloud, error) {
+	instanceTypes := defaultMemoryInstanceTypes()
+	if config != nil {
+		instanceTypes = map[string]api.NodeResources{}
+		if err := json.NewDecoder(config).Decode(&instanceTypes); err != nil {
+			return nil, fmt.Errorf("failed to parse the instance types: %v", err)
+		}
+		if len(instanceTypes) == 0 {
+			return nil, fmt.Errorf("no instance types configured")
+		}
+	}
+	return NewMemoryCloud(instanceTypes), nil
+}
+
+// NewMemoryCloud returns an in-memory cloud without instances, that supports 'instanceTypes'.
+func NewMemoryCloud(instanceTypes map[string]api.NodeResources) *MemoryCloud {
+	return &MemoryCloud{
+		instanceTypes: instanceTypes,
+		instances:     make(map[string]memoryInstance),
+	}
+}
+
+func (m *MemoryCloud) TCPLoadBalancer() (cloudprovider.TCPLoadBalancer, bool) {
+	return nil, false
+}
+
+func (m *MemoryCloud) Instances() (cloudprovider.Instances, bool) {
+	return m, true
+}
+
+func (m *MemoryCloud) Zones() (cloudprovider.Zones, bool) {
+	return nil, false
+}
+
+func (m *MemoryCloud) Clusters() (cloudprovider.Clusters, bool) {
+	return nil, false
+}
+
+func (m *MemoryCloud) getInstance(name string) (memoryInstance, error) {
+	instance, ok := m.instances[name]
+	if !ok {
+		return memoryInstance{}, fmt.Errorf("instance %q not found", name)
+	}
+	return instance, nil
+}
+
+// IPAddress is an implementation of Instances.IPAddress.
+func (m *MemoryCloud) IPAddress(name string) (net.IP, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	instance, err := m.getInstance(name)
+	if err != nil {
+		return nil, err
+	}
+	return instance.ip, nil
+}
+
+// List is an implementation of Instances.List. The instances are sorted by name.
+func (m *MemoryCloud) List(filter string) ([]string, error) {
+	re, err := regexp.Compile("^(?:" + filter + ")$")
+	if err != nil {
+		return nil, err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	result := []string{}
+	for name := range m.instances {
+		if re.MatchString(name) {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// GetNodeResources is an implementation of Instances.GetNodeResources.
+func (m *MemoryCloud) GetNodeResources(name string) (*api.NodeResources, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	instance, err := m.getInstance(name)
+	if err != nil {
+		return nil, err
+	}
+	resources := m.instanceTypes[instance.instanceType]
+	return &resources, nil
+}
+
+// Add is an implementation of Instances.Add.
+func (m *MemoryCloud) Add(name, ipRange, instanceType string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.instanceTypes[instanceType]; !ok {
+		return fmt.Errorf("unknown instance type %q", instanceType)
+	}
+	if _, ok := m.instances[name]; ok {
+		return fmt.Errorf("instance %q already exists", name)
+	}
+	m.numAdded++
+	m.instances[name] = memoryInstance{
+		ipRange:      ipRange,
+		instanceType: instanceType,
+		ip:           net.IPv4(10, 240, byte(m.numAdded/256), byte(m.numAdded%256)),
+	}
+	return nil
+}
+
+// Delete is an implementation of Instances.Delete.
+func (m *MemoryCloud) Delete(name string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, err := m.getInstance(name); err != nil {
+		return err
+	}
+	delete(m.instances, name)
+	return nil
+}
+
+// InstanceTypes is an implementation of Instances.InstanceTypes.
+func (m *MemoryCloud) InstanceTypes() (map[string]api.NodeResources, error) {
+	return m.instanceTypes, nil
+}
+
+// IPRange returns the IP range the instance 'name' was added with.
+func (m *MemoryCloud) IPRange(name string) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	instance, err := m.getInstance(name)
+	if err != nil {
+		return "", err
+	}
+	return instance.ipRange, nil
+}
@@ -620,6 +620,48 @@ func (gce *GCECloud) Add(name, ipRange, instanceType string) error {
 	return nil
 }
 
+// Delete the instance and the route, firewall and disk created for it by Add(), in the reverse order.
+func (gce *GCECloud) Delete(name string) error {
+	name = canonicalizeInstanceName(name)
+	instanceOp, err := gce.service.Instances.Delete(gce.projectID, gce.zone, name).Do()
+	if err != nil {
+		return fmt.Errorf("failed to delete instance with error: %v", err)
+	}
+	err = gce.waitForZoneOp(instanceOp)
+	if err != nil {
+		return fmt.Errorf("failed to delete instance while waiting for completion with error: %v", err)
+	}
+
+	routeOp, err := gce.service.Routes.Delete(gce.projectID, name).Do()
+	if err != nil {
+		return fmt.Errorf("failed to delete route with error: %v", err)
+	}
+	err = gce.waitForGlobalOp(routeOp)
+	if err != nil {
+		return fmt.Errorf("failed to delete route while waiting for completion with error: %v", err)
+	}
+
+	firewallOp, err := gce.service.Firewalls.Delete(gce.projectID, fmt.Sprintf("%s-all", name)).Do()
+	if err != nil {
+		return fmt.Errorf("failed to delete firewall with error: %v", err)
+	}
+	err = gce.waitForGlobalOp(firewallOp)
+	if err != nil {
+		return fmt.Errorf("failed to delete firewall while waiting for completion with error: %v", err)
+	}
+
+	diskOp, err := gce.service.Disks.Delete(gce.projectID, gce.zone, name).Do()
+	if err != nil {
+		return fmt.Errorf("failed to delete disk with error: %v", err)
+	}
+	err = gce.waitForZoneOp(diskOp)
+	if err != nil {
+		return fmt.Errorf("failed to delete disk while waiting for completion with error: %v", err)
+	}
+
+	return nil
+}
+
 func (gce *GCECloud) InstanceTypes() (map[string]api.NodeResources, error) {
 	// TODO: Get this dynamically.
 	return map[string]api.NodeResources{
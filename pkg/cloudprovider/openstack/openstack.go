@@ -346,6 +346,10 @@ func (i *Instances) Add(name, ipRange, instanceType string) error {
 	return fmt.Errorf("Add() unimplemented")
 }
 
+func (i *Instances) Delete(name string) error {
+	return fmt.Errorf("Delete() unimplemented")
+}
+
 func (i *Instances) InstanceTypes() (map[string]api.NodeResources, error) {
 	return nil, fmt.Errorf("InstanceTypes() unimplemented")
 }
@@ -168,6 +168,10 @@ func (v *OVirtCloud) Add(name, ipRange, instanceType string) error {
 	return fmt.Errorf("Add() unimplemented")
 }
 
+func (v *OVirtCloud) Delete(name string) error {
+	return fmt.Errorf("Delete() unimplemented")
+}
+
 func (v *OVirtCloud) InstanceTypes() (map[string]api.NodeResources, error) {
 	return nil, fmt.Errorf("InstanceTypes() unimplemented")
 }
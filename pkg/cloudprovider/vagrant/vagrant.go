@@ -223,6 +223,10 @@ func (v *VagrantCloud) Add(name, ipRange, instanceType string) error {
 	return fmt.Errorf("Add() unimplemented")
 }
 
+func (v *VagrantCloud) Delete(name string) error {
+	return fmt.Errorf("Delete() unimplemented")
+}
+
 func (v *VagrantCloud) InstanceTypes() (map[string]api.NodeResources, error) {
 	return nil, fmt.Errorf("InstanceTypes() unimplemented")
 }
@@ -0,0 +1,160 @@
+package provisioner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+// Instance indices map to the pod CIDRs 10.244.<index>.0/24.
+const (
+	minInstanceIndex = 1
+	maxInstanceIndex = 255
+)
+
+// Matches the names of the instances created by the provisioner, with or without their fqdn suffix.
+var instanceNameRegexp = regexp.MustCompile(`^kubernetes-minion-(\d+)(\..*)?$`)
+
+// Filter for cloudprovider.Instances.List() that matches the instances created by the provisioner.
+const instanceNameFilter = `kubernetes-minion-\d+(\..*)?`
+
+func getInstanceName(index int) string {
+	return fmt.Sprintf("kubernetes-minion-%d", index)
+}
+
+func getInstanceIpRange(index int) string {
+	return fmt.Sprintf("10.244.%d.0/24", index)
+}
+
+// Returns the index of the instance 'name', or false if the provisioner did not name it.
+func instanceIndex(name string) (int, bool) {
+	match := instanceNameRegexp.FindStringSubmatch(name)
+	if match == nil {
+		return 0, false
+	}
+	index, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return index, true
+}
+
+// The persisted state of the allocator.
+type allocatorState struct {
+	// Indices handed out and not released yet.
+	Allocated []int `json:"allocated"`
+}
+
+// Hands out the indices the names and pod CIDRs of new instances are derived from.
+// An index is never handed out while it is allocated or used by a live instance.
+// Allocations are persisted so that instances still being created when the
+// provisioner restarts keep their index.
+type allocator struct {
+	lock sync.Mutex
+	// File the allocations are persisted to. Allocations are only kept in memory if empty.
+	stateFile string
+	allocated map[int]bool
+}
+
+// Returns an allocator with the allocations persisted in 'stateFile', if any.
+func newAllocator(stateFile string) (*allocator, error) {
+	self := &allocator{
+		stateFile: stateFile,
+		allocated: make(map[int]bool),
+	}
+	if stateFile == "" {
+		return self, nil
+	}
+	data, err := ioutil.ReadFile(stateFile)
+	if os.IsNotExist(err) {
+		glog.Infof("No allocator state found at %s", stateFile)
+		return self, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allocator state %s: %s", stateFile, err)
+	}
+	var state allocatorState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse allocator state %s: %s", stateFile, err)
+	}
+	for _, index := range state.Allocated {
+		self.allocated[index] = true
+	}
+	glog.Infof("Restored %d allocated instance indices from %s", len(self.allocated), stateFile)
+	return self, nil
+}
+
+// Write the allocations to the state file. The file is replaced atomically.
+func (self *allocator) save() error {
+	if self.stateFile == "" {
+		return nil
+	}
+	state := allocatorState{Allocated: make([]int, 0, len(self.allocated))}
+	for index := range self.allocated {
+		state.Allocated = append(state.Allocated, index)
+	}
+	sort.Ints(state.Allocated)
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	dir, name := filepath.Split(self.stateFile)
+	file, err := ioutil.TempFile(dir, name+".tmp")
+	if err != nil {
+		return err
+	}
+	// No-op once the file is renamed.
+	defer os.Remove(file.Name())
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write allocator state %s: %s", file.Name(), err)
+	}
+	return os.Rename(file.Name(), self.stateFile)
+}
+
+// Allocate the lowest index that is neither allocated nor used by one of the 'live' instances.
+func (self *allocator) allocate(live []string) (int, error) {
+	used := make(map[int]bool, len(live))
+	for _, name := range live {
+		if index, ok := instanceIndex(name); ok {
+			used[index] = true
+		}
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	for index := minInstanceIndex; index <= maxInstanceIndex; index++ {
+		if self.allocated[index] || used[index] {
+			continue
+		}
+		self.allocated[index] = true
+		if err := self.save(); err != nil {
+			delete(self.allocated, index)
+			return 0, err
+		}
+		return index, nil
+	}
+	return 0, fmt.Errorf("all %d instance indices are in use", maxInstanceIndex-minInstanceIndex+1)
+}
+
+// Release 'index' so that it can be handed out again once no live instance uses it.
+func (self *allocator) release(index int) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if !self.allocated[index] {
+		return nil
+	}
+	delete(self.allocated, index)
+	return self.save()
+}
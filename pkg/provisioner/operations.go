@@ -0,0 +1,175 @@
+package provisioner
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+// States of operations and of the instances they create.
+const (
+	// Not started yet.
+	StatePending = "pending"
+	// In progress. Instances have their name from then on.
+	StateRunning = "running"
+	// Completed successfully.
+	StateDone = "done"
+	// Completed with an error.
+	StateFailed = "failed"
+)
+
+// Progress of the creation of a single instance.
+type InstanceProgress struct {
+	InstanceType string `json:"instance_type"`
+	// Name of the instance, set once the instance is running.
+	Name  string `json:"name,omitempty"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// An asynchronous request to add instances.
+type Operation struct {
+	Id string `json:"id"`
+	// Running until all the instances are done or failed. Failed if any instance failed.
+	State     string             `json:"state"`
+	Instances []InstanceProgress `json:"instances"`
+	Created   time.Time          `json:"created"`
+	// Zero until the operation completes.
+	Finished time.Time `json:"finished,omitempty"`
+	// Order of creation within the provisioner.
+	seq int64
+}
+
+// Returns true if the operation completed, successfully or not.
+func (self *Operation) Complete() bool {
+	return self.State == StateDone || self.State == StateFailed
+}
+
+// Tracks the asynchronous operations of a provisioner. Completed operations are
+// garbage collected after a TTL.
+type operations struct {
+	lock sync.Mutex
+	// Prefix of the operation IDs, so that they are not reused after a restart.
+	idPrefix string
+	lastSeq  int64
+	// Map of operation ID to operation.
+	ops   map[string]*Operation
+	ttl   time.Duration
+	clock util.Clock
+}
+
+func newOperations(ttl time.Duration, clock util.Clock) *operations {
+	return &operations{
+		idPrefix: strconv.FormatInt(clock.Now().UnixNano(), 36),
+		ops:      make(map[string]*Operation),
+		ttl:      ttl,
+		clock:    clock,
+	}
+}
+
+// Add an operation creating instances of 'instanceTypes'. Returns a copy of the new operation.
+func (self *operations) create(instanceTypes []string) Operation {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.lastSeq++
+	op := &Operation{
+		Id:        fmt.Sprintf("%s-%d", self.idPrefix, self.lastSeq),
+		State:     StateRunning,
+		Instances: make([]InstanceProgress, 0, len(instanceTypes)),
+		Created:   self.clock.Now(),
+		seq:       self.lastSeq,
+	}
+	for _, instanceType := range instanceTypes {
+		op.Instances = append(op.Instances, InstanceProgress{
+			InstanceType: instanceType,
+			State:        StatePending,
+		})
+	}
+	if len(instanceTypes) == 0 {
+		op.State = StateDone
+		op.Finished = op.Created
+	}
+	self.ops[op.Id] = op
+	return copyOperation(op)
+}
+
+// Apply 'update' to the progress of instance 'i' of operation 'id', and
+// complete the operation once all its instances completed.
+func (self *operations) update(id string, i int, update func(progress *InstanceProgress)) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	op, ok := self.ops[id]
+	if !ok {
+		return
+	}
+	update(&op.Instances[i])
+	state := StateDone
+	for _, progress := range op.Instances {
+		switch progress.State {
+		case StatePending, StateRunning:
+			return
+		case StateFailed:
+			state = StateFailed
+		}
+	}
+	op.State = state
+	op.Finished = self.clock.Now()
+	glog.Infof("Operation %s completed: %+v", id, op.Instances)
+}
+
+// Returns a copy of the operation 'id', or false if there is no such operation.
+func (self *operations) get(id string) (Operation, bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	op, ok := self.ops[id]
+	if !ok {
+		return Operation{}, false
+	}
+	return copyOperation(op), true
+}
+
+// Returns copies of all the operations, sorted by creation.
+func (self *operations) list() []Operation {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	result := make([]Operation, 0, len(self.ops))
+	for _, op := range self.ops {
+		result = append(result, copyOperation(op))
+	}
+	sort.Sort(byCreation(result))
+	return result
+}
+
+// Garbage collect the operations that completed more than the TTL ago.
+func (self *operations) expire() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	limit := self.clock.Now().Add(-self.ttl)
+	for id, op := range self.ops {
+		if op.Complete() && op.Finished.Before(limit) {
+			delete(self.ops, id)
+		}
+	}
+}
+
+func copyOperation(op *Operation) Operation {
+	result := *op
+	result.Instances = append([]InstanceProgress{}, op.Instances...)
+	return result
+}
+
+type byCreation []Operation
+
+func (self byCreation) Len() int      { return len(self) }
+func (self byCreation) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+func (self byCreation) Less(i, j int) bool {
+	if !self[i].Created.Equal(self[j].Created) {
+		return self[i].Created.Before(self[j].Created)
+	}
+	return self[i].seq < self[j].seq
+}
@@ -0,0 +1,139 @@
+package provisioner
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
+	fake_cloud "github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider/fake"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// An in-memory cloud that fails to add instances of type "broken".
+type brokenCloud struct {
+	*fake_cloud.MemoryCloud
+}
+
+func (self brokenCloud) Instances() (cloudprovider.Instances, bool) {
+	return self, true
+}
+
+func (self brokenCloud) Add(name, ipRange, instanceType string) error {
+	if instanceType == "broken" {
+		return fmt.Errorf("out of quota")
+	}
+	return self.MemoryCloud.Add(name, ipRange, instanceType)
+}
+
+// Polls operation 'id' until it completes.
+func waitForOperation(t *testing.T, prov Provisioner, id string) Operation {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		op, found := prov.GetOperation(id)
+		if !found {
+			t.Fatalf("operation %s not found", id)
+		}
+		if op.Complete() {
+			return op
+		}
+	}
+	t.Fatalf("operation %s did not complete", id)
+	return Operation{}
+}
+
+func TestStartAddInstances(t *testing.T) {
+	cloud := brokenCloud{fake_cloud.NewMemoryCloud(map[string]api.NodeResources{
+		"small":  {},
+		"broken": {},
+	})}
+	prov, err := New(cloud, "small", "", time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := prov.StartAddInstances(AddInstancesRequest{InstanceTypes: []string{"small", "unknown"}}); err == nil {
+		t.Errorf("expected an error for an unknown instance type")
+	}
+
+	op, err := prov.StartAddInstances(AddInstancesRequest{InstanceTypes: []string{"small", "small"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if op.Complete() || len(op.Instances) != 2 {
+		t.Errorf("expected a running operation for 2 instances, got %+v", op)
+	}
+	op = waitForOperation(t, prov, op.Id)
+	if op.State != StateDone || op.Finished.IsZero() {
+		t.Errorf("expected the operation to be done, got %+v", op)
+	}
+	names := map[string]bool{}
+	for _, instance := range op.Instances {
+		if instance.State != StateDone {
+			t.Errorf("expected instance to be done, got %+v", instance)
+		}
+		names[instance.Name] = true
+	}
+	if !names["kubernetes-minion-1"] || !names["kubernetes-minion-2"] {
+		t.Errorf("expected instances kubernetes-minion-1 and kubernetes-minion-2, got %+v", op.Instances)
+	}
+
+	op, err = prov.StartAddInstances(AddInstancesRequest{InstanceTypes: []string{"broken"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	op = waitForOperation(t, prov, op.Id)
+	if op.State != StateFailed || op.Instances[0].Error != "out of quota" {
+		t.Errorf("expected the operation to fail, got %+v", op)
+	}
+	if _, found, _ := prov.GetInstance(op.Instances[0].Name); found {
+		t.Errorf("expected instance %s not to exist", op.Instances[0].Name)
+	}
+	if len(prov.ListOperations()) != 2 {
+		t.Errorf("expected 2 operations, got %+v", prov.ListOperations())
+	}
+}
+
+func TestOperationsExpire(t *testing.T) {
+	start := time.Date(2014, time.November, 1, 12, 0, 0, 0, time.UTC)
+	clock := &util.FakeClock{Time: start}
+	ops := newOperations(10*time.Minute, clock)
+	done := ops.create([]string{"small"})
+	running := ops.create([]string{"small"})
+	ops.update(done.Id, 0, func(progress *InstanceProgress) {
+		progress.Name = "kubernetes-minion-1"
+		progress.State = StateDone
+	})
+
+	clock.Time = start.Add(5 * time.Minute)
+	ops.expire()
+	if len(ops.list()) != 2 {
+		t.Errorf("expected 2 operations, got %+v", ops.list())
+	}
+
+	clock.Time = start.Add(11 * time.Minute)
+	ops.expire()
+	if _, found := ops.get(done.Id); found {
+		t.Errorf("expected completed operation %s to expire", done.Id)
+	}
+	if _, found := ops.get(running.Id); !found {
+		t.Errorf("expected running operation %s to be kept", running.Id)
+	}
+}
+
+func TestOperationIdsAfterRestart(t *testing.T) {
+	start := time.Date(2014, time.November, 1, 12, 0, 0, 0, time.UTC)
+	clock := &util.FakeClock{Time: start}
+	before := newOperations(10*time.Minute, clock).create([]string{"small"})
+	clock.Time = start.Add(time.Minute)
+	ops := newOperations(10*time.Minute, clock)
+	after := ops.create([]string{"small"})
+	if before.Id == after.Id {
+		t.Errorf("expected a new ID after a restart, got %s twice", after.Id)
+	}
+	second := ops.create([]string{"small"})
+	list := ops.list()
+	if len(list) != 2 || list[0].Id != after.Id || list[1].Id != second.Id {
+		t.Errorf("expected operations %s and %s in order, got %+v", after.Id, second.Id, list)
+	}
+}
@@ -2,10 +2,14 @@ package provisioner
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider/gce"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/golang/glog"
 )
 
@@ -14,6 +18,26 @@ type Provisioner interface {
 	// Those that are created will be returned alongside the error.
 	AddInstances(request AddInstancesRequest) ([]Instance, error)
 
+	// Starts adding instances of the specified types in the background. Returns the operation tracking them.
+	StartAddInstances(request AddInstancesRequest) (Operation, error)
+
+	// Returns the operation with the specified ID, or false if there is no such operation.
+	// Completed operations are only kept for a while.
+	GetOperation(id string) (Operation, bool)
+
+	// Lists the operations, oldest first.
+	ListOperations() []Operation
+
+	// Remove the specified instances. In the case of an error, some instances may already have been removed.
+	// Those that are removed will be returned alongside the error.
+	RemoveInstances(request RemoveInstancesRequest) ([]Instance, error)
+
+	// Lists the instances created by the provisioner.
+	ListInstances() ([]Instance, error)
+
+	// Returns the instance with the specified name, or false if there is no such instance.
+	GetInstance(name string) (Instance, bool, error)
+
 	// Gets a list of the types of instances available and their corresponding capacity.
 	InstanceTypes() (map[string]api.NodeResources, error)
 
@@ -27,18 +51,24 @@ type AddInstancesRequest struct {
 	InstanceTypes []string `json:"instance_types,omitempty"`
 }
 
+type RemoveInstancesRequest struct {
+	// Names of the instances to remove.
+	Names []string `json:"names,omitempty"`
+}
+
 type Instance struct {
 	Name         string `json:"name,omitempty"`
 	InstanceType string `json:"instance_type,omitempty"`
 }
 
-func New(defaultInstanceType string) (Provisioner, error) {
-	// TODO(vmarmol): Make this generically for any provider.
-	gce, err := gce_cloud.NewGCECloud()
-	if err != nil {
-		return nil, err
+// Returns a provisioner of the instances of 'cloud'. Allocated instance indices
+// are persisted to 'allocatorStateFile', or only kept in memory if it is empty.
+// Completed operations are garbage collected after 'operationTTL'.
+func New(cloud cloudprovider.Interface, defaultInstanceType, allocatorStateFile string, operationTTL time.Duration) (Provisioner, error) {
+	if cloud == nil {
+		return nil, fmt.Errorf("no cloud provider specified")
 	}
-	instances, valid := gce.Instances()
+	instances, valid := cloud.Instances()
 	if !valid {
 		return nil, fmt.Errorf("instance requests are not valid for the current cloud provider")
 	}
@@ -49,13 +79,27 @@ func New(defaultInstanceType string) (Provisioner, error) {
 		return nil, err
 	}
 	if _, ok := instanceTypes[defaultInstanceType]; !ok {
-		return nil, fmt.Errorf("default instance type %q is not a valid instance type")
+		return nil, fmt.Errorf("default instance type %q is not a valid instance type", defaultInstanceType)
 	}
 
+	if operationTTL <= 0 {
+		return nil, fmt.Errorf("operation TTL invalid: %v", operationTTL)
+	}
+
+	allocator, err := newAllocator(allocatorStateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	operations := newOperations(operationTTL, util.RealClock{})
+	go util.Forever(operations.expire, time.Minute)
+
 	return &prov{
-		cloudProvider:       gce,
+		cloudProvider:       cloud,
 		instances:           instances,
 		defaultInstanceType: defaultInstanceType,
+		allocator:           allocator,
+		operations:          operations,
 	}, nil
 }
 
@@ -63,36 +107,164 @@ type prov struct {
 	cloudProvider       cloudprovider.Interface
 	instances           cloudprovider.Instances
 	defaultInstanceType string
+	allocator           *allocator
+	operations          *operations
 }
 
-func (self *prov) AddInstances(request AddInstancesRequest) ([]Instance, error) {
-	// TODO(vmarmol): Improve this logic.
-	// Get a instance ID, assume they are created sequencially.
-	machs, err := self.instances.List("kubernetes-minion.+")
+// Add an instance of type 'instanceType'. 'allocated' is called with the name of
+// the instance once it is allocated, before the instance is created.
+func (self *prov) addInstance(instanceType string, allocated func(name string)) (Instance, error) {
+	machs, err := self.instances.List(instanceNameFilter)
 	if err != nil {
-		return []Instance{}, err
+		return Instance{}, err
+	}
+	instanceId, err := self.allocator.allocate(machs)
+	if err != nil {
+		return Instance{}, err
+	}
+	instanceName := getInstanceName(instanceId)
+	instanceIpRange := getInstanceIpRange(instanceId)
+	allocated(instanceName)
+	glog.Infof("Adding instance %q with IP range %q", instanceName, instanceIpRange)
+	err = self.instances.Add(instanceName, instanceIpRange, instanceType)
+	if err != nil {
+		self.releaseIfNotLive(instanceName, instanceId)
+		return Instance{}, err
 	}
-	instanceBase := len(machs) + 1
 
+	return Instance{
+		Name:         instanceName,
+		InstanceType: instanceType,
+	}, nil
+}
+
+func (self *prov) AddInstances(request AddInstancesRequest) ([]Instance, error) {
 	// Add all requested instances
 	newInstances := make([]Instance, 0, len(request.InstanceTypes))
-	for i, instanceType := range request.InstanceTypes {
-		instanceId := instanceBase + i
-		instanceName := fmt.Sprintf("kubernetes-minion-%d", instanceId)
-		instanceIpRange := fmt.Sprintf("10.244.%d.0/24", instanceId)
-		glog.Infof("Adding instance %q with IP range %q", instanceName, instanceIpRange)
-		err := self.instances.Add(instanceName, instanceIpRange, instanceType)
+	for _, instanceType := range request.InstanceTypes {
+		instance, err := self.addInstance(instanceType, func(string) {})
 		if err != nil {
 			return newInstances, err
 		}
+		newInstances = append(newInstances, instance)
+	}
+
+	return newInstances, nil
+}
+
+func (self *prov) StartAddInstances(request AddInstancesRequest) (Operation, error) {
+	// Reject unknown instance types right away rather than in the operation.
+	instanceTypes, err := self.instances.InstanceTypes()
+	if err != nil {
+		return Operation{}, err
+	}
+	for _, instanceType := range request.InstanceTypes {
+		if _, ok := instanceTypes[instanceType]; !ok {
+			return Operation{}, fmt.Errorf("unknown instance type %q", instanceType)
+		}
+	}
 
-		newInstances = append(newInstances, Instance{
-			Name:         instanceName,
-			InstanceType: instanceType,
+	op := self.operations.create(request.InstanceTypes)
+	for i, instanceType := range request.InstanceTypes {
+		go func(i int, instanceType string) {
+			defer util.HandleCrash()
+			instance, err := self.addInstance(instanceType, func(name string) {
+				self.operations.update(op.Id, i, func(progress *InstanceProgress) {
+					progress.Name = name
+					progress.State = StateRunning
+				})
+			})
+			self.operations.update(op.Id, i, func(progress *InstanceProgress) {
+				if err != nil {
+					glog.Errorf("Operation %s failed to add an instance of type %q: %v", op.Id, instanceType, err)
+					progress.State = StateFailed
+					progress.Error = err.Error()
+					return
+				}
+				progress.Name = instance.Name
+				progress.State = StateDone
+			})
+		}(i, instanceType)
+	}
+
+	return op, nil
+}
+
+func (self *prov) GetOperation(id string) (Operation, bool) {
+	return self.operations.get(id)
+}
+
+func (self *prov) ListOperations() []Operation {
+	return self.operations.list()
+}
+
+// Release the index of the instance 'name' that failed to be added, unless the instance got created anyway.
+func (self *prov) releaseIfNotLive(name string, index int) {
+	_, found, err := self.GetInstance(name)
+	if err != nil {
+		glog.Warningf("Keeping the index of instance %q allocated, failed to check if it exists: %v", name, err)
+		return
+	}
+	if found {
+		return
+	}
+	if err := self.allocator.release(index); err != nil {
+		glog.Warningf("Failed to release the index of instance %q: %v", name, err)
+	}
+}
+
+func (self *prov) RemoveInstances(request RemoveInstancesRequest) ([]Instance, error) {
+	removedInstances := make([]Instance, 0, len(request.Names))
+	for _, instanceName := range request.Names {
+		glog.Infof("Removing instance %q", instanceName)
+		err := self.instances.Delete(instanceName)
+		if err != nil {
+			return removedInstances, err
+		}
+		if index, ok := instanceIndex(instanceName); ok {
+			if err := self.allocator.release(index); err != nil {
+				glog.Warningf("Failed to release the index of instance %q: %v", instanceName, err)
+			}
+		}
+
+		removedInstances = append(removedInstances, Instance{
+			Name: instanceName,
 		})
 	}
 
-	return newInstances, nil
+	return removedInstances, nil
+}
+
+func (self *prov) ListInstances() ([]Instance, error) {
+	machs, err := self.instances.List(instanceNameFilter)
+	if err != nil {
+		return []Instance{}, err
+	}
+	instances := make([]Instance, 0, len(machs))
+	for _, mach := range machs {
+		// Instances are listed with their fqdn.
+		if ix := strings.Index(mach, "."); ix != -1 {
+			mach = mach[:ix]
+		}
+		instances = append(instances, Instance{Name: mach})
+	}
+	sort.Sort(byName(instances))
+	return instances, nil
+}
+
+func (self *prov) GetInstance(name string) (Instance, bool, error) {
+	machs, err := self.instances.List(regexp.QuoteMeta(name))
+	if err != nil {
+		return Instance{}, false, err
+	}
+	// Instances are listed with their fqdn.
+	for _, mach := range machs {
+		if mach == name || strings.HasPrefix(mach, name+".") {
+			return Instance{Name: name}, true, nil
+		}
+	}
+
+	return Instance{}, false, nil
 }
 
 func (self *prov) InstanceTypes() (map[string]api.NodeResources, error) {
@@ -102,3 +274,9 @@ func (self *prov) InstanceTypes() (map[string]api.NodeResources, error) {
 func (self *prov) DefaultInstanceType() (string, error) {
 	return self.defaultInstanceType, nil
 }
+
+type byName []Instance
+
+func (self byName) Len() int           { return len(self) }
+func (self byName) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+func (self byName) Less(i, j int) bool { return self[i].Name < self[j].Name }
@@ -0,0 +1,153 @@
+package provisioner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	fake_cloud "github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider/fake"
+)
+
+func newTestProvisioner(t *testing.T, stateFile string) (*fake_cloud.MemoryCloud, Provisioner) {
+	cloud := fake_cloud.NewMemoryCloud(map[string]api.NodeResources{
+		"small": {},
+		"large": {},
+	})
+	prov, err := New(cloud, "small", stateFile, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return cloud, prov
+}
+
+func addInstances(t *testing.T, prov Provisioner, instanceTypes ...string) []string {
+	instances, err := prov.AddInstances(AddInstancesRequest{InstanceTypes: instanceTypes})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := []string{}
+	for _, instance := range instances {
+		names = append(names, instance.Name)
+	}
+	return names
+}
+
+func TestAddInstancesAfterRemoval(t *testing.T) {
+	cloud, prov := newTestProvisioner(t, "")
+	// Created out of band, its index must not be handed out.
+	if err := cloud.Add("kubernetes-minion-2", "10.244.2.0/24", "small"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	names := addInstances(t, prov, "small", "large", "small")
+	expected := []string{"kubernetes-minion-1", "kubernetes-minion-3", "kubernetes-minion-4"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected instances %v, got %v", expected, names)
+	}
+	ipRange, err := cloud.IPRange("kubernetes-minion-3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ipRange != "10.244.3.0/24" {
+		t.Errorf("expected IP range 10.244.3.0/24, got %s", ipRange)
+	}
+
+	if _, err := prov.RemoveInstances(RemoveInstancesRequest{Names: []string{"kubernetes-minion-1", "kubernetes-minion-3"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names = addInstances(t, prov, "small", "small", "small")
+	expected = []string{"kubernetes-minion-1", "kubernetes-minion-3", "kubernetes-minion-5"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected instances %v, got %v", expected, names)
+	}
+	live, err := cloud.List(".*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(live) != 5 {
+		t.Errorf("expected 5 live instances, got %v", live)
+	}
+}
+
+func TestAddInstancesFailureReleasesIndex(t *testing.T) {
+	_, prov := newTestProvisioner(t, "")
+	if _, err := prov.AddInstances(AddInstancesRequest{InstanceTypes: []string{"unknown"}}); err == nil {
+		t.Fatalf("expected an error for an unknown instance type")
+	}
+	names := addInstances(t, prov, "small")
+	if !reflect.DeepEqual(names, []string{"kubernetes-minion-1"}) {
+		t.Errorf("expected instance kubernetes-minion-1, got %v", names)
+	}
+}
+
+func TestAllocatorPersistsAllocations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "allocator")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "state")
+
+	first, err := newAllocator(stateFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []int{1, 2, 3} {
+		index, err := first.allocate([]string{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if index != expected {
+			t.Errorf("expected index %d, got %d", expected, index)
+		}
+	}
+	if err := first.release(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A restarted allocator does not hand out the indices of instances still being created.
+	second, err := newAllocator(stateFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	index, err := second.allocate([]string{"kubernetes-minion-2.c.project.internal"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if index != 4 {
+		t.Errorf("expected index 4, got %d", index)
+	}
+}
+
+func TestListAndRemoveInstances(t *testing.T) {
+	cloud, prov := newTestProvisioner(t, "")
+	// Not created by the provisioner, so not listed.
+	if err := cloud.Add("kubernetes-master", "", "small"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	addInstances(t, prov, "small", "large", "small")
+
+	removed, err := prov.RemoveInstances(RemoveInstancesRequest{Names: []string{"kubernetes-minion-2"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(removed, []Instance{{Name: "kubernetes-minion-2"}}) {
+		t.Errorf("expected kubernetes-minion-2 to be removed, got %v", removed)
+	}
+	instances, err := prov.ListInstances()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Instance{{Name: "kubernetes-minion-1"}, {Name: "kubernetes-minion-3"}}
+	if !reflect.DeepEqual(instances, expected) {
+		t.Errorf("expected instances %v, got %v", expected, instances)
+	}
+
+	if _, err := prov.RemoveInstances(RemoveInstancesRequest{Names: []string{"kubernetes-minion-2"}}); err == nil {
+		t.Errorf("expected an error removing an instance that does not exist")
+	}
+}
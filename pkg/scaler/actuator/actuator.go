@@ -2,68 +2,99 @@ package actuator
 
 import (
 	"fmt"
-	"strings"
+	"time"
 
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/provisioner"
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/apiclient"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 )
 
 type realActuator struct {
-	serviceHostPort string
+	provisioner apiclient.Provisioner
+	clock       util.Clock
+	// Sleeps between polls of an operation. Replaced in tests.
+	sleep func(time.Duration)
 }
 
 func (self *realActuator) GetNodeShapes() (NodeShapes, error) {
-	var response map[string]api.NodeResources
-	if err := types.PostRequestAndGetResponse(fmt.Sprintf("http://%s/instance_types", self.serviceHostPort), nil, &response); err != nil {
+	response, err := self.provisioner.InstanceTypes()
+	if err != nil {
 		return NodeShapes{}, err
 	}
 
 	if len(response) == 0 {
 		return NodeShapes{}, fmt.Errorf("no node shapes returned by actuator.")
 	}
-	nodeShapes := newNodeShapes()
-	for shape, resources := range response {
-		capacity := types.Resource{
-			Cpu:    uint64(resources.Capacity["cpu"].IntVal),
-			Memory: uint64(resources.Capacity["memory"].IntVal),
-		}
-		nodeShapes.add(capacity, shape)
-	}
 
-	return nodeShapes, nil
+	return NewNodeShapesFromInstanceTypes(response), nil
 }
 
 func (self *realActuator) GetDefaultNodeShape() (string, error) {
-	var response string
-	err := types.PostRequestAndGetResponse(fmt.Sprintf("http://%s/instance_types/default", self.serviceHostPort), nil, &response)
-
-	return response, err
+	return self.provisioner.DefaultInstanceType()
 }
 
-func (self *realActuator) CreateNode(nodeShapeName string) (string, error) {
-	var request provisioner.AddInstancesRequest
-	var response []provisioner.Instance
-	request.InstanceTypes = []string{nodeShapeName}
+// Node creations are asynchronous operations of the provisioner. Their
+// operation is polled until the new node has a name, for at most
+// operationNameTimeout and operationMaxPolls polls.
+const (
+	operationPollInterval = time.Second
+	operationNameTimeout  = time.Minute
+	operationMaxPolls     = int(operationNameTimeout / operationPollInterval)
+)
 
-	if err := types.PostRequestAndGetResponse(fmt.Sprintf("http://%s/instances", self.serviceHostPort), request, &response); err != nil {
+func (self *realActuator) CreateNode(nodeShapeName string) (string, error) {
+	op, err := self.provisioner.AddInstances([]string{nodeShapeName})
+	if err != nil {
 		return "", err
 	}
 
-	if len(response) != 1 {
-		return "", fmt.Errorf("invalid response from the actuator - %v", response)
+	// The node is named as soon as the provisioner allocates it. Whether it
+	// joins the cluster is tracked by the scaler from then on.
+	deadline := self.clock.Now().Add(operationNameTimeout)
+	for polls := 0; ; polls++ {
+		if len(op.Instances) != 1 {
+			return "", fmt.Errorf("invalid response from the actuator - %+v", op)
+		}
+		instance := op.Instances[0]
+		if instance.State == provisioner.StateFailed {
+			return "", fmt.Errorf("failed to create node of type %q: %s", nodeShapeName, instance.Error)
+		}
+		if instance.Name != "" {
+			return instance.Name, nil
+		}
+		if polls >= operationMaxPolls || self.clock.Now().After(deadline) {
+			return "", fmt.Errorf("node of type %q not allocated by operation %s after %d polls", nodeShapeName, op.Id, polls)
+		}
+		self.sleep(operationPollInterval)
+		if op, err = self.provisioner.GetOperation(op.Id); err != nil {
+			return "", err
+		}
 	}
+}
 
-	return response[0].Name, nil
+func (self *realActuator) RemoveNode(hostname string) error {
+	return self.provisioner.RemoveInstance(hostname)
 }
 
-func New(actuatorHostPort string) (Actuator, error) {
-	if actuatorHostPort == "" {
-		return nil, fmt.Errorf("actuator host port empty.")
+func (self *realActuator) NodeExists(hostname string) (bool, error) {
+	instance, err := self.provisioner.GetInstance(hostname)
+	if apiclient.IsNotFound(err) {
+		return false, nil
 	}
-	if len(strings.Split(actuatorHostPort, ":")) != 2 {
-		return nil, fmt.Errorf("actuator host port invalid - %s.", actuatorHostPort)
+	if err != nil {
+		return false, err
 	}
+	if instance.Name != hostname {
+		return false, fmt.Errorf("invalid response from the actuator - %v", instance)
+	}
+
+	return true, nil
+}
 
-	return &realActuator{actuatorHostPort}, nil
+func New(provisioner apiclient.Provisioner, clock util.Clock) Actuator {
+	return &realActuator{
+		provisioner: provisioner,
+		clock:       clock,
+		sleep:       time.Sleep,
+	}
 }
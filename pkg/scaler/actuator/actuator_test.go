@@ -0,0 +1,75 @@
+package actuator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/provisioner"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/apiclient"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// Provisioner whose operation names its instance after 'namedAfter' polls.
+type fakeProvisioner struct {
+	apiclient.Provisioner
+	namedAfter int
+	polls      int
+}
+
+func (self *fakeProvisioner) operation() provisioner.Operation {
+	instance := provisioner.InstanceProgress{InstanceType: "small", State: provisioner.StatePending}
+	if self.polls >= self.namedAfter {
+		instance.Name = "kubernetes-minion-1"
+	}
+	return provisioner.Operation{Id: "1", State: provisioner.StateRunning, Instances: []provisioner.InstanceProgress{instance}}
+}
+
+func (self *fakeProvisioner) AddInstances(instanceTypes []string) (provisioner.Operation, error) {
+	return self.operation(), nil
+}
+
+func (self *fakeProvisioner) GetOperation(id string) (provisioner.Operation, error) {
+	self.polls++
+	return self.operation(), nil
+}
+
+// Returns an actuator whose polls advance 'clock' by 'step' instead of sleeping.
+func newTestActuator(fake *fakeProvisioner, clock *util.FakeClock, step time.Duration) *realActuator {
+	actuator := New(fake, clock).(*realActuator)
+	actuator.sleep = func(time.Duration) { clock.Time = clock.Time.Add(step) }
+	return actuator
+}
+
+func TestCreateNodeWaitsForName(t *testing.T) {
+	clock := &util.FakeClock{Time: time.Date(2014, 10, 1, 0, 0, 0, 0, time.UTC)}
+	fake := &fakeProvisioner{namedAfter: 3}
+	name, err := newTestActuator(fake, clock, operationPollInterval).CreateNode("small")
+	if err != nil || name != "kubernetes-minion-1" {
+		t.Errorf("expected node kubernetes-minion-1, got %q, %v", name, err)
+	}
+	if fake.polls != 3 {
+		t.Errorf("expected 3 polls, got %d", fake.polls)
+	}
+}
+
+func TestCreateNodeWaitIsBounded(t *testing.T) {
+	start := time.Date(2014, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		step  time.Duration
+		polls int
+	}{
+		{name: "frozen clock", step: 0, polls: operationMaxPolls},
+		{name: "slow polls", step: 20 * time.Second, polls: 4},
+	}
+	for _, test := range tests {
+		clock := &util.FakeClock{Time: start}
+		fake := &fakeProvisioner{namedAfter: operationMaxPolls + 1}
+		if _, err := newTestActuator(fake, clock, test.step).CreateNode("small"); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+		if fake.polls != test.polls {
+			t.Errorf("%s: expected %d polls, got %d", test.name, test.polls, fake.polls)
+		}
+	}
+}
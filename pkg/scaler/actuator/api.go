@@ -3,7 +3,9 @@ package actuator
 import (
 	"fmt"
 	"math"
+	"sort"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
 )
 
@@ -21,6 +23,10 @@ type Actuator interface {
 	GetDefaultNodeShape() (string, error)
 	// Creates a new nodes based on the input nodeShapeName and returns the hostname of the new node.
 	CreateNode(nodeShapeName string) (string, error)
+	// Removes the node with the input hostname. Pods running on the node are not drained.
+	RemoveNode(hostname string) error
+	// Returns true if the node with the input hostname exists in the cloud provider, whether or not it joined the cluster.
+	NodeExists(hostname string) (bool, error)
 }
 
 // Represents all the node shapes available.
@@ -53,6 +59,30 @@ func (self *NodeShapes) GetNodeShapeWithCapacity(expectedCapacity types.Resource
 	return nodeShape, nil
 }
 
+// Returns all the node shapes, sorted by increasing capacity.
+func (self *NodeShapes) List() []NodeShape {
+	shapes := make([]NodeShape, 0, len(self.nodeCapacityToShape))
+	for capacity, shape := range self.nodeCapacityToShape {
+		shapes = append(shapes, NodeShape{shape, capacity})
+	}
+	sort.Sort(byCapacity(shapes))
+	return shapes
+}
+
+type byCapacity []NodeShape
+
+func (self byCapacity) Len() int      { return len(self) }
+func (self byCapacity) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+func (self byCapacity) Less(i, j int) bool {
+	if self[i].Capacity.Cpu != self[j].Capacity.Cpu {
+		return self[i].Capacity.Cpu < self[j].Capacity.Cpu
+	}
+	if self[i].Capacity.Memory != self[j].Capacity.Memory {
+		return self[i].Capacity.Memory < self[j].Capacity.Memory
+	}
+	return self[i].Name < self[j].Name
+}
+
 // Returns a node shape if 'shapeType' maps to a legal node shape, error otherwise.
 func (self *NodeShapes) GetNodeShapeWithType(shapeType string) (NodeShape, error) {
 	for capacity, shape := range self.nodeCapacityToShape {
@@ -73,3 +103,25 @@ func newNodeShapes() NodeShapes {
 		nodeCapacityToShape: make(map[types.Resource]string),
 	}
 }
+
+// Returns the node shapes made of 'shapes'.
+func NewNodeShapes(shapes []NodeShape) NodeShapes {
+	nodeShapes := newNodeShapes()
+	for _, shape := range shapes {
+		nodeShapes.add(shape.Capacity, shape.Name)
+	}
+	return nodeShapes
+}
+
+// Returns the node shapes for the instance types of the provisioner, as listed by its /instance_types endpoint.
+func NewNodeShapesFromInstanceTypes(instanceTypes map[string]api.NodeResources) NodeShapes {
+	nodeShapes := newNodeShapes()
+	for shape, resources := range instanceTypes {
+		capacity := types.Resource{
+			Cpu:    uint64(resources.Capacity["cpu"].IntVal),
+			Memory: uint64(resources.Capacity["memory"].IntVal),
+		}
+		nodeShapes.add(capacity, shape)
+	}
+	return nodeShapes
+}
@@ -1,39 +1,72 @@
 package aggregator
 
 import (
-	"fmt"
-	"strings"
-
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/apiclient"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/statscollector"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/statscollector/api/v1"
 )
 
 type realAggregator struct {
-	aggregatorHostPort string
+	statsCollector apiclient.StatsCollector
+}
+
+func fromV1Percentiles(percentiles v1.Percentiles) statscollector.Percentiles {
+	result := statscollector.Percentiles{
+		Mean:   percentiles[v1.PercentileMean],
+		Max:    percentiles[v1.PercentileMax],
+		Ninety: percentiles[v1.PercentileNinety],
+	}
+	for name, value := range percentiles {
+		if name == v1.PercentileMean || name == v1.PercentileMax || name == v1.PercentileNinety {
+			continue
+		}
+		if result.Values == nil {
+			result.Values = make(map[string]uint64)
+		}
+		result.Values[name] = value
+	}
+	return result
+}
+
+func fromV1Usage(usage v1.Usage) statscollector.Resource {
+	if !usage.Valid {
+		return statscollector.Resource{}
+	}
+	return statscollector.Resource{
+		Valid:  true,
+		Cpu:    fromV1Percentiles(usage.Cpu),
+		Memory: fromV1Percentiles(usage.Memory),
+	}
+}
+
+// Converts a node of the versioned statscollector API.
+func fromV1Node(node v1.Node) Node {
+	return Node{
+		Hostname: node.Name,
+		Capacity: types.Resource{Cpu: node.Capacity.Cpu, Memory: node.Capacity.Memory},
+		Usage: DerivedStats{
+			LastUpdate:  node.LastUpdate,
+			MinuteUsage: fromV1Usage(node.Usage[v1.WindowMinute]),
+			HourUsage:   fromV1Usage(node.Usage[v1.WindowHour]),
+			DayUsage:    fromV1Usage(node.Usage[v1.WindowDay]),
+		},
+	}
 }
 
 func (self *realAggregator) GetClusterInfo() (map[string]Node, error) {
-	var response map[string]statscollector.NodeData
-	if err := types.PostRequestAndGetResponse(fmt.Sprintf("http://%s/stats", self.aggregatorHostPort), nil, &response); err != nil {
+	nodes, err := self.statsCollector.ListNodes()
+	if err != nil {
 		return map[string]Node{}, err
 	}
 
-	result := make(map[string]Node)
-	for host, nodeData := range response {
-		node := Node{
-			Hostname: host,
-			Capacity: types.Resource{Cpu: uint64(nodeData.Capacity.Cpu), Memory: nodeData.Capacity.Memory},
-			Usage:    DerivedStats(nodeData.Stats),
-		}
-		result[host] = node
+	result := make(map[string]Node, len(nodes))
+	for _, node := range nodes {
+		result[node.Name] = fromV1Node(node)
 	}
 	return result, nil
 }
 
-func New(aggregatorHostPort string) (Aggregator, error) {
-	if aggregatorHostPort == "" || len(strings.Split(aggregatorHostPort, ":")) != 2 {
-		return nil, fmt.Errorf("Arrgregator address invalid: %s", aggregatorHostPort)
-	}
-
-	return &realAggregator{aggregatorHostPort}, nil
+func New(statsCollector apiclient.StatsCollector) Aggregator {
+	return &realAggregator{statsCollector}
 }
@@ -0,0 +1,68 @@
+package aggregator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/statscollector"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/statscollector/api/v1"
+)
+
+type fakeStatsCollector struct {
+	nodes []v1.Node
+}
+
+func (self *fakeStatsCollector) ListNodes() ([]v1.Node, error) {
+	return self.nodes, nil
+}
+
+func (self *fakeStatsCollector) GetNode(name string) (v1.Node, error) {
+	return v1.Node{}, nil
+}
+
+func (self *fakeStatsCollector) GetSummary() (v1.ClusterSummary, error) {
+	return v1.ClusterSummary{}, nil
+}
+
+func TestGetClusterInfo(t *testing.T) {
+	lastUpdate := time.Date(2014, time.November, 1, 12, 0, 0, 0, time.UTC)
+	statsCollector := &fakeStatsCollector{[]v1.Node{
+		{
+			Name:       "node-1",
+			Capacity:   v1.Capacity{Cpu: 1000, Memory: 4096},
+			LastUpdate: lastUpdate,
+			Usage: map[string]v1.Usage{
+				v1.WindowMinute: {
+					Valid:  true,
+					Cpu:    v1.Percentiles{"mean": 100, "max": 300, "90": 200, "99": 250},
+					Memory: v1.Percentiles{"mean": 1024, "max": 2048, "90": 1536},
+				},
+				v1.WindowHour: {Valid: false},
+			},
+		},
+	}}
+
+	nodes, err := New(statsCollector).GetClusterInfo()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]Node{
+		"node-1": {
+			Hostname: "node-1",
+			Capacity: types.Resource{Cpu: 1000, Memory: 4096},
+			Usage: DerivedStats{
+				LastUpdate: lastUpdate,
+				MinuteUsage: statscollector.Resource{
+					Valid:  true,
+					Cpu:    statscollector.Percentiles{Mean: 100, Max: 300, Ninety: 200, Values: map[string]uint64{"99": 250}},
+					Memory: statscollector.Percentiles{Mean: 1024, Max: 2048, Ninety: 1536},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("expected nodes %+v, got %+v", expected, nodes)
+	}
+}
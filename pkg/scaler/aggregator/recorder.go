@@ -0,0 +1,72 @@
+package aggregator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+// The nodes reported by an Aggregator at a point in time.
+type Snapshot struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Nodes     map[string]Node `json:"nodes"`
+}
+
+// Records the nodes reported by an Aggregator, one JSON encoded Snapshot per line.
+type recordingAggregator struct {
+	aggregator Aggregator
+	encoder    *json.Encoder
+	clock      util.Clock
+}
+
+func (self *recordingAggregator) GetClusterInfo() (map[string]Node, error) {
+	nodes, err := self.aggregator.GetClusterInfo()
+	if err != nil {
+		return nodes, err
+	}
+	// A failure to record must not stop the scaler.
+	if err := self.encoder.Encode(Snapshot{Timestamp: self.clock.Now(), Nodes: nodes}); err != nil {
+		glog.Errorf("Failed to record cluster snapshot - %q", err)
+	}
+	return nodes, nil
+}
+
+// Returns an Aggregator that writes the nodes reported by 'aggregator' to 'out'.
+// The recording can be read back with ReadSnapshots.
+func NewRecorder(aggregator Aggregator, out io.Writer) Aggregator {
+	return &recordingAggregator{
+		aggregator: aggregator,
+		encoder:    json.NewEncoder(out),
+		clock:      util.RealClock{},
+	}
+}
+
+type byTimestamp []Snapshot
+
+func (self byTimestamp) Len() int           { return len(self) }
+func (self byTimestamp) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+func (self byTimestamp) Less(i, j int) bool { return self[i].Timestamp.Before(self[j].Timestamp) }
+
+// Reads the snapshots written by a recorder, sorted by increasing timestamp.
+func ReadSnapshots(in io.Reader) ([]Snapshot, error) {
+	snapshots := []Snapshot{}
+	decoder := json.NewDecoder(in)
+	for {
+		var snapshot Snapshot
+		err := decoder.Decode(&snapshot)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode snapshot %d - %q", len(snapshots)+1, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Sort(byTimestamp(snapshots))
+	return snapshots, nil
+}
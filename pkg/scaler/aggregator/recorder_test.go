@@ -0,0 +1,48 @@
+package aggregator
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+type fakeAggregator struct {
+	nodes map[string]Node
+}
+
+func (self *fakeAggregator) GetClusterInfo() (map[string]Node, error) {
+	return self.nodes, nil
+}
+
+func TestRecorder(t *testing.T) {
+	start := time.Date(2014, 12, 1, 0, 0, 0, 0, time.UTC)
+	clock := &util.FakeClock{Time: start}
+	fake := &fakeAggregator{
+		nodes: map[string]Node{"a": {Hostname: "a", Capacity: types.Resource{Cpu: 1000, Memory: 1000}}},
+	}
+	var out bytes.Buffer
+	recorder := NewRecorder(fake, &out)
+	recorder.(*recordingAggregator).clock = clock
+	for i := 0; i < 2; i++ {
+		if _, err := recorder.GetClusterInfo(); err != nil {
+			t.Fatal(err)
+		}
+		clock.Time = clock.Time.Add(time.Minute)
+	}
+
+	snapshots, err := ReadSnapshots(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Snapshot{
+		{Timestamp: start, Nodes: fake.nodes},
+		{Timestamp: start.Add(time.Minute), Nodes: fake.nodes},
+	}
+	if !reflect.DeepEqual(snapshots, expected) {
+		t.Errorf("expected snapshots %+v, got %+v", expected, snapshots)
+	}
+}
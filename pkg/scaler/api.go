@@ -1,6 +1,7 @@
 package scaler
 
 import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/aggregator"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
@@ -8,6 +9,8 @@ import (
 
 type Scaler interface {
 	AutoScale() error
+	// Returns the current state of the scaler and its last decisions.
+	GetStatus() Status
 }
 
 type Node struct {
@@ -22,14 +25,30 @@ type Cluster struct {
 	// Map of hostname to node
 	Current map[string]Node
 	// List of node shapes.
-	New   []string
-	Slack types.Resource
+	New []string
+	// Hostnames of the nodes to remove.
+	Remove []string
+	Slack  types.Resource
+	// Nodes, pods and replication controllers as seen by the apiserver.
+	Minions     api.NodeList
+	Pods        []api.Pod
+	Controllers []api.ReplicationController
+	// Set if the nodes, pods and replication controllers could not be read from the
+	// apiserver. They are empty then, and the policies that need them fail with it.
+	SchedulingStateErr error
+	// Map of node shape name to the cost of adding a node of that shape.
+	ShapeCosts map[string]float64
+	// Map of node shape names in 'New' and hostnames in 'Remove' to the policies that asked for them.
+	newRequestedBy    map[string][]string
+	removeRequestedBy map[string][]string
 }
 
 type Policy interface {
 	// Analyze the current state of cluster and scale the cluster if necessary.
 	// Arguments:
 	//   *Cluster: Contains the current state of the cluster and scaling that needs to be performed.
-	// Updates 'New' and 'Slack' fields of the input on success, error otherwise.
+	// Updates 'New', 'Remove' and 'Slack' fields of the input on success, error otherwise.
+	// Policies are applied in order, and may veto or amend the nodes added to 'New' and
+	// 'Remove' by the policies before them. The updates of a failing policy are discarded.
 	PerformScaling(*Cluster) error
 }
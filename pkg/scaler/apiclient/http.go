@@ -0,0 +1,208 @@
+// Clients of the statscollector and provisioner HTTP APIs, with timeouts and
+// retries with exponential backoff.
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+// Configuration of a client.
+type Config struct {
+	// Host:port of the server.
+	HostPort string
+	// Timeout of a single attempt of a request.
+	Timeout time.Duration
+	// Number of times a failed idempotent request is retried.
+	MaxRetries int
+	// Delay before the first retry. It doubles on every retry.
+	Backoff time.Duration
+}
+
+func (self Config) validate() error {
+	if self.HostPort == "" || len(strings.Split(self.HostPort, ":")) != 2 {
+		return fmt.Errorf("host port invalid: %q", self.HostPort)
+	}
+	if self.Timeout < 0 {
+		return fmt.Errorf("timeout invalid: %v", self.Timeout)
+	}
+	if self.MaxRetries < 0 {
+		return fmt.Errorf("number of retries invalid: %d", self.MaxRetries)
+	}
+	if self.Backoff < 0 {
+		return fmt.Errorf("backoff invalid: %v", self.Backoff)
+	}
+	return nil
+}
+
+// Returned when the server responds with an error status.
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// Message returned by the server.
+	Message string
+}
+
+func (self *StatusError) Error() string {
+	return fmt.Sprintf("%s %s failed with status %d: %s", self.Method, self.URL, self.StatusCode, self.Message)
+}
+
+// Returned when the server cannot be reached, after all the retries.
+type ConnectionError struct {
+	Method string
+	URL    string
+	// Number of attempts made.
+	Attempts int
+	// Error of the last attempt.
+	Err error
+}
+
+func (self *ConnectionError) Error() string {
+	return fmt.Sprintf("%s %s failed after %d attempts: %v", self.Method, self.URL, self.Attempts, self.Err)
+}
+
+// Returned when the response of the server cannot be decoded.
+type DecodeError struct {
+	URL  string
+	Body string
+	Err  error
+}
+
+func (self *DecodeError) Error() string {
+	return fmt.Sprintf("unable to decode the response of %s %q: %v", self.URL, self.Body, self.Err)
+}
+
+// Returns true if 'err' is a StatusError with status 404.
+func IsNotFound(err error) bool {
+	statusErr, ok := err.(*StatusError)
+	return ok && statusErr.StatusCode == http.StatusNotFound
+}
+
+// Returns true if the failed request may succeed when retried.
+func isRetriable(err error) bool {
+	switch err := err.(type) {
+	case *ConnectionError:
+		return true
+	case *StatusError:
+		return err.StatusCode >= 500
+	}
+	return false
+}
+
+// Extracts the message of an error response. Servers either return plain text
+// or a JSON object with a "message".
+func errorMessage(body []byte) string {
+	var response struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &response); err == nil && response.Message != "" {
+		return response.Message
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// Escapes 'segment' to be used as a single segment of a URL path.
+func escapePathSegment(segment string) string {
+	return strings.Replace(url.QueryEscape(segment), "+", "%20", -1)
+}
+
+type httpClient struct {
+	config Config
+	client *http.Client
+	// Sleeps between retries. Replaced in tests.
+	sleep func(time.Duration)
+}
+
+func newHttpClient(config Config) (*httpClient, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &httpClient{
+		config: config,
+		client: &http.Client{Transport: util.NewTimeoutTransport(config.Timeout)},
+		sleep:  time.Sleep,
+	}, nil
+}
+
+// Make a single attempt of a request, and decode its JSON response into 'response'.
+func (self *httpClient) doOnce(method, url string, body []byte, response interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := self.client.Do(req)
+	if err != nil {
+		return &ConnectionError{Method: method, URL: url, Attempts: 1, Err: err}
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return &ConnectionError{Method: method, URL: url, Attempts: 1, Err: err}
+	}
+	glog.V(3).Infof("%s %s: status %d, response %s", method, url, resp.StatusCode, data)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{Method: method, URL: url, StatusCode: resp.StatusCode, Message: errorMessage(data)}
+	}
+	if err := json.Unmarshal(data, response); err != nil {
+		return &DecodeError{URL: url, Body: string(data), Err: err}
+	}
+	return nil
+}
+
+// Send a request with the JSON encoding of 'request', if not nil, to 'path' and
+// decode the JSON response into 'response'. Failed GET and DELETE requests are
+// retried with exponential backoff. A DELETE that is not found when retried is
+// taken to have succeeded on an earlier attempt whose response was lost, so no
+// error is returned and 'response' is left unset.
+func (self *httpClient) do(method, path string, request, response interface{}) error {
+	url := fmt.Sprintf("http://%s%s", self.config.HostPort, path)
+	var body []byte
+	if request != nil {
+		var err error
+		if body, err = json.Marshal(request); err != nil {
+			return fmt.Errorf("unable to encode request %+v: %v", request, err)
+		}
+	}
+	retries := 0
+	if method == "GET" || method == "DELETE" {
+		retries = self.config.MaxRetries
+	}
+	backoff := self.config.Backoff
+	for attempt := 1; ; attempt++ {
+		err := self.doOnce(method, url, body, response)
+		if method == "DELETE" && attempt > 1 && IsNotFound(err) {
+			glog.V(1).Infof("Assuming an earlier attempt succeeded: %v", err)
+			return nil
+		}
+		if err == nil || !isRetriable(err) {
+			return err
+		}
+		if attempt > retries {
+			if connErr, ok := err.(*ConnectionError); ok {
+				connErr.Attempts = attempt
+			}
+			return err
+		}
+		glog.V(1).Infof("Retrying in %v: %v", backoff, err)
+		self.sleep(backoff)
+		backoff *= 2
+	}
+}
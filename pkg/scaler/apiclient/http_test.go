@@ -0,0 +1,205 @@
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/provisioner"
+)
+
+// Returns a client of 'server' that records its retry delays in 'sleeps'.
+func newTestClient(t *testing.T, server *httptest.Server, sleeps *[]time.Duration) *httpClient {
+	client, err := newHttpClient(Config{
+		HostPort:   strings.TrimPrefix(server.URL, "http://"),
+		Timeout:    time.Second,
+		MaxRetries: 2,
+		Backoff:    time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client.sleep = func(d time.Duration) { *sleeps = append(*sleeps, d) }
+	return client
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"version": "v1", "items": [{"name": "node-1"}]}`)
+	}))
+	defer server.Close()
+	sleeps := []time.Duration{}
+	client := &statsCollectorClient{newTestClient(t, server, &sleeps)}
+
+	nodes, err := client.ListNodes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Name != "node-1" {
+		t.Errorf("expected node node-1, got %+v", nodes)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+	if len(sleeps) != 2 || sleeps[0] != time.Second || sleeps[1] != 2*time.Second {
+		t.Errorf("expected backoffs of 1s and 2s, got %v", sleeps)
+	}
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"version": "v1", "message": "no stats yet"}`)
+	}))
+	defer server.Close()
+	sleeps := []time.Duration{}
+	client := &statsCollectorClient{newTestClient(t, server, &sleeps)}
+
+	_, err := client.GetSummary()
+	statusErr, ok := err.(*StatusError)
+	if !ok {
+		t.Fatalf("expected a status error, got %v", err)
+	}
+	if statusErr.StatusCode != http.StatusInternalServerError || statusErr.Message != "no stats yet" {
+		t.Errorf("unexpected error %+v", statusErr)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestNoRetry(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch r.URL.Path {
+		case "/instances":
+			http.Error(w, "quota exceeded", http.StatusInternalServerError)
+		case "/instances/missing":
+			http.Error(w, "instance \"missing\" not found", http.StatusNotFound)
+		default:
+			fmt.Fprint(w, "not json")
+		}
+	}))
+	defer server.Close()
+	sleeps := []time.Duration{}
+	client := &provisionerClient{newTestClient(t, server, &sleeps)}
+
+	// Adding instances is not idempotent.
+	if _, err := client.AddInstances([]string{"small"}); err == nil {
+		t.Errorf("expected an error")
+	}
+	if _, err := client.GetInstance("missing"); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if _, err := client.DefaultInstanceType(); err == nil {
+		t.Errorf("expected an error")
+	} else if _, ok := err.(*DecodeError); !ok {
+		t.Errorf("expected a decode error, got %v", err)
+	}
+	if attempts != 3 || len(sleeps) != 0 {
+		t.Errorf("expected 3 attempts without retries, got %d attempts and backoffs %v", attempts, sleeps)
+	}
+}
+
+func TestRetriedDeleteNotFound(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		// The first attempt removes the instance but its response is lost.
+		if attempts == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, "instance \"kubernetes-minion-1\" not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+	sleeps := []time.Duration{}
+	client := &provisionerClient{newTestClient(t, server, &sleeps)}
+
+	if err := client.RemoveInstance("kubernetes-minion-1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+	// A DELETE that is not found on its first attempt still fails.
+	attempts = 1
+	if err := client.RemoveInstance("kubernetes-minion-1"); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	sleeps := []time.Duration{}
+	client := &provisionerClient{newTestClient(t, server, &sleeps)}
+	server.Close()
+
+	err := client.RemoveInstance("kubernetes-minion-1")
+	connErr, ok := err.(*ConnectionError)
+	if !ok {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+	if connErr.Attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", connErr.Attempts)
+	}
+}
+
+func TestProvisionerClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/instances":
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, `{"id": "1", "state": "running", "instances": [{"instance_type": "small", "state": "pending"}]}`)
+		case r.Method == "DELETE" && r.URL.Path == "/instances/kubernetes-minion-1":
+			fmt.Fprint(w, `[{"name": "kubernetes-minion-1"}]`)
+		case r.Method == "GET" && r.RequestURI == "/operations/a%20b%2Fc":
+			fmt.Fprint(w, `{"id": "a b/c", "state": "done"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	sleeps := []time.Duration{}
+	client := &provisionerClient{newTestClient(t, server, &sleeps)}
+
+	op, err := client.AddInstances([]string{"small"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if op.Id != "1" || op.State != provisioner.StateRunning || len(op.Instances) != 1 {
+		t.Errorf("unexpected operation %+v", op)
+	}
+	if err := client.RemoveInstance("kubernetes-minion-1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if op, err := client.GetOperation("a b/c"); err != nil || op.State != provisioner.StateDone {
+		t.Errorf("expected the escaped operation, got %+v, %v", op, err)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	for _, config := range []Config{
+		{HostPort: "localhost"},
+		{HostPort: "localhost:8085", MaxRetries: -1},
+	} {
+		if _, err := NewStatsCollector(config); err == nil {
+			t.Errorf("expected an error for config %+v", config)
+		}
+	}
+	if _, err := NewStatsCollector(Config{HostPort: "localhost:8085"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package apiclient
+
+import (
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/provisioner"
+)
+
+// Client of the provisioner API.
+type Provisioner interface {
+	// Returns the types of instances available and their capacity.
+	InstanceTypes() (map[string]api.NodeResources, error)
+
+	// Returns the default instance type.
+	DefaultInstanceType() (string, error)
+
+	// Starts adding instances of 'instanceTypes'. Returns the operation tracking them.
+	AddInstances(instanceTypes []string) (provisioner.Operation, error)
+
+	// Returns the operation 'id'. The error satisfies IsNotFound() if there is no such operation.
+	GetOperation(id string) (provisioner.Operation, error)
+
+	// Lists the instances created by the provisioner.
+	ListInstances() ([]provisioner.Instance, error)
+
+	// Returns the instance 'name'. The error satisfies IsNotFound() if there is no such instance.
+	GetInstance(name string) (provisioner.Instance, error)
+
+	// Removes the instance 'name'.
+	RemoveInstance(name string) error
+}
+
+type provisionerClient struct {
+	*httpClient
+}
+
+func NewProvisioner(config Config) (Provisioner, error) {
+	client, err := newHttpClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &provisionerClient{client}, nil
+}
+
+func (self *provisionerClient) InstanceTypes() (map[string]api.NodeResources, error) {
+	var response map[string]api.NodeResources
+	err := self.do("GET", "/instance_types", nil, &response)
+	return response, err
+}
+
+func (self *provisionerClient) DefaultInstanceType() (string, error) {
+	var response string
+	err := self.do("GET", "/instance_types/default", nil, &response)
+	return response, err
+}
+
+func (self *provisionerClient) AddInstances(instanceTypes []string) (provisioner.Operation, error) {
+	var response provisioner.Operation
+	err := self.do("POST", "/instances", provisioner.AddInstancesRequest{InstanceTypes: instanceTypes}, &response)
+	return response, err
+}
+
+func (self *provisionerClient) GetOperation(id string) (provisioner.Operation, error) {
+	var response provisioner.Operation
+	err := self.do("GET", "/operations/"+escapePathSegment(id), nil, &response)
+	return response, err
+}
+
+func (self *provisionerClient) ListInstances() ([]provisioner.Instance, error) {
+	var response []provisioner.Instance
+	err := self.do("GET", "/instances", nil, &response)
+	return response, err
+}
+
+func (self *provisionerClient) GetInstance(name string) (provisioner.Instance, error) {
+	var response provisioner.Instance
+	err := self.do("GET", "/instances/"+escapePathSegment(name), nil, &response)
+	return response, err
+}
+
+func (self *provisionerClient) RemoveInstance(name string) error {
+	var response []provisioner.Instance
+	if err := self.do("DELETE", "/instances/"+escapePathSegment(name), nil, &response); err != nil {
+		return err
+	}
+	// Nothing is returned if the instance was removed by an earlier attempt.
+	if response == nil {
+		return nil
+	}
+	if len(response) != 1 || response[0].Name != name {
+		return fmt.Errorf("invalid response from the provisioner - %v", response)
+	}
+	return nil
+}
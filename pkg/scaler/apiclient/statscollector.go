@@ -0,0 +1,53 @@
+package apiclient
+
+import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/statscollector/api/v1"
+)
+
+// Client of the versioned statscollector query API.
+type StatsCollector interface {
+	// Returns all the nodes, with their usage over all the windows.
+	ListNodes() ([]v1.Node, error)
+
+	// Returns the node 'name'. The error satisfies IsNotFound() if there is no such node.
+	GetNode(name string) (v1.Node, error)
+
+	// Returns the summary of the cluster.
+	GetSummary() (v1.ClusterSummary, error)
+}
+
+type statsCollectorClient struct {
+	*httpClient
+}
+
+func NewStatsCollector(config Config) (StatsCollector, error) {
+	client, err := newHttpClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &statsCollectorClient{client}, nil
+}
+
+const statsCollectorPrefix = "/api/" + v1.Version
+
+func (self *statsCollectorClient) ListNodes() ([]v1.Node, error) {
+	var response v1.NodeList
+	if err := self.do("GET", statsCollectorPrefix+"/nodes", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Items, nil
+}
+
+func (self *statsCollectorClient) GetNode(name string) (v1.Node, error) {
+	var response v1.NodeResponse
+	if err := self.do("GET", statsCollectorPrefix+"/nodes/"+escapePathSegment(name), nil, &response); err != nil {
+		return v1.Node{}, err
+	}
+	return response.Node, nil
+}
+
+func (self *statsCollectorClient) GetSummary() (v1.ClusterSummary, error) {
+	var response v1.ClusterSummary
+	err := self.do("GET", statsCollectorPrefix+"/summary", nil, &response)
+	return response, err
+}
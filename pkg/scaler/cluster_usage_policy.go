@@ -46,9 +46,19 @@ func (self *clusterUsagePolicy) getUsageBasedOnPolicy(derivedStats aggregator.De
 	return nil
 }
 
+// Returns the capacity to add for 'usage' to be below the threshold percentage of 'capacity'.
+func (self *clusterUsagePolicy) getUnmetDemand(usage, capacity types.Resource) types.Resource {
+	needed := types.Resource{
+		Cpu:    usage.Cpu * 100 / uint64(self.threshold),
+		Memory: usage.Memory * 100 / uint64(self.threshold),
+	}
+	return subtractResource(needed, capacity)
+}
+
 func (self *clusterUsagePolicy) PerformScaling(cluster *Cluster) error {
 	nodesAboveThreshold := 0
 	stableNodes := 0
+	var totalUsage, totalCapacity types.Resource
 	for _, node := range cluster.Current {
 		glog.V(1).Infof("Checking usage of node: %s", node.Hostname)
 		// TODO(vishh): Handle nodes that have been offline for a while, and hence no recent stats.
@@ -69,14 +79,24 @@ func (self *clusterUsagePolicy) PerformScaling(cluster *Cluster) error {
 		} else {
 			glog.V(1).Infof("Node %s is below threshold", node.Hostname)
 		}
+		totalUsage.Cpu += usage.Cpu
+		totalUsage.Memory += usage.Memory
+		totalCapacity.Cpu += node.Capacity.Cpu
+		totalCapacity.Memory += node.Capacity.Memory
 		cluster.Slack.Cpu += (node.Capacity.Cpu - usage.Cpu)
 		cluster.Slack.Memory += (node.Capacity.Memory - usage.Memory)
 	}
 	if nodesAboveThreshold > 0 && PercentageOf(uint64(nodesAboveThreshold), uint64(stableNodes)) > self.threshold {
 		if len(cluster.New) == 0 {
-			glog.Infof("%d nodes in the cluster are above their threshold resource usage. Increasing cluster size by one node.", nodesAboveThreshold)
-			// Increase the cluster size by one node.
-			cluster.New = append(cluster.New, cluster.DefaultShape.Name)
+			demand := self.getUnmetDemand(totalUsage, totalCapacity)
+			if demand.Cpu == 0 && demand.Memory == 0 {
+				// The cluster as a whole is below the threshold. Add a node's worth of capacity.
+				demand = cluster.DefaultShape.Capacity
+			}
+			shapes := selectShapes(cluster, demand)
+			glog.Infof("%d nodes in the cluster are above their threshold resource usage. Adding nodes %v for cpu %d and memory %d.",
+				nodesAboveThreshold, shapes, demand.Cpu, demand.Memory)
+			cluster.New = append(cluster.New, shapes...)
 		}
 	}
 
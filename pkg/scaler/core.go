@@ -2,27 +2,52 @@ package scaler
 
 import (
 	"fmt"
+	"reflect"
+	"sync"
 	"time"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/aggregator"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/golang/glog"
 )
 
 type realAutoScaler struct {
 	// Housekeeping duration.
 	housekeeping time.Duration
-	// A map of policy name to Policy
-	policies         map[string]Policy
+	// Policies in the order they are applied.
+	policies         []namedPolicy
 	nodeShapes       actuator.NodeShapes
 	defaultNodeShape actuator.NodeShape
 	actuator         actuator.Actuator
 	aggregator       aggregator.Aggregator
+	kubeClient       client.Interface
+	// Map of node shape name to cost.
+	shapeCosts map[string]float64
 	// Map of hostname to Node information.
 	existingNodes map[string]Node
-	// Map of hostname to shape type.
-	newNodes map[string]string
+	// Map of hostname to pending node creation.
+	newNodes map[string]pendingNode
+	// Pending node creations are given up on after this long.
+	nodeCreationTimeout time.Duration
+	// Whether to retry node creations that timed out on a different shape.
+	retryNodeCreation bool
+	clock             util.Clock
+	limits            Limits
+	// Times at which nodes were last added and removed.
+	lastScaleUp   time.Time
+	lastScaleDown time.Time
+	// Hostnames of the nodes removed by the scaler that may still be reported by the aggregator.
+	removedNodes map[string]bool
+	// Protects the status and the decisions, which are read by the status endpoint.
+	statusLock sync.Mutex
+	status     Status
+	// The last decisions, oldest first.
+	decisions []Decision
 }
 
 func (self *realAutoScaler) AutoScale() error {
@@ -38,28 +63,65 @@ func (self *realAutoScaler) AutoScale() error {
 }
 
 func (self *realAutoScaler) doHousekeeping() error {
+	cluster, err := self.resizeCluster()
+	self.updateStatus(cluster, err)
+	return err
+}
+
+// Applies the policies to the current state of the cluster and resizes it accordingly.
+// Returns the cluster as seen by the policies, if they could be applied.
+func (self *realAutoScaler) resizeCluster() (*Cluster, error) {
 	hostnameToNodesMap, err := self.aggregator.GetClusterInfo()
 	if err != nil {
-		return fmt.Errorf("failed to get cluster node information from aggregator - %q", err)
+		return nil, fmt.Errorf("failed to get cluster node information from aggregator - %q", err)
 	}
 
+	self.updateRemovedNodes(hostnameToNodesMap)
 	cluster, err := self.applyPolicies(hostnameToNodesMap)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = self.handleClusterResizing(cluster)
 	if err != nil {
-		return err
+		return cluster, err
 	}
 
-	// TODO(vishh): Surface slack resources/nodes.
+	return cluster, nil
+}
+
+// Read the nodes, pods and replication controllers in all namespaces from the apiserver.
+func (self *realAutoScaler) getSchedulingState(cluster *Cluster) error {
+	minions, err := self.kubeClient.Nodes().List()
+	if err != nil {
+		return fmt.Errorf("failed to list nodes - %q", err)
+	}
+	pods, err := self.kubeClient.Pods(api.NamespaceAll).List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list pods - %q", err)
+	}
+	controllers, err := self.kubeClient.ReplicationControllers(api.NamespaceAll).List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list replication controllers - %q", err)
+	}
+	// Nodes being removed are not considered for placing pods.
+	cluster.Minions.Items = make([]api.Node, 0, len(minions.Items))
+	for _, minion := range minions.Items {
+		if !self.removedNodes[minion.Name] {
+			cluster.Minions.Items = append(cluster.Minions.Items, minion)
+		}
+	}
+	cluster.Pods = pods.Items
+	cluster.Controllers = controllers.Items
 	return nil
 }
 
 func (self *realAutoScaler) applyPolicies(hostnameToNodesMap map[string]aggregator.Node) (*Cluster, error) {
 	clusterNodes := make(map[string]Node, 0)
 	for _, node := range hostnameToNodesMap {
+		if self.removedNodes[node.Hostname] {
+			continue
+		}
 		nodeShape, err := self.nodeShapes.GetNodeShapeWithCapacity(node.Capacity)
 		if err != nil {
 			glog.Fatal(err)
@@ -71,35 +133,63 @@ func (self *realAutoScaler) applyPolicies(hostnameToNodesMap map[string]aggregat
 		DefaultShape: self.defaultNodeShape,
 		Current:      clusterNodes,
 		New:          make([]string, 0),
+		Remove:       make([]string, 0),
 		Slack:        types.Resource{0, 0},
+		ShapeCosts:   self.shapeCosts,
+
+		newRequestedBy:    make(map[string][]string),
+		removeRequestedBy: make(map[string][]string),
+	}
+	if err := self.getSchedulingState(cluster); err != nil {
+		glog.Errorf("Skipping the policies that need the scheduling state - %q", err)
+		cluster.SchedulingStateErr = err
 	}
 
-	for title, policy := range self.policies {
-		glog.V(1).Infof("Applying policy %s", title)
+	for _, policy := range self.policies {
+		glog.V(1).Infof("Applying policy %s", policy.name)
 		glog.V(3).Infof("Cluster: %+v", cluster)
-		err := policy.PerformScaling(cluster)
-		if err != nil {
-			// TODO(vishh): Move on to applying other policies instead.
-			return nil, err
+		// The decisions of a failing policy are discarded.
+		newNodes := append([]string{}, cluster.New...)
+		removeNodes := append([]string{}, cluster.Remove...)
+		slack := cluster.Slack
+		if err := policy.policy.PerformScaling(cluster); err != nil {
+			glog.Errorf("Policy %s failed, skipping it - %q", policy.name, err)
+			self.recordDecision(actionFailedPolicy, "", "", "policy %s failed and was skipped: %v", policy.name, err)
+			cluster.New = newNodes
+			cluster.Remove = removeNodes
+			cluster.Slack = slack
+			continue
+		}
+		for _, shapeName := range addedEntries(newNodes, cluster.New) {
+			cluster.newRequestedBy[shapeName] = appendPolicy(cluster.newRequestedBy[shapeName], policy.name)
 		}
-		glog.V(3).Infof("Cluster after applying policy %s: %+v", title, cluster)
+		for _, hostname := range addedEntries(removeNodes, cluster.Remove) {
+			cluster.removeRequestedBy[hostname] = appendPolicy(cluster.removeRequestedBy[hostname], policy.name)
+		}
+		if !reflect.DeepEqual(newNodes, cluster.New) || !reflect.DeepEqual(removeNodes, cluster.Remove) {
+			glog.V(1).Infof("Policy %s changed the nodes to add from %v to %v and the nodes to remove from %v to %v",
+				policy.name, newNodes, cluster.New, removeNodes, cluster.Remove)
+		}
+		glog.V(3).Infof("Cluster after applying policy %s: %+v", policy.name, cluster)
 	}
 
 	return cluster, nil
 }
 
+// Returns a scaler that resizes the cluster reported by 'myAggregator' using 'myActuator'.
+// 'clock' is the time source of the scaler and its policies.
 func New(housekeeping time.Duration,
-	actuatorHostPort,
-	aggregatorHostPort,
-	clusterScalingPolicy string,
-	clusterScalingThreshold uint) (Scaler, error) {
-	myActuator, err := actuator.New(actuatorHostPort)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create actuator %q", err)
-	}
-	myAggregator, err := aggregator.New(aggregatorHostPort)
-	if err != nil {
-		return nil, err
+	myActuator actuator.Actuator,
+	myAggregator aggregator.Aggregator,
+	policyConfig PolicyConfig,
+	limits Limits,
+	kubeClient client.Interface,
+	shapeCostsFile string,
+	nodeCreationTimeout time.Duration,
+	retryNodeCreation bool,
+	clock util.Clock) (Scaler, error) {
+	if nodeCreationTimeout <= 0 {
+		return nil, fmt.Errorf("node creation timeout invalid: %v", nodeCreationTimeout)
 	}
 	nodeShapes, err := myActuator.GetNodeShapes()
 	if err != nil {
@@ -115,23 +205,33 @@ func New(housekeeping time.Duration,
 		return nil, err
 	}
 	glog.V(2).Infof("Default node shape is: %v", defaultNodeShape)
-	// List policies in the order of increasing priority
-	clusterPolicy, err := newClusterUsagePolicy(clusterScalingThreshold, clusterScalingPolicy)
+	shapeCosts, err := loadShapeCosts(shapeCostsFile, nodeShapes)
 	if err != nil {
 		return nil, err
 	}
-	policies := map[string]Policy{
-		"ClusterUsage": clusterPolicy,
+	if err := limits.validate(nodeShapes, policyConfig); err != nil {
+		return nil, err
+	}
+	policies, err := newPolicies(policyConfig, clock)
+	if err != nil {
+		return nil, err
 	}
 
 	return &realAutoScaler{
-		housekeeping:     housekeeping,
-		policies:         policies,
-		aggregator:       myAggregator,
-		actuator:         myActuator,
-		nodeShapes:       nodeShapes,
-		defaultNodeShape: defaultNodeShape,
-		existingNodes:    make(map[string]Node),
-		newNodes:         make(map[string]string),
+		housekeeping:        housekeeping,
+		policies:            policies,
+		aggregator:          myAggregator,
+		actuator:            myActuator,
+		kubeClient:          kubeClient,
+		nodeShapes:          nodeShapes,
+		defaultNodeShape:    defaultNodeShape,
+		shapeCosts:          shapeCosts,
+		existingNodes:       make(map[string]Node),
+		newNodes:            make(map[string]pendingNode),
+		nodeCreationTimeout: nodeCreationTimeout,
+		retryNodeCreation:   retryNodeCreation,
+		clock:               clock,
+		limits:              limits,
+		removedNodes:        make(map[string]bool),
 	}, nil
 }
@@ -0,0 +1,158 @@
+package scaler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/golang/glog"
+)
+
+// Bounds on the size of the cluster and on the rate at which it changes.
+// They apply to the decisions of all the policies.
+type Limits struct {
+	// Nodes are added to reach this many nodes, and never removed below it.
+	MinNodes int
+	// Nodes are never added beyond this many nodes. No limit if 0.
+	MaxNodes int
+	// Map of node shape name to the maximum number of nodes of that shape.
+	MaxNodesPerShape map[string]int
+	// Nodes are not added for this long after nodes were last added.
+	ScaleUpCooldown time.Duration
+	// Nodes are not removed for this long after nodes were last added or removed.
+	ScaleDownCooldown time.Duration
+	// Nodes are not removed if the peak hourly usage of the cluster would exceed this
+	// percentage of the remaining capacity. Set it below the threshold at which nodes
+	// are added so that the cluster does not flap. Disabled if 0.
+	ScaleDownMaxUsage uint
+}
+
+func (self *Limits) validate(shapes actuator.NodeShapes, policyConfig PolicyConfig) error {
+	if self.MinNodes < 0 || self.MaxNodes < 0 {
+		return fmt.Errorf("node count limits invalid: min %d, max %d", self.MinNodes, self.MaxNodes)
+	}
+	if self.MaxNodes > 0 && self.MinNodes > self.MaxNodes {
+		return fmt.Errorf("min node count %d is above max node count %d", self.MinNodes, self.MaxNodes)
+	}
+	for name, limit := range self.MaxNodesPerShape {
+		if _, err := shapes.GetNodeShapeWithType(name); err != nil {
+			return err
+		}
+		if limit < 0 {
+			return fmt.Errorf("node count limit invalid for node shape %s: %d", name, limit)
+		}
+	}
+	if self.ScaleUpCooldown < 0 || self.ScaleDownCooldown < 0 {
+		return fmt.Errorf("cooldowns invalid: scale up %v, scale down %v", self.ScaleUpCooldown, self.ScaleDownCooldown)
+	}
+	if self.ScaleDownMaxUsage > 100 {
+		return fmt.Errorf("scale down max usage invalid: %d", self.ScaleDownMaxUsage)
+	}
+	// As for the scale down policies, removals must stop well below the usage at which nodes are added.
+	for _, spec := range policyConfig.Policies {
+		if spec.Type == "ClusterUsage" && self.ScaleDownMaxUsage >= spec.Threshold {
+			return fmt.Errorf("scale down max usage %d must be below cluster usage threshold %d", self.ScaleDownMaxUsage, spec.Threshold)
+		}
+	}
+	return nil
+}
+
+// Returns the number of existing and pending nodes of each shape.
+func (self *realAutoScaler) getShapeCounts(cluster *Cluster) map[string]int {
+	shapeCounts := make(map[string]int)
+	for _, node := range cluster.Current {
+		shapeCounts[node.shapeName]++
+	}
+	for _, pending := range self.newNodes {
+		shapeCounts[pending.shapeName]++
+	}
+	return shapeCounts
+}
+
+// A node to add, along with the reason for adding it.
+type nodeRequest struct {
+	shapeName string
+	reason    string
+}
+
+// Returns the nodes of 'requested' that can be added within the limits, along with
+// the nodes of the default shape needed to reach the minimum cluster size.
+func (self *realAutoScaler) limitNewNodes(cluster *Cluster, requested []string) []nodeRequest {
+	count := len(cluster.Current) + len(self.newNodes)
+	shapeCounts := self.getShapeCounts(cluster)
+
+	allowed := []nodeRequest{}
+	if len(requested) > 0 && self.clock.Now().Before(self.lastScaleUp.Add(self.limits.ScaleUpCooldown)) {
+		self.recordDecision(actionSkipScaleUp, "", "", "not adding nodes %v within %v of the last scale up", requested, self.limits.ScaleUpCooldown)
+		requested = nil
+	}
+	for i, shapeName := range requested {
+		if self.limits.MaxNodes > 0 && count >= self.limits.MaxNodes {
+			self.recordDecision(actionSkipScaleUp, "", "", "not adding nodes %v beyond the max cluster size of %d", requested[i:], self.limits.MaxNodes)
+			break
+		}
+		if limit, ok := self.limits.MaxNodesPerShape[shapeName]; ok && shapeCounts[shapeName] >= limit {
+			self.recordDecision(actionSkipScaleUp, "", shapeName, "not adding a node beyond the limit of %d nodes of this type", limit)
+			continue
+		}
+		allowed = append(allowed, nodeRequest{shapeName, getRequestReason(cluster.newRequestedBy, shapeName)})
+		count++
+		shapeCounts[shapeName]++
+	}
+	// The minimum cluster size is reached regardless of cooldowns.
+	defaultShape := cluster.DefaultShape.Name
+	for count < self.limits.MinNodes {
+		if limit, ok := self.limits.MaxNodesPerShape[defaultShape]; ok && shapeCounts[defaultShape] >= limit {
+			glog.Warningf("Cannot reach the min cluster size of %d with nodes of type %s", self.limits.MinNodes, defaultShape)
+			break
+		}
+		allowed = append(allowed, nodeRequest{defaultShape, fmt.Sprintf("the cluster is below its min size of %d nodes", self.limits.MinNodes)})
+		count++
+		shapeCounts[defaultShape]++
+	}
+	return allowed
+}
+
+// Returns the nodes of 'requested' that can be removed within the limits.
+func (self *realAutoScaler) limitRemovedNodes(cluster *Cluster, requested []string) []string {
+	if len(requested) == 0 {
+		return nil
+	}
+	now := self.clock.Now()
+	if now.Before(self.lastScaleUp.Add(self.limits.ScaleDownCooldown)) || now.Before(self.lastScaleDown.Add(self.limits.ScaleDownCooldown)) {
+		self.recordDecision(actionSkipScaleDown, "", "", "not removing nodes %v within %v of the last resize", requested, self.limits.ScaleDownCooldown)
+		return nil
+	}
+
+	// Peak usage and capacity of the nodes with stats.
+	var usage, capacity types.Resource
+	for _, node := range cluster.Current {
+		if node.Usage.HourUsage.Valid {
+			usage.Cpu += node.Usage.HourUsage.Cpu.Max
+			usage.Memory += node.Usage.HourUsage.Memory.Max
+			capacity.Cpu += node.Capacity.Cpu
+			capacity.Memory += node.Capacity.Memory
+		}
+	}
+	count := len(cluster.Current)
+	allowed := []string{}
+	for i, hostname := range requested {
+		if count <= self.limits.MinNodes {
+			self.recordDecision(actionSkipScaleDown, "", "", "not removing nodes %v below the min cluster size of %d", requested[i:], self.limits.MinNodes)
+			break
+		}
+		if node, ok := cluster.Current[hostname]; ok && node.Usage.HourUsage.Valid && self.limits.ScaleDownMaxUsage > 0 {
+			remaining := subtractResource(capacity, node.Capacity)
+			maxUsage := uint64(self.limits.ScaleDownMaxUsage)
+			if usage.Cpu*100 > remaining.Cpu*maxUsage || usage.Memory*100 > remaining.Memory*maxUsage {
+				self.recordDecision(actionSkipScaleDown, hostname, node.shapeName, "the remaining nodes would be used above %d%% of their capacity", maxUsage)
+				continue
+			}
+			capacity = remaining
+		}
+		allowed = append(allowed, hostname)
+		count--
+	}
+	return allowed
+}
@@ -0,0 +1,114 @@
+package scaler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+)
+
+func TestLimitNewNodes(t *testing.T) {
+	tests := []struct {
+		name        string
+		limits      Limits
+		lastScaleUp time.Time
+		requested   []string
+		expected    []string
+	}{
+		{
+			name:      "no limits",
+			requested: []string{"large", "small"},
+			expected:  []string{"large", "small"},
+		},
+		{
+			name:      "max nodes and per shape limits",
+			limits:    Limits{MaxNodes: 3, MaxNodesPerShape: map[string]int{"large": 1}},
+			requested: []string{"large", "large", "small", "small"},
+			expected:  []string{"large", "small"},
+		},
+		{
+			name:        "scale up cooldown",
+			limits:      Limits{ScaleUpCooldown: 3 * time.Minute},
+			lastScaleUp: testStart.Add(-time.Minute),
+			requested:   []string{"small"},
+			expected:    nil,
+		},
+		{
+			name:        "min nodes during cooldown",
+			limits:      Limits{MinNodes: 2, ScaleUpCooldown: 3 * time.Minute},
+			lastScaleUp: testStart.Add(-time.Minute),
+			requested:   []string{"large"},
+			expected:    []string{"small"},
+		},
+	}
+	for _, test := range tests {
+		fakeActuator := &fakeActuator{}
+		cluster := newTestClusterWithShapes()
+		cluster.New = test.requested
+		scaler := newTestScaler(fakeActuator, cluster)
+		scaler.limits = test.limits
+		scaler.lastScaleUp = test.lastScaleUp
+		if err := scaler.handleClusterResizing(cluster); err != nil {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		if !reflect.DeepEqual(fakeActuator.created, test.expected) {
+			t.Errorf("%s: expected nodes %v to be created, got %v", test.name, test.expected, fakeActuator.created)
+		}
+	}
+}
+
+func TestLimitRemovedNodes(t *testing.T) {
+	tests := []struct {
+		name        string
+		limits      Limits
+		lastScaleUp time.Time
+		expected    []string
+	}{
+		{
+			name:     "within limits",
+			limits:   Limits{MinNodes: 1, ScaleDownMaxUsage: 80, ScaleDownCooldown: 10 * time.Minute},
+			expected: []string{"idle"},
+		},
+		{
+			name:   "min nodes",
+			limits: Limits{MinNodes: 2},
+		},
+		{
+			// The remaining node would be used at 70%.
+			name:   "hysteresis",
+			limits: Limits{ScaleDownMaxUsage: 60},
+		},
+		{
+			name:        "scale down cooldown",
+			limits:      Limits{ScaleDownCooldown: 10 * time.Minute},
+			lastScaleUp: testStart.Add(-5 * time.Minute),
+		},
+	}
+	for _, test := range tests {
+		fakeActuator := &fakeActuator{}
+		cluster := newTestCluster()
+		cluster.Remove = []string{"idle"}
+		scaler := newTestScaler(fakeActuator, cluster)
+		scaler.limits = test.limits
+		scaler.lastScaleUp = test.lastScaleUp
+		if err := scaler.handleClusterResizing(cluster); err != nil {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		if !reflect.DeepEqual(fakeActuator.removed, test.expected) {
+			t.Errorf("%s: expected nodes %v to be removed, got %v", test.name, test.expected, fakeActuator.removed)
+		}
+	}
+}
+
+func TestScaleDownMaxUsageBelowThreshold(t *testing.T) {
+	policyConfig := PolicyConfig{Policies: []PolicySpec{{Type: "ClusterUsage", Threshold: 90, Window: "hour"}}}
+	limits := Limits{ScaleDownMaxUsage: 70}
+	if err := limits.validate(actuator.NodeShapes{}, policyConfig); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	limits.ScaleDownMaxUsage = 90
+	if err := limits.validate(actuator.NodeShapes{}, policyConfig); err == nil {
+		t.Errorf("expected scale down max usage %d to be rejected", limits.ScaleDownMaxUsage)
+	}
+}
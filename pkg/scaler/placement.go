@@ -0,0 +1,117 @@
+package scaler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/resources"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+// Simulates the placement of pods on existing and hypothetical nodes using the scheduler predicates.
+type placementSimulator struct {
+	minions *api.NodeList
+	// Map of hostname to the pods placed on it.
+	podsByHost map[string][]api.Pod
+	predicates []scheduler.FitPredicate
+	// Number of hypothetical nodes added so far.
+	numNewNodes int
+}
+
+func newPlacementSimulator(minions []api.Node, podsByHost map[string][]api.Pod) *placementSimulator {
+	simulator := &placementSimulator{
+		minions:    &api.NodeList{Items: append([]api.Node{}, minions...)},
+		podsByHost: make(map[string][]api.Pod, len(podsByHost)),
+	}
+	for host, pods := range podsByHost {
+		simulator.podsByHost[host] = pods
+	}
+	nodeInfo := scheduler.StaticNodeInfo{NodeList: simulator.minions}
+	simulator.predicates = []scheduler.FitPredicate{
+		scheduler.NewResourceFitPredicate(nodeInfo),
+		scheduler.NewSelectorMatchPredicate(nodeInfo),
+		scheduler.PodFitsPorts,
+		scheduler.NoDiskConflict,
+	}
+	return simulator
+}
+
+// Returns an independent copy of the simulator.
+func (self *placementSimulator) clone() *placementSimulator {
+	simulator := newPlacementSimulator(self.minions.Items, self.podsByHost)
+	simulator.numNewNodes = self.numNewNodes
+	return simulator
+}
+
+// Returns true if 'pod' fits on any of the nodes.
+func (self *placementSimulator) fitsAnywhere(pod api.Pod) bool {
+	for _, minion := range self.minions.Items {
+		if self.fits(pod, minion.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if 'pod' passes all the predicates on 'host'.
+func (self *placementSimulator) fits(pod api.Pod, host string) bool {
+	for _, predicate := range self.predicates {
+		// None of the predicates look beyond the node.
+		fits, err := predicate(pod, self.podsByHost[host], host, nil)
+		if err != nil {
+			glog.V(2).Infof("Failed to check if pod %s fits on node %s: %v", pod.Name, host, err)
+			return false
+		}
+		if !fits {
+			return false
+		}
+	}
+	return true
+}
+
+// Place 'pod' on 'host' if it fits there.
+func (self *placementSimulator) placeOn(pod api.Pod, host string) bool {
+	if !self.fits(pod, host) {
+		return false
+	}
+	// Copy so that the pods passed to the simulator are left untouched.
+	self.podsByHost[host] = append(append([]api.Pod{}, self.podsByHost[host]...), pod)
+	return true
+}
+
+// Place 'pod' on the first node it fits on, other than 'exclude'.
+// Returns the hostname of the node, or false if the pod does not fit anywhere.
+func (self *placementSimulator) place(pod api.Pod, exclude string) (string, bool) {
+	for _, minion := range self.minions.Items {
+		if minion.Name != exclude && self.placeOn(pod, minion.Name) {
+			return minion.Name, true
+		}
+	}
+	return "", false
+}
+
+// Add an empty node of type 'shape'. Returns the hostname of the new node.
+func (self *placementSimulator) addNode(shape actuator.NodeShape) string {
+	self.numNewNodes++
+	name := fmt.Sprintf("new-%s-%d", shape.Name, self.numNewNodes)
+	self.minions.Items = append(self.minions.Items, newHypotheticalMinion(name, shape))
+	return name
+}
+
+// Returns a node that does not exist yet, with the capacity of 'shape' as seen by the scheduler.
+func newHypotheticalMinion(name string, shape actuator.NodeShape) api.Node {
+	cores := float64(shape.Capacity.Cpu) / 1000
+	return api.Node{
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Spec: api.NodeSpec{
+			Capacity: api.ResourceList{
+				resources.CPU:    util.NewIntOrStringFromString(strconv.FormatFloat(cores, 'f', -1, 64)),
+				resources.Memory: util.NewIntOrStringFromInt(int(shape.Capacity.Memory)),
+			},
+		},
+	}
+}
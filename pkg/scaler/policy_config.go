@@ -0,0 +1,113 @@
+package scaler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// Configuration of a policy of the scaler.
+type PolicySpec struct {
+	// Type of the policy: "ClusterUsage", "UnschedulablePods", "ScaleDown" or "Predictive".
+	Type string `json:"type"`
+	// Name used in logs. Defaults to the type.
+	Name string `json:"name,omitempty"`
+	// Percentage of resource usage the policy acts on. Used by ClusterUsage, ScaleDown and Predictive.
+	Threshold uint `json:"threshold,omitempty"`
+	// Usage window of the policy: "minute", "hour" or "day". Used by ClusterUsage and ScaleDown.
+	Window string `json:"window,omitempty"`
+	// Pods pending for less than this, e.g. "1m", are left to the scheduler. Used by UnschedulablePods.
+	MinPendingAge string `json:"min_pending_age,omitempty"`
+	// How far ahead, e.g. "30m", the forecast usage is provisioned for. Used by Predictive.
+	LeadTime string `json:"lead_time,omitempty"`
+	// File of cluster stats recorded by the scaler to learn the daily usage from on startup. Used by Predictive.
+	HistoryFile string `json:"history_file,omitempty"`
+}
+
+// Configuration of the policy chain of the scaler.
+type PolicyConfig struct {
+	// Policies in the order they are applied. Each policy sees, and may veto or
+	// amend, the decisions of the policies before it.
+	Policies []PolicySpec `json:"policies"`
+}
+
+// A policy of the chain, along with its name.
+type namedPolicy struct {
+	name   string
+	policy Policy
+}
+
+// Reads the policy chain from a JSON file, for example:
+// {"policies": [{"type": "ClusterUsage", "threshold": 90, "window": "hour"}, {"type": "ScaleDown", "threshold": 30, "window": "hour"}]}
+func LoadPolicyConfig(path string) (PolicyConfig, error) {
+	var config PolicyConfig
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read policy config - %q", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse policy config in %s - %q", path, err)
+	}
+	return config, nil
+}
+
+func newPolicy(spec PolicySpec, clock util.Clock) (Policy, error) {
+	switch spec.Type {
+	case "ClusterUsage":
+		return newClusterUsagePolicy(spec.Threshold, spec.Window)
+	case "UnschedulablePods":
+		minPendingAge := time.Duration(0)
+		if spec.MinPendingAge != "" {
+			var err error
+			if minPendingAge, err = time.ParseDuration(spec.MinPendingAge); err != nil {
+				return nil, fmt.Errorf("Unschedulable pod age invalid: %q", spec.MinPendingAge)
+			}
+		}
+		return newUnschedulablePodsPolicy(minPendingAge, clock)
+	case "ScaleDown":
+		return newScaleDownPolicy(spec.Threshold, spec.Window)
+	case "Predictive":
+		leadTime, err := time.ParseDuration(spec.LeadTime)
+		if err != nil {
+			return nil, fmt.Errorf("Predictive scaling lead time invalid: %q", spec.LeadTime)
+		}
+		return newPredictivePolicy(spec.Threshold, leadTime, spec.HistoryFile, clock)
+	}
+	return nil, fmt.Errorf("unknown policy type %q", spec.Type)
+}
+
+// Returns the policy chain described by 'config'.
+func newPolicies(config PolicyConfig, clock util.Clock) ([]namedPolicy, error) {
+	if len(config.Policies) == 0 {
+		return nil, fmt.Errorf("no scaling policies configured")
+	}
+	// Nodes must be removed well below the usage at which they are added, or the cluster flaps.
+	for _, scaleDown := range config.Policies {
+		for _, scaleUp := range config.Policies {
+			if scaleDown.Type == "ScaleDown" && scaleUp.Type == "ClusterUsage" && scaleDown.Threshold >= scaleUp.Threshold {
+				return nil, fmt.Errorf("scale down threshold %d must be below cluster usage threshold %d", scaleDown.Threshold, scaleUp.Threshold)
+			}
+		}
+	}
+	policies := make([]namedPolicy, 0, len(config.Policies))
+	names := make(map[string]bool)
+	for _, spec := range config.Policies {
+		name := spec.Name
+		if name == "" {
+			name = spec.Type
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicate policy name %q", name)
+		}
+		names[name] = true
+		policy, err := newPolicy(spec, clock)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy %s - %q", name, err)
+		}
+		policies = append(policies, namedPolicy{name, policy})
+	}
+	return policies, nil
+}
@@ -0,0 +1,164 @@
+package scaler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/aggregator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+func TestLoadPolicyConfig(t *testing.T) {
+	tests := []struct {
+		contents string
+		names    []string
+	}{
+		{
+			contents: `{"policies": [{"type": "ScaleDown", "threshold": 30, "window": "hour"}, {"type": "UnschedulablePods", "min_pending_age": "2m"}, {"type": "ClusterUsage", "name": "Burst", "threshold": 80, "window": "minute"}]}`,
+			names:    []string{"ScaleDown", "UnschedulablePods", "Burst"},
+		},
+		{
+			contents: `{"policies": [{"type": "ScaleDown", "threshold": 30, "window": "hour"}, {"type": "Predictive", "threshold": 80, "lead_time": "30m"}]}`,
+			names:    []string{"ScaleDown", "Predictive"},
+		},
+		{contents: `{"policies": []}`},
+		{contents: `{"policies": [{"type": "Predictive", "threshold": 80}]}`},
+		{contents: `{"policies": [{"type": "ClusterUsage", "threshold": 80}, {"type": "ScaleDown", "threshold": 80, "window": "hour"}]}`},
+		{contents: `{"policies": [{"type": "Magic"}]}`},
+		{contents: `{"policies": [{"type": "ScaleDown", "threshold": 30, "window": "week"}]}`},
+		{contents: `{"policies": [{"type": "UnschedulablePods", "min_pending_age": "soon"}]}`},
+		{contents: `{"policies": [{"type": "UnschedulablePods"}, {"type": "UnschedulablePods"}]}`},
+	}
+	for _, test := range tests {
+		file, err := ioutil.TempFile("", "policy_config")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		if _, err := file.WriteString(test.contents); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+		config, err := LoadPolicyConfig(file.Name())
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", test.contents, err)
+		}
+		policies, err := newPolicies(config, util.RealClock{})
+		if test.names == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.contents)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", test.contents, err)
+		}
+		names := []string{}
+		for _, policy := range policies {
+			names = append(names, policy.name)
+		}
+		if !reflect.DeepEqual(names, test.names) {
+			t.Errorf("%s: expected policies %v, got %v", test.contents, test.names, names)
+		}
+	}
+}
+
+type policyFunc func(*Cluster) error
+
+func (self policyFunc) PerformScaling(cluster *Cluster) error {
+	return self(cluster)
+}
+
+func TestApplyPoliciesInOrder(t *testing.T) {
+	scaler := &realAutoScaler{
+		policies: []namedPolicy{
+			{"add", policyFunc(func(cluster *Cluster) error {
+				cluster.New = append(cluster.New, "small", "small")
+				return nil
+			})},
+			{"fail", policyFunc(func(cluster *Cluster) error {
+				cluster.New = append(cluster.New, "large")
+				cluster.Remove = append(cluster.Remove, "busy")
+				return fmt.Errorf("failed")
+			})},
+			{"veto", policyFunc(func(cluster *Cluster) error {
+				cluster.New = cluster.New[:1]
+				return nil
+			})},
+		},
+		nodeShapes:       actuator.NewNodeShapes([]actuator.NodeShape{smallShape, largeShape}),
+		defaultNodeShape: smallShape,
+		kubeClient:       &client.Fake{},
+		removedNodes:     make(map[string]bool),
+		clock:            &util.FakeClock{Time: testStart},
+	}
+	cluster, err := scaler.applyPolicies(map[string]aggregator.Node{"busy": newRecordedNode("busy", 90)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cluster.New, []string{"small"}) || len(cluster.Remove) != 0 {
+		t.Errorf("expected a single small node to be added, got %v and %v removed", cluster.New, cluster.Remove)
+	}
+	if !reflect.DeepEqual(cluster.newRequestedBy, map[string][]string{"small": {"add"}}) {
+		t.Errorf("expected the small nodes to be requested by policy add, got %v", cluster.newRequestedBy)
+	}
+	decisions := scaler.GetStatus().Decisions
+	if len(decisions) != 1 || decisions[0].Action != actionFailedPolicy {
+		t.Errorf("expected the failure of policy fail to be recorded, got %+v", decisions)
+	}
+}
+
+type failingNodes struct {
+	*client.FakeNodes
+}
+
+func (self failingNodes) List() (*api.NodeList, error) {
+	return nil, fmt.Errorf("apiserver unavailable")
+}
+
+// A client whose nodes cannot be listed.
+type unavailableClient struct {
+	*client.Fake
+}
+
+func (self unavailableClient) Nodes() client.NodeInterface {
+	return failingNodes{&client.FakeNodes{Fake: self.Fake}}
+}
+
+func TestApplyPoliciesWithoutSchedulingState(t *testing.T) {
+	scaleDown, err := newScaleDownPolicy(30, "hour")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scaler := &realAutoScaler{
+		policies: []namedPolicy{
+			{"usage", policyFunc(func(cluster *Cluster) error {
+				cluster.New = append(cluster.New, "small")
+				return nil
+			})},
+			{"scaleDown", scaleDown},
+		},
+		nodeShapes:       actuator.NewNodeShapes([]actuator.NodeShape{smallShape, largeShape}),
+		defaultNodeShape: smallShape,
+		kubeClient:       unavailableClient{&client.Fake{}},
+		removedNodes:     make(map[string]bool),
+		clock:            &util.FakeClock{Time: testStart},
+	}
+	cluster, err := scaler.applyPolicies(map[string]aggregator.Node{"idle": newRecordedNode("idle", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cluster.New, []string{"small"}) || len(cluster.Remove) != 0 {
+		t.Errorf("expected the usage policy to add a small node, got %v and %v removed", cluster.New, cluster.Remove)
+	}
+	decisions := scaler.GetStatus().Decisions
+	if len(decisions) != 1 || decisions[0].Action != actionFailedPolicy {
+		t.Errorf("expected the scale down policy to be skipped, got %+v", decisions)
+	}
+}
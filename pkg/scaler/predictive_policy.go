@@ -0,0 +1,163 @@
+package scaler
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/aggregator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+// Parameters of the seasonal model of the predictive policy.
+const (
+	seasonalSlot  = 15 * time.Minute
+	seasonalAlpha = 0.5
+)
+
+// Adds nodes ahead of the daily peaks of the cluster usage, and holds the removal
+// of nodes that the forecast peaks need. The usage of the cluster is learned per
+// time of day from the usage reported by the aggregator, and optionally from the
+// stats recorded in a history file. The policy must come after the policies
+// removing nodes in the chain to hold their removals.
+type predictivePolicy struct {
+	// Percentage of the cluster capacity the forecast usage should stay below.
+	threshold uint
+	// How far ahead the forecast peaks are provisioned for. Should cover the
+	// time it takes for new nodes to join the cluster.
+	leadTime time.Duration
+	model    *seasonalModel
+	clock    util.Clock
+}
+
+// Returns the total 90th percentile usage over the last minute of 'nodes', or
+// false if none of the nodes have recent usage.
+func getClusterUsage(nodes map[string]aggregator.Node) (types.Resource, bool) {
+	var usage types.Resource
+	found := false
+	for _, node := range nodes {
+		if !node.Usage.MinuteUsage.Valid {
+			continue
+		}
+		usage.Cpu += node.Usage.MinuteUsage.Cpu.Ninety
+		usage.Memory += node.Usage.MinuteUsage.Memory.Ninety
+		found = true
+	}
+	return usage, found
+}
+
+// Seed the model with the cluster stats recorded in 'historyFile'.
+func (self *predictivePolicy) loadHistory(historyFile string) error {
+	file, err := os.Open(historyFile)
+	if os.IsNotExist(err) {
+		glog.Infof("No cluster stats history found at %s", historyFile)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	snapshots, err := aggregator.ReadSnapshots(file)
+	if err != nil {
+		return fmt.Errorf("failed to read cluster stats history %s - %q", historyFile, err)
+	}
+	for _, snapshot := range snapshots {
+		if usage, ok := getClusterUsage(snapshot.Nodes); ok {
+			self.model.observe(snapshot.Timestamp, usage)
+		}
+	}
+	glog.Infof("Learned the daily usage of the cluster from %d snapshots in %s", len(snapshots), historyFile)
+	return nil
+}
+
+// Returns true if 'capacity' covers all of 'needed'.
+func covers(capacity, needed types.Resource) bool {
+	return capacity.Cpu >= needed.Cpu && capacity.Memory >= needed.Memory
+}
+
+func (self *predictivePolicy) PerformScaling(cluster *Cluster) error {
+	now := self.clock.Now()
+	nodes := make(map[string]aggregator.Node, len(cluster.Current))
+	var capacity types.Resource
+	for hostname, node := range cluster.Current {
+		nodes[hostname] = node.Node
+		capacity.Cpu += node.Capacity.Cpu
+		capacity.Memory += node.Capacity.Memory
+	}
+	if usage, ok := getClusterUsage(nodes); ok {
+		self.model.observe(now, usage)
+	}
+	forecast, ok := self.model.forecast(now, self.leadTime)
+	if !ok {
+		glog.V(1).Infof("No usage forecast until %v yet", now.Add(self.leadTime))
+		return nil
+	}
+	needed := types.Resource{
+		Cpu:    forecast.Cpu * 100 / uint64(self.threshold),
+		Memory: forecast.Memory * 100 / uint64(self.threshold),
+	}
+	for _, shapeName := range cluster.New {
+		shape, err := cluster.Shapes.GetNodeShapeWithType(shapeName)
+		if err != nil {
+			return err
+		}
+		capacity.Cpu += shape.Capacity.Cpu
+		capacity.Memory += shape.Capacity.Memory
+	}
+
+	// Hold the removals that would leave too little capacity for the forecast peak.
+	remove := []string{}
+	for _, hostname := range cluster.Remove {
+		node, ok := cluster.Current[hostname]
+		if !ok {
+			remove = append(remove, hostname)
+			continue
+		}
+		remaining := subtractResource(capacity, node.Capacity)
+		if !covers(remaining, needed) {
+			glog.Infof("Holding the removal of node %s, the forecast usage until %v needs cpu %d and memory %d",
+				hostname, now.Add(self.leadTime), needed.Cpu, needed.Memory)
+			continue
+		}
+		capacity = remaining
+		remove = append(remove, hostname)
+	}
+	cluster.Remove = remove
+
+	demand := subtractResource(needed, capacity)
+	if demand.Cpu == 0 && demand.Memory == 0 {
+		return nil
+	}
+	shapes := selectShapes(cluster, demand)
+	glog.Infof("The forecast usage until %v exceeds %d%% of the cluster capacity. Adding nodes %v for cpu %d and memory %d.",
+		now.Add(self.leadTime), self.threshold, shapes, demand.Cpu, demand.Memory)
+	cluster.New = append(cluster.New, shapes...)
+	return nil
+}
+
+// Returns a predictive policy keeping the forecast usage below 'threshold' percent
+// of the cluster capacity 'leadTime' ahead. The model is seeded with the stats
+// recorded in 'historyFile', if set.
+func newPredictivePolicy(threshold uint, leadTime time.Duration, historyFile string, clock util.Clock) (Policy, error) {
+	if threshold <= 0 || threshold > 100 {
+		return nil, fmt.Errorf("Predictive scaling threshold invalid: %d", threshold)
+	}
+	if leadTime <= 0 {
+		return nil, fmt.Errorf("Predictive scaling lead time invalid: %v", leadTime)
+	}
+	policy := &predictivePolicy{
+		threshold: threshold,
+		leadTime:  leadTime,
+		model:     newSeasonalModel(seasonalSlot, seasonalAlpha),
+		clock:     clock,
+	}
+	if historyFile != "" {
+		if err := policy.loadHistory(historyFile); err != nil {
+			return nil, err
+		}
+	}
+	glog.Infof("Provisioning for the usage forecast %v ahead, with threshold %d", leadTime, threshold)
+	return policy, nil
+}
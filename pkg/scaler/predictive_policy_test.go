@@ -0,0 +1,189 @@
+package scaler
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// Feed 'model' with 'days' of usage sampled every minute from 'series', starting at testStart.
+func observeSeries(model *seasonalModel, days int, series func(t time.Time) uint64) {
+	for t := testStart; t.Before(testStart.Add(time.Duration(days) * 24 * time.Hour)); t = t.Add(time.Minute) {
+		usage := series(t)
+		model.observe(t, types.Resource{Cpu: usage, Memory: usage})
+	}
+}
+
+// A daily peak of 3000 from noon to 2pm over a base usage of 1000.
+func middayPeak(t time.Time) uint64 {
+	if t.Hour() >= 12 && t.Hour() < 14 {
+		return 3000
+	}
+	return 1000
+}
+
+func TestSeasonalModelForecast(t *testing.T) {
+	model := newSeasonalModel(seasonalSlot, seasonalAlpha)
+	if _, ok := model.forecast(testStart, time.Hour); ok {
+		t.Errorf("expected no forecast without observations")
+	}
+	observeSeries(model, 3, middayPeak)
+
+	day := testStart.Add(3 * 24 * time.Hour)
+	tests := []struct {
+		from     time.Duration
+		horizon  time.Duration
+		expected uint64
+	}{
+		{11 * time.Hour, 30 * time.Minute, 1000},
+		{11 * time.Hour, 90 * time.Minute, 3000},
+		{13 * time.Hour, 0, 3000},
+		{14 * time.Hour, 4 * time.Hour, 1000},
+		// Wraps around midnight.
+		{23 * time.Hour, 2 * time.Hour, 1000},
+	}
+	for _, test := range tests {
+		forecast, ok := model.forecast(day.Add(test.from), test.horizon)
+		if !ok || forecast.Cpu != test.expected || forecast.Memory != test.expected {
+			t.Errorf("expected forecast %d from %v for %v, got %+v", test.expected, test.from, test.horizon, forecast)
+		}
+	}
+}
+
+func TestSeasonalModelFollowsSinusoid(t *testing.T) {
+	model := newSeasonalModel(seasonalSlot, seasonalAlpha)
+	// Peaks at 3000 at 6am and bottoms out at 1000 at 6pm.
+	observeSeries(model, 4, func(t time.Time) uint64 {
+		sinceMidnight := t.Sub(t.Truncate(24 * time.Hour))
+		return uint64(2000 + 1000*math.Sin(2*math.Pi*sinceMidnight.Hours()/24))
+	})
+
+	day := testStart.Add(4 * 24 * time.Hour)
+	peak, _ := model.forecast(day.Add(5*time.Hour), 2*time.Hour)
+	if peak.Cpu < 2950 || peak.Cpu > 3000 {
+		t.Errorf("expected a forecast peak close to 3000, got %d", peak.Cpu)
+	}
+	trough, _ := model.forecast(day.Add(18*time.Hour), 0)
+	if trough.Cpu < 1000 || trough.Cpu > 1100 {
+		t.Errorf("expected a forecast close to 1000 at the trough, got %d", trough.Cpu)
+	}
+}
+
+func TestSeasonalModelAdapts(t *testing.T) {
+	model := newSeasonalModel(seasonalSlot, seasonalAlpha)
+	observeSeries(model, 2, middayPeak)
+	// The peak doubles on the third day.
+	for t := testStart.Add(48 * time.Hour); t.Before(testStart.Add(72 * time.Hour)); t = t.Add(time.Minute) {
+		usage := middayPeak(t) * 2
+		model.observe(t, types.Resource{Cpu: usage, Memory: usage})
+	}
+	forecast, _ := model.forecast(testStart.Add(72*time.Hour+12*time.Hour), time.Hour)
+	if forecast.Cpu != 4500 {
+		t.Errorf("expected a forecast of 4500 halfway between the old and new peaks, got %d", forecast.Cpu)
+	}
+}
+
+func newTestPredictivePolicy(now time.Time) *predictivePolicy {
+	policy := &predictivePolicy{
+		threshold: 90,
+		leadTime:  30 * time.Minute,
+		model:     newSeasonalModel(seasonalSlot, seasonalAlpha),
+		clock:     &util.FakeClock{Time: now},
+	}
+	observeSeries(policy.model, 3, func(t time.Time) uint64 {
+		// 100% of two nodes at the midday peak, 20% otherwise.
+		if t.Hour() == 12 {
+			return 2000
+		}
+		return 400
+	})
+	return policy
+}
+
+// Returns a cluster of two nodes using 20% of their capacity, asking to remove "idle".
+func newTestPredictiveCluster() *Cluster {
+	return &Cluster{
+		Shapes:       actuator.NewNodeShapes([]actuator.NodeShape{smallShape, largeShape}),
+		DefaultShape: smallShape,
+		Current: map[string]Node{
+			"busy": newPredictiveTestNode("busy"),
+			"idle": newPredictiveTestNode("idle"),
+		},
+		New:    []string{},
+		Remove: []string{"idle"},
+	}
+}
+
+func newPredictiveTestNode(hostname string) Node {
+	node := newTestNode(hostname, 20)
+	node.Usage.MinuteUsage.Cpu.Ninety = 200
+	node.Usage.MinuteUsage.Memory.Ninety = 200
+	return node
+}
+
+func TestPredictivePolicyAddsNodesAheadOfPeak(t *testing.T) {
+	day := testStart.Add(3 * 24 * time.Hour)
+	policy := newTestPredictivePolicy(day.Add(11*time.Hour + 45*time.Minute))
+	cluster := newTestPredictiveCluster()
+	if err := policy.PerformScaling(cluster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The peak needs 2222 of cpu and memory to stay below 90% usage.
+	if !reflect.DeepEqual(cluster.New, []string{"small"}) {
+		t.Errorf("expected a small node to be added, got %v", cluster.New)
+	}
+	if len(cluster.Remove) != 0 {
+		t.Errorf("expected the removal of idle to be held, got %v", cluster.Remove)
+	}
+}
+
+func TestPredictivePolicyHoldsScaleDown(t *testing.T) {
+	day := testStart.Add(3 * 24 * time.Hour)
+	// The peak is more than the lead time away. The nodes are enough until then.
+	policy := newTestPredictivePolicy(day.Add(10 * time.Hour))
+	cluster := newTestPredictiveCluster()
+	if err := policy.PerformScaling(cluster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cluster.New) != 0 || !reflect.DeepEqual(cluster.Remove, []string{"idle"}) {
+		t.Errorf("expected idle to be removed without adding nodes, got new %v and remove %v", cluster.New, cluster.Remove)
+	}
+
+	// Removing both idle and spare would leave too little capacity for the peak.
+	policy.clock = &util.FakeClock{Time: day.Add(11*time.Hour + 40*time.Minute)}
+	policy.leadTime = 20 * time.Minute
+	cluster = newTestPredictiveCluster()
+	cluster.Current["busy"] = newTestNode("busy", 0)
+	cluster.Current["idle"] = newTestNode("idle", 0)
+	cluster.Current["spare"] = newTestNode("spare", 0)
+	cluster.Current["extra"] = newTestNode("extra", 0)
+	cluster.Remove = []string{"idle", "spare"}
+	if err := policy.PerformScaling(cluster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cluster.New) != 0 || !reflect.DeepEqual(cluster.Remove, []string{"idle"}) {
+		t.Errorf("expected only idle to be removed, got new %v and remove %v", cluster.New, cluster.Remove)
+	}
+}
+
+func TestPredictivePolicyWithoutForecast(t *testing.T) {
+	policy := &predictivePolicy{
+		threshold: 90,
+		leadTime:  30 * time.Minute,
+		model:     newSeasonalModel(seasonalSlot, seasonalAlpha),
+		clock:     &util.FakeClock{Time: testStart},
+	}
+	cluster := newTestPredictiveCluster()
+	if err := policy.PerformScaling(cluster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The usage observed now is the only forecast, and it allows the removal.
+	if len(cluster.New) != 0 || !reflect.DeepEqual(cluster.Remove, []string{"idle"}) {
+		t.Errorf("expected idle to be removed without adding nodes, got new %v and remove %v", cluster.New, cluster.Remove)
+	}
+}
@@ -1,9 +1,102 @@
 package scaler
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/aggregator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/golang/glog"
 )
 
+// A node creation requested by the scaler that has not joined the cluster yet.
+type pendingNode struct {
+	shapeName string
+	// The node is given up on if it has not joined the cluster by then.
+	deadline time.Time
+	// True if the creation replaces an earlier one that timed out.
+	retry bool
+}
+
+// Request the creation of a node of type 'shapeName' and track it until it joins the cluster.
+func (self *realAutoScaler) createNode(shapeName, reason string, retry bool) {
+	hostname, err := self.actuator.CreateNode(shapeName)
+	if err != nil {
+		self.recordDecision(actionFailedScaleUp, "", shapeName, "failed to create a node %s: %v", reason, err)
+		return
+	}
+	self.recordDecision(actionScaleUp, hostname, shapeName, "%s", reason)
+	self.lastScaleUp = self.clock.Now()
+	self.newNodes[hostname] = pendingNode{
+		shapeName: shapeName,
+		deadline:  self.clock.Now().Add(self.nodeCreationTimeout),
+		retry:     retry,
+	}
+}
+
+// Returns the cheapest shape, other than 'failed', with at least the capacity of 'failed'.
+// Shapes with as many nodes in 'shapeCounts' as their limit in 'shapeLimits' are skipped.
+func getRetryShape(cluster *Cluster, failed actuator.NodeShape, shapeCounts, shapeLimits map[string]int) (actuator.NodeShape, bool) {
+	var best actuator.NodeShape
+	for _, shape := range cluster.Shapes.List() {
+		if shape.Name == failed.Name || shape.Capacity.Cpu < failed.Capacity.Cpu || shape.Capacity.Memory < failed.Capacity.Memory {
+			continue
+		}
+		if limit, ok := shapeLimits[shape.Name]; ok && shapeCounts[shape.Name] >= limit {
+			continue
+		}
+		if best.Name == "" || cluster.shapeCost(shape) < cluster.shapeCost(best) {
+			best = shape
+		}
+	}
+	return best, best.Name != ""
+}
+
+// Give up on the pending node creations that are past their deadline. The
+// provisioner is asked about each of them: an instance that exists but never
+// joined the cluster is removed. Failed creations are optionally retried once,
+// on a different shape.
+func (self *realAutoScaler) expirePendingNodes(cluster *Cluster) {
+	now := self.clock.Now()
+	for hostname, pending := range self.newNodes {
+		if now.Before(pending.deadline) {
+			continue
+		}
+		exists, err := self.actuator.NodeExists(hostname)
+		if err != nil {
+			glog.Errorf("Failed to check if pending node %s exists - %q", hostname, err)
+			continue
+		}
+		if exists {
+			glog.Warningf("Node %s did not join the cluster by %v. Removing it.", hostname, pending.deadline)
+			if err := self.actuator.RemoveNode(hostname); err != nil {
+				glog.Errorf("Failed to remove node %s - %q", hostname, err)
+				continue
+			}
+		} else {
+			glog.Warningf("Node %s does not exist anymore and never joined the cluster", hostname)
+		}
+		delete(self.newNodes, hostname)
+		self.recordDecision(actionCreationTimeout, hostname, pending.shapeName, "the node did not join the cluster by %v", pending.deadline)
+
+		if !self.retryNodeCreation || pending.retry {
+			continue
+		}
+		shape, err := cluster.Shapes.GetNodeShapeWithType(pending.shapeName)
+		if err != nil {
+			glog.Error(err)
+			continue
+		}
+		retryShape, ok := getRetryShape(cluster, shape, self.getShapeCounts(cluster), self.limits.MaxNodesPerShape)
+		if !ok {
+			glog.Warningf("No node shape to retry the creation of node %s on", hostname)
+			continue
+		}
+		self.createNode(retryShape.Name, fmt.Sprintf("retry of node %s that did not join the cluster", hostname), true)
+	}
+}
+
 func (self *realAutoScaler) updateNewNodes(cluster *Cluster) {
 	for _, node := range cluster.Current {
 		if _, ok := self.newNodes[node.Hostname]; ok {
@@ -59,16 +152,15 @@ func (self *realAutoScaler) needResizing(cluster *Cluster) (newNodes []string) {
 
 	// Update pending node creations
 	self.updateNewNodes(cluster)
+	self.expirePendingNodes(cluster)
 
 	// Update the scalers view of existing nodes.
 	// TODO(vishh): If the net effect is a reduction in cluster size then consider adding new nodes, if required, even if there are pending new node creations.
 	_, _ = self.updateExistingNodes(cluster)
 
 	// Some nodes are yet to become active. Let the cluster settle down before altering it.
+	// Pending nodes that failed or got removed by another service are given up on by expirePendingNodes.
 	if len(self.newNodes) > 0 {
-		// There is a chance that the newly created node became inactive or got removed by another service.
-		// We should either have a timeout and give up on the pending nodes
-		// or query an authoritative source for the state of the node.
 		return
 	}
 
@@ -78,15 +170,65 @@ func (self *realAutoScaler) needResizing(cluster *Cluster) (newNodes []string) {
 	return
 }
 
+// Forget about removed nodes once the aggregator stops reporting them.
+func (self *realAutoScaler) updateRemovedNodes(hostnameToNodesMap map[string]aggregator.Node) {
+	for hostname := range self.removedNodes {
+		if _, ok := hostnameToNodesMap[hostname]; !ok {
+			delete(self.removedNodes, hostname)
+		}
+	}
+}
+
+// Remove the node and drain it. The instance is removed first, so that a failure
+// leaves the node in the cluster to be removed again by a later housekeeping rather
+// than running unnoticed. Its pods are then deleted so that their replication
+// controllers recreate them on the remaining nodes, and the node is deleted from the
+// apiserver last.
+func (self *realAutoScaler) removeNode(hostname string, cluster *Cluster) error {
+	glog.Infof("Removing node %s", hostname)
+	if err := self.actuator.RemoveNode(hostname); err != nil {
+		return fmt.Errorf("failed to remove node %s - %q", hostname, err)
+	}
+	self.removedNodes[hostname] = true
+	delete(self.existingNodes, hostname)
+	var errs []error
+	for _, pod := range cluster.Pods {
+		if pod.Status.Host != hostname {
+			continue
+		}
+		glog.V(1).Infof("Deleting pod %s/%s from node %s", pod.Namespace, pod.Name, hostname)
+		if err := self.kubeClient.Pods(pod.Namespace).Delete(pod.Name); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete pod %s/%s - %q", pod.Namespace, pod.Name, err))
+		}
+	}
+	if err := self.kubeClient.Nodes().Delete(hostname); err != nil {
+		errs = append(errs, fmt.Errorf("failed to delete node %s from the apiserver - %q", hostname, err))
+	}
+	return util.SliceToError(errs)
+}
+
 func (self *realAutoScaler) handleClusterResizing(cluster *Cluster) error {
-	newNodes := self.needResizing(cluster)
+	newNodes := self.limitNewNodes(cluster, self.needResizing(cluster))
 	// Create new nodes if needed.
-	for _, shapeName := range newNodes {
-		hostname, err := self.actuator.CreateNode(shapeName)
-		if err != nil {
-			glog.Errorf("Failed to create a new node - %q", err)
+	for _, request := range newNodes {
+		self.createNode(request.shapeName, request.reason, false)
+	}
+
+	// Never shrink the cluster while it is growing.
+	if len(cluster.New) > 0 || len(self.newNodes) > 0 {
+		return nil
+	}
+	for _, hostname := range self.limitRemovedNodes(cluster, cluster.Remove) {
+		shapeName := cluster.Current[hostname].shapeName
+		err := self.removeNode(hostname, cluster)
+		if err != nil && !self.removedNodes[hostname] {
+			self.recordDecision(actionFailedScaleDown, hostname, shapeName, "failed to remove the node %s: %v", getRequestReason(cluster.removeRequestedBy, hostname), err)
 		} else {
-			self.newNodes[hostname] = shapeName
+			if err != nil {
+				glog.Errorf("Failed to clean up after removing node %s - %q", hostname, err)
+			}
+			self.recordDecision(actionScaleDown, hostname, shapeName, "%s", getRequestReason(cluster.removeRequestedBy, hostname))
+			self.lastScaleDown = self.clock.Now()
 		}
 	}
 
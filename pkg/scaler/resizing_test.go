@@ -0,0 +1,89 @@
+package scaler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/record"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+func newTestScaler(fakeActuator *fakeActuator, cluster *Cluster) *realAutoScaler {
+	scaler := &realAutoScaler{
+		actuator:            fakeActuator,
+		kubeClient:          &client.Fake{},
+		existingNodes:       make(map[string]Node),
+		newNodes:            make(map[string]pendingNode),
+		nodeCreationTimeout: 10 * time.Minute,
+		retryNodeCreation:   true,
+		clock:               &util.FakeClock{Time: testStart},
+		removedNodes:        make(map[string]bool),
+	}
+	for hostname, node := range cluster.Current {
+		scaler.existingNodes[hostname] = node
+	}
+	return scaler
+}
+
+func TestPendingNodeCreationTimeout(t *testing.T) {
+	events := make(chan *api.Event, 10)
+	watcher := record.GetEvents(func(event *api.Event) { events <- event })
+	defer watcher.Stop()
+
+	fakeActuator := &fakeActuator{existing: map[string]bool{"stuck": true}}
+	cluster := newTestClusterWithShapes()
+	scaler := newTestScaler(fakeActuator, cluster)
+	scaler.newNodes["stuck"] = pendingNode{shapeName: "small", deadline: testStart}
+	scaler.newNodes["slow"] = pendingNode{shapeName: "small", deadline: testStart.Add(time.Minute)}
+
+	if err := scaler.handleClusterResizing(cluster); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fakeActuator.removed, []string{"stuck"}) {
+		t.Errorf("expected the node that never joined to be removed, got %v", fakeActuator.removed)
+	}
+	if !reflect.DeepEqual(fakeActuator.created, []string{"large"}) {
+		t.Errorf("expected the creation to be retried on a large node, got %v", fakeActuator.created)
+	}
+	expected := map[string]pendingNode{
+		"slow":    {shapeName: "small", deadline: testStart.Add(time.Minute)},
+		"large-1": {shapeName: "large", deadline: testStart.Add(10 * time.Minute), retry: true},
+	}
+	if !reflect.DeepEqual(scaler.newNodes, expected) {
+		t.Errorf("expected pending nodes %v, got %v", expected, scaler.newNodes)
+	}
+	timeout := time.After(time.Second)
+	for found := false; !found; {
+		select {
+		case event := <-events:
+			found = event.InvolvedObject.Name == "stuck" && event.Reason == actionCreationTimeout
+		case <-timeout:
+			t.Fatalf("expected an event for node stuck")
+		}
+	}
+}
+
+func TestPendingNodeRetryTimeout(t *testing.T) {
+	fakeActuator := &fakeActuator{}
+	cluster := newTestClusterWithShapes()
+	cluster.New = []string{"small"}
+	scaler := newTestScaler(fakeActuator, cluster)
+	scaler.newNodes["gone"] = pendingNode{shapeName: "small", deadline: testStart, retry: true}
+
+	if err := scaler.handleClusterResizing(cluster); err != nil {
+		t.Fatal(err)
+	}
+	if len(fakeActuator.removed) != 0 {
+		t.Errorf("expected no nodes to be removed, got %v", fakeActuator.removed)
+	}
+	// The retry is not retried again, and the scaler moves on to the requested nodes.
+	if !reflect.DeepEqual(fakeActuator.created, []string{"small"}) {
+		t.Errorf("expected a small node to be created, got %v", fakeActuator.created)
+	}
+	if _, ok := scaler.newNodes["gone"]; ok {
+		t.Errorf("expected node gone to be given up on")
+	}
+}
@@ -0,0 +1,164 @@
+package scaler
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/aggregator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+	"github.com/golang/glog"
+)
+
+// Removes nodes whose peak usage stayed below a threshold for the whole usage window,
+// provided that all their pods are replicated and fit on the remaining nodes.
+type scaleDownPolicy struct {
+	threshold     uint
+	scalingPolicy int
+}
+
+// Returns the peak usage over the window required by the policy if available.
+func (self *scaleDownPolicy) getPeakUsage(derivedStats aggregator.DerivedStats) *types.Resource {
+	switch self.scalingPolicy {
+	case minute:
+		if derivedStats.MinuteUsage.Valid {
+			return &types.Resource{Cpu: derivedStats.MinuteUsage.Cpu.Max, Memory: derivedStats.MinuteUsage.Memory.Max}
+		}
+	case hour:
+		if derivedStats.HourUsage.Valid {
+			return &types.Resource{Cpu: derivedStats.HourUsage.Cpu.Max, Memory: derivedStats.HourUsage.Memory.Max}
+		}
+	case day:
+		if derivedStats.DayUsage.Valid {
+			return &types.Resource{Cpu: derivedStats.DayUsage.Cpu.Max, Memory: derivedStats.DayUsage.Memory.Max}
+		}
+	}
+	return nil
+}
+
+// Returns true if a replication controller will recreate 'pod' once it is deleted.
+func isReplicated(pod api.Pod, controllers []api.ReplicationController) bool {
+	for _, controller := range controllers {
+		if controller.Namespace != pod.Namespace || len(controller.Spec.Selector) == 0 {
+			continue
+		}
+		if labels.SelectorFromSet(controller.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if all the 'pods' can be scheduled on nodes other than 'hostname'.
+// Placements are simulated with the scheduler predicates, taking earlier placements into account.
+func canReschedule(pods []api.Pod, hostname string, cluster *Cluster, podsByHost map[string][]api.Pod) bool {
+	simulator := newPlacementSimulator(cluster.Minions.Items, podsByHost)
+	for _, pod := range pods {
+		if _, ok := simulator.place(pod, hostname); !ok {
+			glog.V(2).Infof("Pod %s/%s on node %s does not fit on any other node", pod.Namespace, pod.Name, hostname)
+			return false
+		}
+	}
+	return true
+}
+
+func (self *scaleDownPolicy) PerformScaling(cluster *Cluster) error {
+	if cluster.SchedulingStateErr != nil {
+		return cluster.SchedulingStateErr
+	}
+	if len(cluster.New) > 0 {
+		glog.V(1).Infof("Cluster is scaling up, skipping scale down")
+		return nil
+	}
+	if len(cluster.Current) <= 1 {
+		return nil
+	}
+	podsByHost, err := scheduler.MapPodsToMachines(scheduler.FakePodLister(cluster.Pods))
+	if err != nil {
+		return err
+	}
+	if pending := len(podsByHost[""]); pending > 0 {
+		glog.V(1).Infof("%d pods are waiting to be scheduled, skipping scale down", pending)
+		return nil
+	}
+
+	candidates := []string{}
+	usagePercentage := make(map[string]uint)
+	for hostname, node := range cluster.Current {
+		usage := self.getPeakUsage(node.Usage)
+		if usage == nil || usage.Cpu >= node.Capacity.Cpu || usage.Memory >= node.Capacity.Memory {
+			continue
+		}
+		cpu := PercentageOf(usage.Cpu, node.Capacity.Cpu)
+		memory := PercentageOf(usage.Memory, node.Capacity.Memory)
+		if cpu >= self.threshold || memory >= self.threshold {
+			continue
+		}
+		glog.V(2).Infof("Node %s peak usage percentage cpu: %d, memory: %d is below threshold %d", hostname, cpu, memory, self.threshold)
+		candidates = append(candidates, hostname)
+		usagePercentage[hostname] = cpu + memory
+	}
+	// Try the least utilized nodes first.
+	sort.Strings(candidates)
+	sort.Stable(byUsage{candidates, usagePercentage})
+
+	// Remove at most one node per housekeeping period to let the cluster settle down.
+	for _, hostname := range candidates {
+		pods := podsByHost[hostname]
+		replicated := true
+		for _, pod := range pods {
+			if !isReplicated(pod, cluster.Controllers) {
+				glog.V(1).Infof("Not removing node %s: pod %s/%s is not replicated", hostname, pod.Namespace, pod.Name)
+				replicated = false
+				break
+			}
+		}
+		if !replicated || !canReschedule(pods, hostname, cluster, podsByHost) {
+			continue
+		}
+		glog.Infof("Node %s is underutilized and its %d pods can be rescheduled. Removing it.", hostname, len(pods))
+		cluster.Remove = append(cluster.Remove, hostname)
+		break
+	}
+	return nil
+}
+
+// Sorts hostnames by increasing usage.
+type byUsage struct {
+	hostnames []string
+	usage     map[string]uint
+}
+
+func (self byUsage) Len() int { return len(self.hostnames) }
+func (self byUsage) Less(i, j int) bool {
+	return self.usage[self.hostnames[i]] < self.usage[self.hostnames[j]]
+}
+func (self byUsage) Swap(i, j int) {
+	self.hostnames[i], self.hostnames[j] = self.hostnames[j], self.hostnames[i]
+}
+
+func newScaleDownPolicy(threshold uint, requestedScalingPolicy string) (Policy, error) {
+	if threshold == 0 || threshold >= 100 {
+		return nil, fmt.Errorf("Scale down threshold invalid: %d", threshold)
+	}
+	scalingPolicy := hour
+	switch requestedScalingPolicy {
+	case "minute":
+		scalingPolicy = minute
+	case "hour":
+		scalingPolicy = hour
+	case "day":
+		scalingPolicy = day
+	default:
+		return nil, fmt.Errorf("Scale down policy invalid: %q", requestedScalingPolicy)
+	}
+
+	glog.Infof("Scale down threshold is set at %d", threshold)
+	glog.Infof("Scale down policy is %s", requestedScalingPolicy)
+	return &scaleDownPolicy{
+		threshold:     threshold,
+		scalingPolicy: scalingPolicy,
+	}, nil
+}
@@ -0,0 +1,229 @@
+package scaler
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/resources"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/aggregator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/statscollector"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// Returns a node with 1 core and 1000 bytes of memory, whose peak usage is 'percentage' of its capacity.
+func newTestNode(hostname string, percentage uint64) Node {
+	usage := statscollector.Resource{
+		Valid:  true,
+		Cpu:    statscollector.Percentiles{Max: percentage * 10},
+		Memory: statscollector.Percentiles{Max: percentage * 10},
+	}
+	return Node{
+		Node: aggregator.Node{
+			Hostname: hostname,
+			Capacity: types.Resource{Cpu: 1000, Memory: 1000},
+			Usage:    aggregator.DerivedStats{MinuteUsage: usage, HourUsage: usage},
+		},
+	}
+}
+
+func newTestMinion(hostname string) api.Node {
+	return api.Node{
+		ObjectMeta: api.ObjectMeta{Name: hostname},
+		Spec: api.NodeSpec{
+			Capacity: api.ResourceList{
+				resources.CPU:    util.NewIntOrStringFromString("1"),
+				resources.Memory: util.NewIntOrStringFromInt(1000),
+			},
+		},
+	}
+}
+
+// Returns a pod labeled with 'app' requesting 'cpu' milli cores and 'memory' bytes.
+func newTestPod(name, host, app string, cpu, memory int) api.Pod {
+	return api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name:      name,
+			Namespace: api.NamespaceDefault,
+			Labels:    map[string]string{"app": app},
+		},
+		Spec: api.PodSpec{
+			Containers: []api.Container{{CPU: cpu, Memory: memory}},
+		},
+		Status: api.PodStatus{Host: host},
+	}
+}
+
+func newTestCluster(pods ...api.Pod) *Cluster {
+	return &Cluster{
+		Current: map[string]Node{
+			"busy": newTestNode("busy", 60),
+			"idle": newTestNode("idle", 10),
+		},
+		Minions: api.NodeList{
+			Items: []api.Node{newTestMinion("busy"), newTestMinion("idle")},
+		},
+		Pods: pods,
+		Controllers: []api.ReplicationController{
+			{
+				ObjectMeta: api.ObjectMeta{Name: "frontend", Namespace: api.NamespaceDefault},
+				Spec:       api.ReplicationControllerSpec{Selector: map[string]string{"app": "frontend"}},
+			},
+		},
+	}
+}
+
+func TestScaleDownRemovesIdleNode(t *testing.T) {
+	policy, err := newScaleDownPolicy(30, "hour")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := newTestCluster(
+		newTestPod("a", "busy", "frontend", 500, 500),
+		newTestPod("b", "idle", "frontend", 200, 200),
+	)
+	if err := policy.PerformScaling(cluster); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cluster.Remove, []string{"idle"}) {
+		t.Errorf("expected node idle to be removed, got %v", cluster.Remove)
+	}
+}
+
+func TestScaleDownKeepsNodes(t *testing.T) {
+	tests := []struct {
+		name string
+		pods []api.Pod
+		new  []string
+	}{
+		{
+			name: "pod not replicated",
+			pods: []api.Pod{newTestPod("b", "idle", "batch", 100, 100)},
+		},
+		{
+			name: "pod does not fit on the remaining nodes",
+			pods: []api.Pod{
+				newTestPod("a", "busy", "frontend", 500, 500),
+				newTestPod("b", "idle", "frontend", 600, 100),
+			},
+		},
+		{
+			name: "pods waiting to be scheduled",
+			pods: []api.Pod{newTestPod("b", "", "frontend", 100, 100)},
+		},
+		{
+			name: "cluster scaling up",
+			new:  []string{"n1-standard-1"},
+		},
+	}
+	policy, err := newScaleDownPolicy(30, "hour")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		cluster := newTestCluster(test.pods...)
+		cluster.New = test.new
+		if err := policy.PerformScaling(cluster); err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if len(cluster.Remove) != 0 {
+			t.Errorf("%s: expected no nodes to be removed, got %v", test.name, cluster.Remove)
+		}
+	}
+}
+
+type fakeActuator struct {
+	created []string
+	removed []string
+	// Hostnames of the nodes that exist in the cloud provider.
+	existing map[string]bool
+	// Returned by RemoveNode if set.
+	removeErr error
+}
+
+func (self *fakeActuator) GetNodeShapes() (actuator.NodeShapes, error) {
+	return actuator.NodeShapes{}, nil
+}
+
+func (self *fakeActuator) GetDefaultNodeShape() (string, error) {
+	return "", nil
+}
+
+func (self *fakeActuator) CreateNode(nodeShapeName string) (string, error) {
+	self.created = append(self.created, nodeShapeName)
+	return fmt.Sprintf("%s-%d", nodeShapeName, len(self.created)), nil
+}
+
+func (self *fakeActuator) RemoveNode(hostname string) error {
+	if self.removeErr != nil {
+		return self.removeErr
+	}
+	self.removed = append(self.removed, hostname)
+	return nil
+}
+
+func (self *fakeActuator) NodeExists(hostname string) (bool, error) {
+	return self.existing[hostname], nil
+}
+
+func TestRemoveNodeDrainsPods(t *testing.T) {
+	kubeClient := &client.Fake{}
+	fakeActuator := &fakeActuator{}
+	scaler := &realAutoScaler{
+		actuator:      fakeActuator,
+		kubeClient:    kubeClient,
+		existingNodes: make(map[string]Node),
+		newNodes:      make(map[string]pendingNode),
+		removedNodes:  make(map[string]bool),
+		clock:         &util.FakeClock{Time: testStart},
+	}
+	cluster := newTestCluster(
+		newTestPod("a", "busy", "frontend", 500, 500),
+		newTestPod("b", "idle", "frontend", 200, 200),
+	)
+	cluster.Remove = []string{"idle"}
+	if err := scaler.handleClusterResizing(cluster); err != nil {
+		t.Fatal(err)
+	}
+	expectedActions := []client.FakeAction{
+		{Action: "delete-pod", Value: "b"},
+		{Action: "delete-minion", Value: "idle"},
+	}
+	if !reflect.DeepEqual(kubeClient.Actions, expectedActions) {
+		t.Errorf("expected actions %v, got %v", expectedActions, kubeClient.Actions)
+	}
+	if !reflect.DeepEqual(fakeActuator.removed, []string{"idle"}) {
+		t.Errorf("expected node idle to be removed, got %v", fakeActuator.removed)
+	}
+	if !scaler.removedNodes["idle"] {
+		t.Errorf("node idle should be remembered as removed")
+	}
+}
+
+func TestRemoveNodeFailure(t *testing.T) {
+	kubeClient := &client.Fake{}
+	scaler := &realAutoScaler{
+		actuator:      &fakeActuator{removeErr: fmt.Errorf("quota")},
+		kubeClient:    kubeClient,
+		existingNodes: make(map[string]Node),
+		newNodes:      make(map[string]pendingNode),
+		removedNodes:  make(map[string]bool),
+		clock:         &util.FakeClock{Time: testStart},
+	}
+	cluster := newTestCluster(newTestPod("b", "idle", "frontend", 200, 200))
+	cluster.Remove = []string{"idle"}
+	if err := scaler.handleClusterResizing(cluster); err != nil {
+		t.Fatal(err)
+	}
+	// The node is left untouched so that it is removed again later.
+	if len(kubeClient.Actions) != 0 {
+		t.Errorf("expected no actions, got %v", kubeClient.Actions)
+	}
+	if scaler.removedNodes["idle"] {
+		t.Errorf("node idle should not be remembered as removed")
+	}
+}
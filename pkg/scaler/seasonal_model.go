@@ -0,0 +1,110 @@
+package scaler
+
+import (
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+)
+
+// The usage forecast for a slot of the day.
+type slotForecast struct {
+	usage types.Resource
+	// False until the slot has been observed on at least one day.
+	valid bool
+}
+
+// Forecasts cluster usage from its daily seasonality. The day is split in slots
+// of equal length. The peak usage observed in a slot is folded into the forecast
+// for that slot of the day once the slot is over, with an exponentially weighted
+// moving average over the days.
+type seasonalModel struct {
+	slot time.Duration
+	// Weight of the last day in the forecast, between 0 and 1.
+	alpha float64
+	// Forecast for each slot of the day, in UTC.
+	slots []slotForecast
+	// Start of the slot being observed, zero before the first observation.
+	currentStart time.Time
+	// Peak usage observed in the current slot so far.
+	currentPeak types.Resource
+}
+
+// Returns a model without observations. 'slot' must divide a day.
+func newSeasonalModel(slot time.Duration, alpha float64) *seasonalModel {
+	return &seasonalModel{
+		slot:  slot,
+		alpha: alpha,
+		slots: make([]slotForecast, int(24*time.Hour/slot)),
+	}
+}
+
+// Returns the index of the slot of the day 't' falls in.
+func (self *seasonalModel) slotIndex(t time.Time) int {
+	t = t.UTC()
+	sinceMidnight := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+	return int(sinceMidnight / self.slot)
+}
+
+func maxResource(a, b types.Resource) types.Resource {
+	if b.Cpu > a.Cpu {
+		a.Cpu = b.Cpu
+	}
+	if b.Memory > a.Memory {
+		a.Memory = b.Memory
+	}
+	return a
+}
+
+// Fold the peak of the current slot into the forecast of its slot of the day.
+func (self *seasonalModel) fold() {
+	forecast := &self.slots[self.slotIndex(self.currentStart)]
+	if !forecast.valid {
+		forecast.usage = self.currentPeak
+		forecast.valid = true
+		return
+	}
+	blend := func(last, previous uint64) uint64 {
+		return uint64(self.alpha*float64(last) + (1-self.alpha)*float64(previous))
+	}
+	forecast.usage = types.Resource{
+		Cpu:    blend(self.currentPeak.Cpu, forecast.usage.Cpu),
+		Memory: blend(self.currentPeak.Memory, forecast.usage.Memory),
+	}
+}
+
+// Record the cluster 'usage' at time 't'. Observations older than the current slot are ignored.
+func (self *seasonalModel) observe(t time.Time, usage types.Resource) {
+	start := t.Truncate(self.slot)
+	if !self.currentStart.IsZero() && start.Before(self.currentStart) {
+		return
+	}
+	if start.Equal(self.currentStart) {
+		self.currentPeak = maxResource(self.currentPeak, usage)
+		return
+	}
+	if !self.currentStart.IsZero() {
+		self.fold()
+	}
+	self.currentStart = start
+	self.currentPeak = usage
+}
+
+// Returns the peak usage forecast between 'from' and 'from' + 'horizon', or
+// false if none of the slots in that period have been observed yet. The peak
+// observed so far in the current slot counts towards the forecast.
+func (self *seasonalModel) forecast(from time.Time, horizon time.Duration) (types.Resource, bool) {
+	var peak types.Resource
+	found := false
+	for t := from.Truncate(self.slot); !t.After(from.Add(horizon)); t = t.Add(self.slot) {
+		forecast := self.slots[self.slotIndex(t)]
+		if forecast.valid {
+			peak = maxResource(peak, forecast.usage)
+			found = true
+		}
+		if t.Equal(self.currentStart) {
+			peak = maxResource(peak, self.currentPeak)
+			found = true
+		}
+	}
+	return peak, found
+}
@@ -0,0 +1,98 @@
+// HTTP status of the scaler.
+//
+// /status          Status page.
+// /api/status      Status as JSON. Accepts decisions=<n> to only return the last n decisions.
+
+package scaler
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/golang/glog"
+)
+
+var statusTemplate = template.Must(template.New("status").Parse(`<html>
+<head><title>Cluster scaler</title></head>
+<body>
+<h1>Cluster scaler</h1>
+<p>Last housekeeping: {{.LastHousekeeping}}</p>
+{{if .LastError}}<p>Last error: {{.LastError}}</p>{{end}}
+<h2>Nodes</h2>
+<table border="1">
+<tr><th>Hostname</th><th>Shape</th><th>Cpu capacity</th><th>Memory capacity</th></tr>
+{{range $hostname, $node := .Cluster.Current}}<tr><td>{{$hostname}}</td><td>{{$node.Shape}}</td><td>{{$node.Capacity.Cpu}}</td><td>{{$node.Capacity.Memory}}</td></tr>
+{{end}}</table>
+<p>Slack: cpu {{.Cluster.Slack.Cpu}}, memory {{.Cluster.Slack.Memory}}</p>
+<p>Requested by the policies: add {{.Cluster.New}}, remove {{.Cluster.Remove}}</p>
+<h2>Pending node creations</h2>
+<table border="1">
+<tr><th>Hostname</th><th>Shape</th><th>Deadline</th></tr>
+{{range .Pending}}<tr><td>{{.Hostname}}</td><td>{{.Shape}}</td><td>{{.Deadline}}</td></tr>
+{{end}}</table>
+<h2>Decisions</h2>
+<table border="1">
+<tr><th>Time</th><th>Action</th><th>Node</th><th>Shape</th><th>Reason</th></tr>
+{{range .Decisions}}<tr><td>{{.Timestamp}}</td><td>{{.Action}}</td><td>{{.Node}}</td><td>{{.Shape}}</td><td>{{.Reason}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type StatusServer struct {
+	scaler Scaler
+	mux    *http.ServeMux
+}
+
+// Create a server for the status of 'scaler'.
+func NewStatusServer(scaler Scaler) *StatusServer {
+	server := &StatusServer{
+		scaler: scaler,
+		mux:    http.NewServeMux(),
+	}
+	server.mux.HandleFunc("/status", server.handleStatusPage)
+	server.mux.HandleFunc("/api/status", server.handleStatus)
+	return server
+}
+
+func (self *StatusServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	self.mux.ServeHTTP(w, req)
+}
+
+func (self *StatusServer) handleStatusPage(w http.ResponseWriter, req *http.Request) {
+	status := self.scaler.GetStatus()
+	// Most recent decisions first.
+	decisions := make([]Decision, 0, len(status.Decisions))
+	for i := len(status.Decisions) - 1; i >= 0; i-- {
+		decisions = append(decisions, status.Decisions[i])
+	}
+	status.Decisions = decisions
+	w.Header().Set("Content-Type", "text/html")
+	if err := statusTemplate.Execute(w, status); err != nil {
+		glog.Errorf("Failed to render status page - %q", err)
+	}
+}
+
+func (self *StatusServer) handleStatus(w http.ResponseWriter, req *http.Request) {
+	status := self.scaler.GetStatus()
+	if value := req.URL.Query().Get("decisions"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("invalid number of decisions %q", value), http.StatusBadRequest)
+			return
+		}
+		if n < len(status.Decisions) {
+			status.Decisions = status.Decisions[len(status.Decisions)-n:]
+		}
+	}
+	out, err := json.Marshal(status)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal status: %s", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
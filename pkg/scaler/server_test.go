@@ -0,0 +1,81 @@
+package scaler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeScaler struct {
+	status Status
+}
+
+func (self *fakeScaler) AutoScale() error {
+	return nil
+}
+
+func (self *fakeScaler) GetStatus() Status {
+	return self.status
+}
+
+func get(t *testing.T, url string, expectedStatus int) string {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != expectedStatus {
+		t.Fatalf("%s: expected status %d, got %d: %s", url, expectedStatus, resp.StatusCode, body)
+	}
+	return string(body)
+}
+
+func TestStatusServer(t *testing.T) {
+	scaler := &fakeScaler{
+		status: Status{
+			LastHousekeeping: testStart,
+			Cluster: ClusterStatus{
+				Current: map[string]NodeStatus{"busy": {Shape: "small"}},
+				New:     []string{"large"},
+			},
+			Pending: []PendingNodeStatus{{Hostname: "large-1", Shape: "large", Deadline: testStart}},
+			Decisions: []Decision{
+				{Timestamp: testStart, Action: actionScaleUp, Node: "small-1", Shape: "small", Reason: "requested by policy ClusterUsage"},
+				{Timestamp: testStart, Action: actionScaleUp, Node: "large-1", Shape: "large", Reason: "requested by policy UnschedulablePods"},
+			},
+		},
+	}
+	server := httptest.NewServer(NewStatusServer(scaler))
+	defer server.Close()
+
+	var status Status
+	if err := json.Unmarshal([]byte(get(t, server.URL+"/api/status", http.StatusOK)), &status); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(status, scaler.status) {
+		t.Errorf("expected status %+v, got %+v", scaler.status, status)
+	}
+
+	if err := json.Unmarshal([]byte(get(t, server.URL+"/api/status?decisions=1", http.StatusOK)), &status); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(status.Decisions, scaler.status.Decisions[1:]) {
+		t.Errorf("expected the last decision, got %+v", status.Decisions)
+	}
+	get(t, server.URL+"/api/status?decisions=all", http.StatusBadRequest)
+
+	page := get(t, server.URL+"/status", http.StatusOK)
+	for _, expected := range []string{"busy", "large-1", "requested by policy UnschedulablePods"} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("expected %q in the status page:\n%s", expected, page)
+		}
+	}
+}
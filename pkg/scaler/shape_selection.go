@@ -0,0 +1,150 @@
+package scaler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/golang/glog"
+)
+
+// Upper bound on the number of nodes requested in a single decision.
+const maxNewNodes = 100
+
+// Reads the cost of each node shape from a JSON file mapping shape names to costs,
+// for example {"n1-standard-1": 0.07, "n1-standard-4": 0.28}. Shapes that are not
+// listed cost as much as their number of cores.
+func loadShapeCosts(path string, shapes actuator.NodeShapes) (map[string]float64, error) {
+	costs := map[string]float64{}
+	if path == "" {
+		return costs, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node shape costs - %q", err)
+	}
+	if err := json.Unmarshal(data, &costs); err != nil {
+		return nil, fmt.Errorf("failed to parse node shape costs in %s - %q", path, err)
+	}
+	for name, cost := range costs {
+		if _, err := shapes.GetNodeShapeWithType(name); err != nil {
+			return nil, fmt.Errorf("invalid node shape costs in %s - %q", path, err)
+		}
+		if cost <= 0 {
+			return nil, fmt.Errorf("invalid cost %v for node shape %s in %s", cost, name, path)
+		}
+	}
+	glog.Infof("Node shape costs are: %v", costs)
+	return costs, nil
+}
+
+// Returns the cost of adding a node of type 'shape'.
+func (self *Cluster) shapeCost(shape actuator.NodeShape) float64 {
+	if cost, ok := self.ShapeCosts[shape.Name]; ok {
+		return cost
+	}
+	return float64(shape.Capacity.Cpu) / 1000
+}
+
+// Returns the share of 'demand' that 'capacity' covers, counting each resource equally.
+func coveredShare(capacity, demand types.Resource) float64 {
+	share := 0.0
+	if demand.Cpu > 0 {
+		share += float64(minUint64(capacity.Cpu, demand.Cpu)) / float64(demand.Cpu)
+	}
+	if demand.Memory > 0 {
+		share += float64(minUint64(capacity.Memory, demand.Memory)) / float64(demand.Memory)
+	}
+	return share
+}
+
+func minUint64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func subtractResource(a, b types.Resource) types.Resource {
+	return types.Resource{
+		Cpu:    a.Cpu - minUint64(a.Cpu, b.Cpu),
+		Memory: a.Memory - minUint64(a.Memory, b.Memory),
+	}
+}
+
+// Returns the cheapest shape whose capacity covers all of 'demand', if any.
+func cheapestCover(cluster *Cluster, shapes []actuator.NodeShape, demand types.Resource) (actuator.NodeShape, bool) {
+	var best actuator.NodeShape
+	found := false
+	for _, shape := range shapes {
+		if shape.Capacity.Cpu < demand.Cpu || shape.Capacity.Memory < demand.Memory {
+			continue
+		}
+		if !found || cluster.shapeCost(shape) < cluster.shapeCost(best) {
+			best = shape
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Returns the total cost of 'shapes'.
+func (self *Cluster) totalCost(shapes []actuator.NodeShape) float64 {
+	total := 0.0
+	for _, shape := range shapes {
+		total += self.shapeCost(shape)
+	}
+	return total
+}
+
+// Bin-packs the cpu and memory 'demand' onto new nodes, minimizing their total cost.
+// Nodes are picked greedily by lowest cost per share of the remaining demand they
+// cover. The tail of the greedy pick is then replaced by the single cheapest shape
+// covering the same demand wherever that is cheaper.
+// Returns the shape names of the nodes to add, possibly of different shapes.
+func selectShapes(cluster *Cluster, demand types.Resource) []string {
+	shapes := cluster.Shapes.List()
+	greedy := []actuator.NodeShape{}
+	// remaining[i] is the demand left after the first i nodes of the greedy pick.
+	remaining := []types.Resource{demand}
+	for left := demand; (left.Cpu > 0 || left.Memory > 0) && len(greedy) < maxNewNodes; {
+		var best actuator.NodeShape
+		bestScore := 0.0
+		for _, shape := range shapes {
+			share := coveredShare(shape.Capacity, left)
+			if share == 0 {
+				continue
+			}
+			score := cluster.shapeCost(shape) / share
+			if best.Name == "" || score < bestScore {
+				best = shape
+				bestScore = score
+			}
+		}
+		if best.Name == "" {
+			break
+		}
+		greedy = append(greedy, best)
+		left = subtractResource(left, best.Capacity)
+		remaining = append(remaining, left)
+	}
+
+	selected := greedy
+	for i := range greedy {
+		cover, ok := cheapestCover(cluster, shapes, remaining[i])
+		if !ok {
+			continue
+		}
+		candidate := append(append([]actuator.NodeShape{}, greedy[:i]...), cover)
+		if cluster.totalCost(candidate) < cluster.totalCost(selected) {
+			selected = candidate
+		}
+	}
+	names := make([]string, 0, len(selected))
+	for _, shape := range selected {
+		names = append(names, shape.Name)
+	}
+	return names
+}
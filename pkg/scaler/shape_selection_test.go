@@ -0,0 +1,89 @@
+package scaler
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+)
+
+func TestSelectShapes(t *testing.T) {
+	tests := []struct {
+		name     string
+		costs    map[string]float64
+		demand   types.Resource
+		expected []string
+	}{
+		{
+			name:     "default costs",
+			demand:   types.Resource{Cpu: 1500, Memory: 500},
+			expected: []string{"small", "small"},
+		},
+		{
+			name:     "large nodes are cheaper",
+			costs:    map[string]float64{"large": 2},
+			demand:   types.Resource{Cpu: 5000, Memory: 5000},
+			expected: []string{"large", "small"},
+		},
+		{
+			name:     "single node covering the demand",
+			costs:    map[string]float64{"large": 2},
+			demand:   types.Resource{Cpu: 3000, Memory: 500},
+			expected: []string{"large"},
+		},
+		{
+			name:     "memory demand",
+			demand:   types.Resource{Memory: 800},
+			expected: []string{"small"},
+		},
+	}
+	for _, test := range tests {
+		cluster := newTestClusterWithShapes()
+		cluster.ShapeCosts = test.costs
+		shapes := selectShapes(cluster, test.demand)
+		if !reflect.DeepEqual(shapes, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, shapes)
+		}
+	}
+}
+
+func TestLoadShapeCosts(t *testing.T) {
+	shapes := actuator.NewNodeShapes([]actuator.NodeShape{smallShape, largeShape})
+	costs, err := loadShapeCosts("", shapes)
+	if err != nil || len(costs) != 0 {
+		t.Errorf("expected no costs without a file, got %v, %v", costs, err)
+	}
+
+	tests := []struct {
+		contents string
+		valid    bool
+	}{
+		{`{"small": 0.5, "large": 1.5}`, true},
+		{`{"medium": 1}`, false},
+		{`{"small": 0}`, false},
+		{`{"small": `, false},
+	}
+	for _, test := range tests {
+		file, err := ioutil.TempFile("", "shape_costs")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		if _, err := file.WriteString(test.contents); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+		costs, err := loadShapeCosts(file.Name(), shapes)
+		if test.valid {
+			expected := map[string]float64{"small": 0.5, "large": 1.5}
+			if err != nil || !reflect.DeepEqual(costs, expected) {
+				t.Errorf("%s: expected %v, got %v, %v", test.contents, expected, costs, err)
+			}
+		} else if err == nil {
+			t.Errorf("%s: expected an error", test.contents)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package scaler
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/aggregator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/statscollector"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// A node created by the scaler during a simulation.
+type simulatedNode struct {
+	shape actuator.NodeShape
+	// The node joins the cluster at this time.
+	readyAt time.Time
+}
+
+// Replays recorded cluster snapshots against a scaler, on a virtual clock.
+// Nodes created by the scaler join the cluster after a delay, and the recorded
+// load is spread over the nodes of the simulated cluster in proportion to their
+// capacity.
+type Simulation struct {
+	snapshots    []aggregator.Snapshot
+	shapes       actuator.NodeShapes
+	defaultShape string
+	// Nodes created by the scaler join the cluster after this delay.
+	nodeCreationDelay time.Duration
+	clock             *util.FakeClock
+	kubeClient        *client.Fake
+	// Map of hostname to the nodes created by the scaler.
+	created map[string]simulatedNode
+	// Number of nodes created by the scaler so far.
+	numCreated int
+	// Hostnames of the nodes removed by the scaler.
+	removed map[string]bool
+	// Node creations and removals requested in the current step.
+	stepCreated []string
+	stepRemoved []string
+}
+
+// Returns the snapshot in effect at the current time.
+func (self *Simulation) currentSnapshot() aggregator.Snapshot {
+	now := self.clock.Now()
+	i := sort.Search(len(self.snapshots), func(i int) bool {
+		return self.snapshots[i].Timestamp.After(now)
+	})
+	if i == 0 {
+		return aggregator.Snapshot{Timestamp: now}
+	}
+	return self.snapshots[i-1]
+}
+
+// Returns 'resource' with its cpu scaled by 'cpu' and its memory scaled by 'memory'.
+func scaleResource(resource statscollector.Resource, cpu, memory float64) statscollector.Resource {
+	scale := func(percentiles statscollector.Percentiles, factor float64) statscollector.Percentiles {
+		scaled := statscollector.Percentiles{
+			Mean:   uint64(float64(percentiles.Mean) * factor),
+			Max:    uint64(float64(percentiles.Max) * factor),
+			Ninety: uint64(float64(percentiles.Ninety) * factor),
+		}
+		if percentiles.Values != nil {
+			scaled.Values = make(map[string]uint64, len(percentiles.Values))
+			for percentile, value := range percentiles.Values {
+				scaled.Values[percentile] = uint64(float64(value) * factor)
+			}
+		}
+		return scaled
+	}
+	return statscollector.Resource{
+		Valid:  resource.Valid,
+		Cpu:    scale(resource.Cpu, cpu),
+		Memory: scale(resource.Memory, memory),
+	}
+}
+
+// Returns the sum of 'a' and 'b'. The sum is valid if either is.
+func addResource(a, b statscollector.Resource) statscollector.Resource {
+	if !b.Valid {
+		return a
+	}
+	if !a.Valid {
+		return b
+	}
+	add := func(x, y statscollector.Percentiles) statscollector.Percentiles {
+		return statscollector.Percentiles{Mean: x.Mean + y.Mean, Max: x.Max + y.Max, Ninety: x.Ninety + y.Ninety}
+	}
+	return statscollector.Resource{Valid: true, Cpu: add(a.Cpu, b.Cpu), Memory: add(a.Memory, b.Memory)}
+}
+
+func scaleStats(stats aggregator.DerivedStats, cpu, memory float64) aggregator.DerivedStats {
+	return aggregator.DerivedStats{
+		LastUpdate:  stats.LastUpdate,
+		MinuteUsage: scaleResource(stats.MinuteUsage, cpu, memory),
+		HourUsage:   scaleResource(stats.HourUsage, cpu, memory),
+		DayUsage:    scaleResource(stats.DayUsage, cpu, memory),
+	}
+}
+
+func ratio(a, b uint64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// Returns the nodes of the simulated cluster at the current time. Recorded nodes
+// keep their own usage, scaled to the capacity of the simulated cluster. The
+// nodes created by the scaler get the average usage of the cluster. Summing
+// percentiles over nodes is an approximation that is good enough to compare policies.
+func (self *Simulation) getNodes() map[string]aggregator.Node {
+	now := self.clock.Now()
+	snapshot := self.currentSnapshot()
+	var recordedCapacity, capacity types.Resource
+	var total aggregator.DerivedStats
+	for hostname, node := range snapshot.Nodes {
+		recordedCapacity.Cpu += node.Capacity.Cpu
+		recordedCapacity.Memory += node.Capacity.Memory
+		total.MinuteUsage = addResource(total.MinuteUsage, node.Usage.MinuteUsage)
+		total.HourUsage = addResource(total.HourUsage, node.Usage.HourUsage)
+		total.DayUsage = addResource(total.DayUsage, node.Usage.DayUsage)
+		if !self.removed[hostname] {
+			capacity.Cpu += node.Capacity.Cpu
+			capacity.Memory += node.Capacity.Memory
+		}
+	}
+	for _, node := range self.created {
+		if !node.readyAt.After(now) {
+			capacity.Cpu += node.shape.Capacity.Cpu
+			capacity.Memory += node.shape.Capacity.Memory
+		}
+	}
+
+	cpuFactor := ratio(recordedCapacity.Cpu, capacity.Cpu)
+	memoryFactor := ratio(recordedCapacity.Memory, capacity.Memory)
+	nodes := make(map[string]aggregator.Node)
+	for hostname, node := range snapshot.Nodes {
+		if self.removed[hostname] {
+			continue
+		}
+		node.Usage = scaleStats(node.Usage, cpuFactor, memoryFactor)
+		nodes[hostname] = node
+	}
+	for hostname, node := range self.created {
+		if node.readyAt.After(now) {
+			continue
+		}
+		usage := scaleStats(total, ratio(node.shape.Capacity.Cpu, capacity.Cpu), ratio(node.shape.Capacity.Memory, capacity.Memory))
+		usage.LastUpdate = now
+		nodes[hostname] = aggregator.Node{
+			Hostname: hostname,
+			Capacity: node.shape.Capacity,
+			Usage:    usage,
+		}
+	}
+	return nodes
+}
+
+// Reports the nodes of the simulated cluster to the apiserver client of the scaler.
+func (self *Simulation) updateMinions(nodes map[string]aggregator.Node) {
+	self.kubeClient.MinionsList.Items = make([]api.Node, 0, len(nodes))
+	for hostname, node := range nodes {
+		minion := newHypotheticalMinion(hostname, actuator.NodeShape{Capacity: node.Capacity})
+		self.kubeClient.MinionsList.Items = append(self.kubeClient.MinionsList.Items, minion)
+	}
+}
+
+type simulatedAggregator struct {
+	*Simulation
+}
+
+func (self simulatedAggregator) GetClusterInfo() (map[string]aggregator.Node, error) {
+	return self.getNodes(), nil
+}
+
+type simulatedActuator struct {
+	*Simulation
+}
+
+func (self simulatedActuator) GetNodeShapes() (actuator.NodeShapes, error) {
+	return self.shapes, nil
+}
+
+func (self simulatedActuator) GetDefaultNodeShape() (string, error) {
+	return self.defaultShape, nil
+}
+
+func (self simulatedActuator) CreateNode(nodeShapeName string) (string, error) {
+	shape, err := self.shapes.GetNodeShapeWithType(nodeShapeName)
+	if err != nil {
+		return "", err
+	}
+	self.numCreated++
+	hostname := fmt.Sprintf("simulated-%s-%d", nodeShapeName, self.numCreated)
+	self.created[hostname] = simulatedNode{
+		shape:   shape,
+		readyAt: self.clock.Now().Add(self.nodeCreationDelay),
+	}
+	self.stepCreated = append(self.stepCreated, nodeShapeName)
+	return hostname, nil
+}
+
+func (self simulatedActuator) RemoveNode(hostname string) error {
+	self.removed[hostname] = true
+	delete(self.created, hostname)
+	self.stepRemoved = append(self.stepRemoved, hostname)
+	return nil
+}
+
+func (self simulatedActuator) NodeExists(hostname string) (bool, error) {
+	_, ok := self.created[hostname]
+	return ok, nil
+}
+
+// Returns the Actuator that creates and removes the nodes of the simulated cluster.
+func (self *Simulation) Actuator() actuator.Actuator {
+	return simulatedActuator{self}
+}
+
+// Returns the Aggregator that reports the nodes of the simulated cluster.
+func (self *Simulation) Aggregator() aggregator.Aggregator {
+	return simulatedAggregator{self}
+}
+
+// Returns the apiserver client of the simulated cluster. The cluster runs no pods.
+func (self *Simulation) KubeClient() client.Interface {
+	return self.kubeClient
+}
+
+// Returns the virtual clock of the simulation.
+func (self *Simulation) Clock() util.Clock {
+	return self.clock
+}
+
+// Returns the utilization percentage of cpu and memory of 'nodes', based on their mean usage over the last minute.
+func getUtilization(nodes map[string]aggregator.Node) (cpu, memory float64) {
+	var usage, capacity types.Resource
+	for _, node := range nodes {
+		if !node.Usage.MinuteUsage.Valid {
+			continue
+		}
+		usage.Cpu += node.Usage.MinuteUsage.Cpu.Mean
+		usage.Memory += node.Usage.MinuteUsage.Memory.Mean
+		capacity.Cpu += node.Capacity.Cpu
+		capacity.Memory += node.Capacity.Memory
+	}
+	return 100 * ratio(usage.Cpu, capacity.Cpu), 100 * ratio(usage.Memory, capacity.Memory)
+}
+
+// Runs 'scaler' every 'housekeeping' of virtual time from the first to the last
+// snapshot, and writes a line per step to 'out' with the scaling decisions and
+// the resulting size and utilization of the cluster.
+func (self *Simulation) Run(scaler Scaler, housekeeping time.Duration, out io.Writer) error {
+	realScaler, ok := scaler.(*realAutoScaler)
+	if !ok {
+		return fmt.Errorf("cannot simulate scaler of type %T", scaler)
+	}
+	if len(self.snapshots) == 0 {
+		return fmt.Errorf("no snapshots to simulate")
+	}
+	if housekeeping <= 0 {
+		return fmt.Errorf("housekeeping duration invalid: %v", housekeeping)
+	}
+	start := self.snapshots[0].Timestamp
+	end := self.snapshots[len(self.snapshots)-1].Timestamp
+	for now := start; !now.After(end); now = now.Add(housekeeping) {
+		self.clock.Time = now
+		self.stepCreated = []string{}
+		self.stepRemoved = []string{}
+		self.updateMinions(self.getNodes())
+		result := "ok"
+		if err := realScaler.doHousekeeping(); err != nil {
+			result = err.Error()
+		}
+		nodes := self.getNodes()
+		cpu, memory := getUtilization(nodes)
+		_, err := fmt.Fprintf(out, "%s\tnodes=%d\tpending=%d\tcpu=%.1f%%\tmemory=%.1f%%\tcreated=%v\tremoved=%v\t%s\n",
+			now.Format(time.RFC3339), len(nodes), len(realScaler.newNodes), cpu, memory, self.stepCreated, self.stepRemoved, result)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns a simulation that replays 'snapshots' on a cluster that can grow with
+// nodes of types 'shapes'. Created nodes join the cluster after 'nodeCreationDelay'.
+func NewSimulation(snapshots []aggregator.Snapshot, shapes actuator.NodeShapes, defaultShape string, nodeCreationDelay time.Duration) (*Simulation, error) {
+	if _, err := shapes.GetNodeShapeWithType(defaultShape); err != nil {
+		return nil, err
+	}
+	if nodeCreationDelay < 0 {
+		return nil, fmt.Errorf("node creation delay invalid: %v", nodeCreationDelay)
+	}
+	start := time.Now()
+	if len(snapshots) > 0 {
+		start = snapshots[0].Timestamp
+	}
+	return &Simulation{
+		snapshots:         snapshots,
+		shapes:            shapes,
+		defaultShape:      defaultShape,
+		nodeCreationDelay: nodeCreationDelay,
+		clock:             &util.FakeClock{Time: start},
+		kubeClient:        &client.Fake{},
+		created:           make(map[string]simulatedNode),
+		removed:           make(map[string]bool),
+	}, nil
+}
@@ -0,0 +1,69 @@
+package scaler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/aggregator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/statscollector"
+)
+
+// Returns a recorded node with 1 core and 1000 bytes of memory, using 'percentage' of both.
+func newRecordedNode(hostname string, percentage uint64) aggregator.Node {
+	usage := statscollector.Resource{
+		Valid:  true,
+		Cpu:    statscollector.Percentiles{Mean: percentage * 10, Max: percentage * 10, Ninety: percentage * 10},
+		Memory: statscollector.Percentiles{Mean: percentage * 10, Max: percentage * 10, Ninety: percentage * 10},
+	}
+	return aggregator.Node{
+		Hostname: hostname,
+		Capacity: types.Resource{Cpu: 1000, Memory: 1000},
+		Usage:    aggregator.DerivedStats{MinuteUsage: usage, HourUsage: usage, DayUsage: usage},
+	}
+}
+
+func TestSimulation(t *testing.T) {
+	nodes := map[string]aggregator.Node{
+		"a": newRecordedNode("a", 90),
+		"b": newRecordedNode("b", 90),
+	}
+	snapshots := []aggregator.Snapshot{
+		{Timestamp: testStart, Nodes: nodes},
+		{Timestamp: testStart.Add(5 * time.Minute), Nodes: nodes},
+	}
+	shapes := actuator.NewNodeShapes([]actuator.NodeShape{smallShape, largeShape})
+	simulation, err := NewSimulation(snapshots, shapes, "small", 2*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policyConfig := PolicyConfig{Policies: []PolicySpec{{Type: "ClusterUsage", Threshold: 80, Window: "minute"}}}
+	scaler, err := New(time.Minute, simulation.Actuator(), simulation.Aggregator(), policyConfig, Limits{}, simulation.KubeClient(), "", 10*time.Minute, false, simulation.Clock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := simulation.Run(scaler, time.Minute, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expected a line per minute, got %q", out.String())
+	}
+	// The cluster grows by a small node, which takes on a third of the load once it joins.
+	expected := []string{
+		"2014-12-01T00:00:00Z\tnodes=2\tpending=1\tcpu=90.0%\tmemory=90.0%\tcreated=[small]\tremoved=[]\tok",
+		"2014-12-01T00:01:00Z\tnodes=2\tpending=1\tcpu=90.0%\tmemory=90.0%\tcreated=[]\tremoved=[]\tok",
+		"2014-12-01T00:02:00Z\tnodes=3\tpending=0\tcpu=60.0%\tmemory=60.0%\tcreated=[]\tremoved=[]\tok",
+		"2014-12-01T00:05:00Z\tnodes=3\tpending=0\tcpu=60.0%\tmemory=60.0%\tcreated=[]\tremoved=[]\tok",
+	}
+	for i, line := range []int{0, 1, 2, 5} {
+		if lines[line] != expected[i] {
+			t.Errorf("expected line %d to be %q, got %q", line, expected[i], lines[line])
+		}
+	}
+}
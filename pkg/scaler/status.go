@@ -0,0 +1,225 @@
+package scaler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/record"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/aggregator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/golang/glog"
+)
+
+// Number of decisions kept for the status endpoint.
+const maxDecisions = 100
+
+// Actions of the scaling decisions.
+const (
+	actionScaleUp         = "scaleUp"
+	actionScaleDown       = "scaleDown"
+	actionSkipScaleUp     = "skipScaleUp"
+	actionSkipScaleDown   = "skipScaleDown"
+	actionFailedScaleUp   = "failedScaleUp"
+	actionFailedScaleDown = "failedScaleDown"
+	actionCreationTimeout = "creationTimeout"
+	actionFailedPolicy    = "failedPolicy"
+)
+
+// Actions that are also recorded as events, on the node they are about or on the
+// scaler if they are about no node. The other decisions, e.g. the skipped ones that
+// repeat on every housekeeping while a limit holds, are only kept for the status
+// endpoint.
+var eventActions = map[string]bool{
+	actionScaleUp:         true,
+	actionScaleDown:       true,
+	actionFailedScaleUp:   true,
+	actionFailedScaleDown: true,
+	actionCreationTimeout: true,
+	actionFailedPolicy:    true,
+}
+
+// A scaling decision, along with the reason for it.
+type Decision struct {
+	Timestamp time.Time `json:"timestamp"`
+	// What was decided, e.g. "scaleUp" or "skipScaleDown".
+	Action string `json:"action"`
+	// Hostname of the node the decision is about, if any.
+	Node string `json:"node,omitempty"`
+	// Shape of the node the decision is about, if any.
+	Shape  string `json:"shape,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// A node of the cluster as seen by the scaler.
+type NodeStatus struct {
+	Shape    string                  `json:"shape"`
+	Capacity types.Resource          `json:"capacity"`
+	Usage    aggregator.DerivedStats `json:"usage"`
+}
+
+// The cluster as seen by the scaler in its last housekeeping.
+type ClusterStatus struct {
+	DefaultShape string `json:"default_shape"`
+	// Map of hostname to node.
+	Current map[string]NodeStatus `json:"current"`
+	// Shapes of the nodes the policies asked for.
+	New []string `json:"new"`
+	// Hostnames of the nodes the policies asked to remove.
+	Remove []string       `json:"remove"`
+	Slack  types.Resource `json:"slack"`
+}
+
+// A node creation that has not joined the cluster yet.
+type PendingNodeStatus struct {
+	Hostname string    `json:"hostname"`
+	Shape    string    `json:"shape"`
+	Deadline time.Time `json:"deadline"`
+}
+
+// The state of the scaler.
+type Status struct {
+	// Time of the last housekeeping.
+	LastHousekeeping time.Time `json:"last_housekeeping"`
+	// Error of the last housekeeping, if any.
+	LastError string              `json:"last_error,omitempty"`
+	Cluster   ClusterStatus       `json:"cluster"`
+	Pending   []PendingNodeStatus `json:"pending"`
+	// The last decisions, oldest first.
+	Decisions []Decision `json:"decisions"`
+}
+
+// Returns a reference to the minion 'hostname' for events.
+func minionRef(hostname string) *api.ObjectReference {
+	return &api.ObjectReference{
+		Kind:      "Minion",
+		Name:      hostname,
+		Namespace: api.NamespaceDefault,
+	}
+}
+
+// Returns a reference to the scaler for the events about no node in particular.
+func scalerRef() *api.ObjectReference {
+	return &api.ObjectReference{
+		Kind:      "Scaler",
+		Name:      "scaler",
+		Namespace: api.NamespaceDefault,
+	}
+}
+
+// Record a scaling decision for the status endpoint, and as an event if it changed
+// the cluster or failed to.
+func (self *realAutoScaler) recordDecision(action, hostname, shapeName, reasonFmt string, args ...interface{}) {
+	decision := Decision{
+		Timestamp: self.clock.Now(),
+		Action:    action,
+		Node:      hostname,
+		Shape:     shapeName,
+		Reason:    fmt.Sprintf(reasonFmt, args...),
+	}
+	glog.V(1).Infof("Scaling decision: %+v", decision)
+	self.statusLock.Lock()
+	self.decisions = append(self.decisions, decision)
+	if len(self.decisions) > maxDecisions {
+		self.decisions = self.decisions[len(self.decisions)-maxDecisions:]
+	}
+	self.statusLock.Unlock()
+
+	if !eventActions[action] {
+		return
+	}
+	message := decision.Reason
+	if shapeName != "" {
+		message = fmt.Sprintf("Node of type %s: %s", shapeName, decision.Reason)
+	}
+	ref := scalerRef()
+	if hostname != "" {
+		ref = minionRef(hostname)
+	}
+	record.Event(ref, "", action, message)
+}
+
+// Returns the reason for the request of the nodes 'key' of 'requestedBy'.
+func getRequestReason(requestedBy map[string][]string, key string) string {
+	policies := requestedBy[key]
+	if len(policies) == 0 {
+		return "requested"
+	}
+	return fmt.Sprintf("requested by policy %s", strings.Join(policies, ", "))
+}
+
+// Returns 'policies' with 'policy' appended, unless it is already there.
+func appendPolicy(policies []string, policy string) []string {
+	for _, existing := range policies {
+		if existing == policy {
+			return policies
+		}
+	}
+	return append(policies, policy)
+}
+
+// Returns the entries of 'after' that are not in 'before', counting duplicates.
+func addedEntries(before, after []string) []string {
+	counts := make(map[string]int)
+	for _, entry := range before {
+		counts[entry]++
+	}
+	added := []string{}
+	for _, entry := range after {
+		if counts[entry] > 0 {
+			counts[entry]--
+			continue
+		}
+		added = append(added, entry)
+	}
+	return added
+}
+
+// Update the status with the outcome of a housekeeping of 'cluster'.
+func (self *realAutoScaler) updateStatus(cluster *Cluster, err error) {
+	self.statusLock.Lock()
+	defer self.statusLock.Unlock()
+	self.status.LastHousekeeping = self.clock.Now()
+	self.status.LastError = ""
+	if err != nil {
+		self.status.LastError = err.Error()
+	}
+	if cluster != nil {
+		current := make(map[string]NodeStatus, len(cluster.Current))
+		for hostname, node := range cluster.Current {
+			current[hostname] = NodeStatus{Shape: node.shapeName, Capacity: node.Capacity, Usage: node.Usage}
+		}
+		self.status.Cluster = ClusterStatus{
+			DefaultShape: cluster.DefaultShape.Name,
+			Current:      current,
+			New:          append([]string{}, cluster.New...),
+			Remove:       append([]string{}, cluster.Remove...),
+			Slack:        cluster.Slack,
+		}
+	}
+	self.status.Pending = make([]PendingNodeStatus, 0, len(self.newNodes))
+	for hostname, pending := range self.newNodes {
+		self.status.Pending = append(self.status.Pending, PendingNodeStatus{
+			Hostname: hostname,
+			Shape:    pending.shapeName,
+			Deadline: pending.deadline,
+		})
+	}
+	sort.Sort(byDeadline(self.status.Pending))
+}
+
+func (self *realAutoScaler) GetStatus() Status {
+	self.statusLock.Lock()
+	defer self.statusLock.Unlock()
+	status := self.status
+	status.Decisions = append([]Decision{}, self.decisions...)
+	return status
+}
+
+type byDeadline []PendingNodeStatus
+
+func (self byDeadline) Len() int           { return len(self) }
+func (self byDeadline) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+func (self byDeadline) Less(i, j int) bool { return self[i].Deadline.Before(self[j].Deadline) }
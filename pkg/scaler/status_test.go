@@ -0,0 +1,53 @@
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/record"
+)
+
+func TestDecisionEvents(t *testing.T) {
+	events := make(chan *api.Event, 10)
+	watcher := record.GetEvents(func(event *api.Event) { events <- event })
+	defer watcher.Stop()
+
+	fakeActuator := &fakeActuator{}
+	cluster := newTestClusterWithShapes()
+	cluster.New = []string{"small"}
+	scaler := newTestScaler(fakeActuator, cluster)
+	scaler.limits = Limits{ScaleUpCooldown: 3 * time.Minute}
+	scaler.lastScaleUp = testStart.Add(-time.Minute)
+	for i := 0; i < 3; i++ {
+		if err := scaler.handleClusterResizing(cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(scaler.decisions) != 3 || scaler.decisions[0].Action != actionSkipScaleUp {
+		t.Errorf("expected the skipped scale ups in the decisions, got %+v", scaler.decisions)
+	}
+	scaler.recordDecision(actionFailedPolicy, "", "", "policy %s failed", "ClusterUsage")
+	// Events are delivered in order, so the events above show up before this one.
+	scaler.recordDecision(actionScaleDown, "idle", "small", "requested")
+
+	recorded := map[string]*api.Event{}
+	timeout := time.After(time.Second)
+	for recorded[actionScaleDown] == nil {
+		select {
+		case event := <-events:
+			recorded[event.Reason] = event
+		case <-timeout:
+			t.Fatalf("expected an event for node idle, got %+v", recorded)
+		}
+	}
+	if event, ok := recorded[actionSkipScaleUp]; ok {
+		t.Errorf("unexpected event for a skipped decision %+v", event)
+	}
+	if event := recorded[actionFailedPolicy]; event == nil || event.InvolvedObject.Kind != "Scaler" {
+		t.Errorf("expected an event on the scaler for the failed policy, got %+v", event)
+	}
+	if event := recorded[actionScaleDown]; event.InvolvedObject.Kind != "Minion" || event.InvolvedObject.Name != "idle" || event.InvolvedObject.UID != "" {
+		t.Errorf("expected an event on node idle with no made up UID, got %+v", event.InvolvedObject)
+	}
+}
@@ -0,0 +1,168 @@
+package scaler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+// Adds the nodes required to schedule pods that do not fit on any existing node.
+type unschedulablePodsPolicy struct {
+	// Pods pending for less than this are left to the scheduler.
+	minPendingAge time.Duration
+	clock         util.Clock
+}
+
+// Returns the pods that have been waiting to be scheduled for at least 'minPendingAge'.
+func (self *unschedulablePodsPolicy) getPendingPods(pods []api.Pod) []api.Pod {
+	now := self.clock.Now()
+	pending := []api.Pod{}
+	for _, pod := range pods {
+		if pod.Status.Host != "" || pod.Spec.Host != "" {
+			// Scheduled, or bound to a specific node that new nodes cannot help with.
+			continue
+		}
+		if now.Sub(pod.CreationTimestamp.Time) < self.minPendingAge {
+			continue
+		}
+		pending = append(pending, pod)
+	}
+	return pending
+}
+
+// Returns the size of the request of 'pod', relative to the capacity of 'reference'.
+func relativeRequest(pod api.Pod, reference types.Resource) float64 {
+	cpu, memory := getPodRequest(pod)
+	size := 0.0
+	if reference.Cpu > 0 {
+		size += float64(cpu) / float64(reference.Cpu)
+	}
+	if reference.Memory > 0 {
+		size += float64(memory) / float64(reference.Memory)
+	}
+	return size
+}
+
+// Returns the shape of the node to add for 'pod'. Each shape the pod fits on is
+// packed with the pod and as many of the other 'unplaced' pods as fit, and the
+// shape with the lowest cost per packed request wins.
+func (self *unschedulablePodsPolicy) getShapeFor(pod api.Pod, unplaced []api.Pod, cluster *Cluster, simulator *placementSimulator) (actuator.NodeShape, bool) {
+	var best actuator.NodeShape
+	bestScore := 0.0
+	for _, shape := range cluster.Shapes.List() {
+		trial := simulator.clone()
+		host := trial.addNode(shape)
+		if !trial.placeOn(pod, host) {
+			continue
+		}
+		packed := relativeRequest(pod, cluster.DefaultShape.Capacity)
+		for _, other := range unplaced {
+			if trial.placeOn(other, host) {
+				packed += relativeRequest(other, cluster.DefaultShape.Capacity)
+			}
+		}
+		score := cluster.shapeCost(shape)
+		if packed > 0 {
+			score /= packed
+		}
+		if best.Name == "" || score < bestScore {
+			best = shape
+			bestScore = score
+		}
+	}
+	return best, best.Name != ""
+}
+
+func (self *unschedulablePodsPolicy) PerformScaling(cluster *Cluster) error {
+	if cluster.SchedulingStateErr != nil {
+		return cluster.SchedulingStateErr
+	}
+	pending := self.getPendingPods(cluster.Pods)
+	if len(pending) == 0 {
+		return nil
+	}
+	podsByHost, err := scheduler.MapPodsToMachines(scheduler.FakePodLister(cluster.Pods))
+	if err != nil {
+		return err
+	}
+	simulator := newPlacementSimulator(cluster.Minions.Items, podsByHost)
+	// Nodes requested by earlier policies are available to the pending pods.
+	for _, shapeName := range cluster.New {
+		shape, err := cluster.Shapes.GetNodeShapeWithType(shapeName)
+		if err != nil {
+			return err
+		}
+		simulator.addNode(shape)
+	}
+
+	// Place the largest pods first to pack the new nodes tightly.
+	sort.Sort(byResourceRequest(pending))
+	added := []string{}
+	unplaced := 0
+	for i, pod := range pending {
+		if _, ok := simulator.place(pod, ""); ok {
+			continue
+		}
+		unplaced++
+		// The pending pods that do not fit on the nodes so far compete for the new node.
+		others := []api.Pod{}
+		for _, other := range pending[i+1:] {
+			if !simulator.fitsAnywhere(other) {
+				others = append(others, other)
+			}
+		}
+		shape, ok := self.getShapeFor(pod, others, cluster, simulator)
+		if !ok {
+			glog.Warningf("Pod %s/%s does not fit on any node shape", pod.Namespace, pod.Name)
+			continue
+		}
+		host := simulator.addNode(shape)
+		simulator.placeOn(pod, host)
+		added = append(added, shape.Name)
+	}
+	if len(added) > 0 {
+		glog.Infof("%d pods cannot be scheduled on the existing nodes. Adding nodes %v.", unplaced, added)
+		cluster.New = append(cluster.New, added...)
+	}
+	return nil
+}
+
+// Sorts pods by decreasing cpu request, then by decreasing memory request.
+type byResourceRequest []api.Pod
+
+func getPodRequest(pod api.Pod) (cpu, memory int) {
+	for _, container := range pod.Spec.Containers {
+		cpu += container.CPU
+		memory += container.Memory
+	}
+	return
+}
+
+func (self byResourceRequest) Len() int      { return len(self) }
+func (self byResourceRequest) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+func (self byResourceRequest) Less(i, j int) bool {
+	cpuI, memoryI := getPodRequest(self[i])
+	cpuJ, memoryJ := getPodRequest(self[j])
+	if cpuI != cpuJ {
+		return cpuI > cpuJ
+	}
+	return memoryI > memoryJ
+}
+
+func newUnschedulablePodsPolicy(minPendingAge time.Duration, clock util.Clock) (Policy, error) {
+	if minPendingAge < 0 {
+		return nil, fmt.Errorf("Unschedulable pod age invalid: %v", minPendingAge)
+	}
+	glog.Infof("Adding nodes for pods pending for more than %v", minPendingAge)
+	return &unschedulablePodsPolicy{
+		minPendingAge: minPendingAge,
+		clock:         clock,
+	}, nil
+}
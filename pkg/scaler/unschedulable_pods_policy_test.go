@@ -0,0 +1,113 @@
+package scaler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/actuator"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scaler/types"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+var (
+	testStart  = time.Date(2014, 12, 1, 0, 0, 0, 0, time.UTC)
+	smallShape = actuator.NodeShape{Name: "small", Capacity: types.Resource{Cpu: 1000, Memory: 1000}}
+	largeShape = actuator.NodeShape{Name: "large", Capacity: types.Resource{Cpu: 4000, Memory: 4000}}
+)
+
+func newTestUnschedulablePodsPolicy() *unschedulablePodsPolicy {
+	return &unschedulablePodsPolicy{
+		minPendingAge: time.Minute,
+		clock:         &util.FakeClock{Time: testStart.Add(time.Hour)},
+	}
+}
+
+// Returns a cluster with a single node, "busy", running a pod that uses most of its cpu.
+func newTestClusterWithShapes(pending ...api.Pod) *Cluster {
+	cluster := &Cluster{
+		Shapes:       actuator.NewNodeShapes([]actuator.NodeShape{smallShape, largeShape}),
+		DefaultShape: smallShape,
+		Current:      map[string]Node{"busy": newTestNode("busy", 90)},
+		Minions:      api.NodeList{Items: []api.Node{newTestMinion("busy")}},
+		Pods:         []api.Pod{newTestPod("running", "busy", "frontend", 900, 100)},
+	}
+	for _, pod := range pending {
+		pod.CreationTimestamp = util.Time{Time: testStart}
+		cluster.Pods = append(cluster.Pods, pod)
+	}
+	return cluster
+}
+
+func TestUnschedulablePodsAddNodes(t *testing.T) {
+	policy := newTestUnschedulablePodsPolicy()
+	cluster := newTestClusterWithShapes(
+		newTestPod("a", "", "frontend", 500, 100),
+		newTestPod("b", "", "frontend", 2000, 100),
+		newTestPod("c", "", "frontend", 500, 100),
+	)
+	if err := policy.PerformScaling(cluster); err != nil {
+		t.Fatal(err)
+	}
+	// The large pod needs a large node, which has room for the other pods too.
+	if !reflect.DeepEqual(cluster.New, []string{"large"}) {
+		t.Errorf("expected a single large node, got %v", cluster.New)
+	}
+
+	cluster = newTestClusterWithShapes(
+		newTestPod("a", "", "frontend", 600, 100),
+		newTestPod("b", "", "frontend", 600, 100),
+	)
+	if err := policy.PerformScaling(cluster); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cluster.New, []string{"small", "small"}) {
+		t.Errorf("expected two default nodes, got %v", cluster.New)
+	}
+
+	// Both pods fit on a single large node, which costs less than two small ones.
+	cluster = newTestClusterWithShapes(
+		newTestPod("a", "", "frontend", 600, 100),
+		newTestPod("b", "", "frontend", 600, 100),
+	)
+	cluster.ShapeCosts = map[string]float64{"large": 1.5}
+	if err := policy.PerformScaling(cluster); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cluster.New, []string{"large"}) {
+		t.Errorf("expected a single large node, got %v", cluster.New)
+	}
+}
+
+func TestUnschedulablePodsUseRequestedNodes(t *testing.T) {
+	policy := newTestUnschedulablePodsPolicy()
+	cluster := newTestClusterWithShapes(newTestPod("a", "", "frontend", 500, 100))
+	cluster.New = []string{"small"}
+	if err := policy.PerformScaling(cluster); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cluster.New, []string{"small"}) {
+		t.Errorf("expected the pod to fit on the node already requested, got %v", cluster.New)
+	}
+}
+
+func TestUnschedulablePodsIgnored(t *testing.T) {
+	policy := newTestUnschedulablePodsPolicy()
+	recent := newTestPod("recent", "", "frontend", 500, 100)
+	bound := newTestPod("bound", "", "frontend", 500, 100)
+	bound.Spec.Host = "missing"
+	selector := newTestPod("selector", "", "frontend", 500, 100)
+	selector.Spec.NodeSelector = map[string]string{"disk": "ssd"}
+	fits := newTestPod("fits", "", "frontend", 100, 100)
+
+	cluster := newTestClusterWithShapes(bound, selector, fits)
+	recent.CreationTimestamp = util.Time{Time: testStart.Add(time.Hour)}
+	cluster.Pods = append(cluster.Pods, recent)
+	if err := policy.PerformScaling(cluster); err != nil {
+		t.Fatal(err)
+	}
+	if len(cluster.New) != 0 {
+		t.Errorf("expected no new nodes, got %v", cluster.New)
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+)
+
+// PodFitsAffinity fits a pod on the minions satisfying its required affinity and anti-affinity
+// terms, as long as the pods already scheduled near them do not have required anti-affinity
+// terms matching it.
+func PodFitsAffinity(pod api.Pod, existingPods []api.Pod, node string, cluster *ClusterSnapshot) (bool, error) {
+	if !hasRequiredTerms(&pod) && !cluster.requiredAntiAffinity {
+		return true, nil
+	}
+	candidate, ok := cluster.Minions[node]
+	if !ok {
+		return false, fmt.Errorf("minion %q not found", node)
+	}
+	return fitsAffinity(&pod, candidate, existingPods, cluster), nil
+}
+
+func hasRequiredTerms(pod *api.Pod) bool {
+	affinity := pod.Spec.Affinity
+	return hasRequiredAntiAffinity(pod) || (affinity != nil && affinity.PodAffinity != nil && len(affinity.PodAffinity.Required) > 0)
+}
+
+func hasRequiredAntiAffinity(pod *api.Pod) bool {
+	affinity := pod.Spec.Affinity
+	return affinity != nil && affinity.PodAntiAffinity != nil && len(affinity.PodAntiAffinity.Required) > 0
+}
+
+// listPodsAndMinions returns all the scheduled pods, and the minions keyed by name.
+func listPodsAndMinions(podLister PodLister, minionLister MinionLister) ([]api.Pod, map[string]*api.Node, error) {
+	pods, err := podLister.ListPods(labels.Everything())
+	if err != nil {
+		return nil, nil, err
+	}
+	list, err := minionLister.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	minions := make(map[string]*api.Node, len(list.Items))
+	for i := range list.Items {
+		minions[list.Items[i].Name] = &list.Items[i]
+	}
+	return pods, minions, nil
+}
+
+// podMatchesTerm returns true if 'pod' is selected by a term of a pod in 'namespace'.
+func podMatchesTerm(pod *api.Pod, namespace string, term api.PodAffinityTerm) bool {
+	return pod.Namespace == namespace && labels.SelectorFromSet(term.Selector).Matches(labels.Set(pod.Labels))
+}
+
+// isNear returns true if pods running on 'a' and 'b' are near each other for 'topologyKey'.
+// Minions without the topology label are not near any other minion.
+func isNear(a, b *api.Node, topologyKey string) bool {
+	if topologyKey == "" {
+		return a.Name == b.Name
+	}
+	valueA, ok := a.Labels[topologyKey]
+	if !ok {
+		return false
+	}
+	valueB, ok := b.Labels[topologyKey]
+	return ok && valueA == valueB
+}
+
+// isPodNear returns true if 'pod' runs near 'minion' for 'topologyKey'.
+func isPodNear(pod *api.Pod, minion *api.Node, minions map[string]*api.Node, topologyKey string) bool {
+	host, ok := minions[pod.Status.Host]
+	return ok && isNear(host, minion, topologyKey)
+}
+
+func fitsAffinity(pod *api.Pod, candidate *api.Node, existingPods []api.Pod, cluster *ClusterSnapshot) bool {
+	// podsOn returns the pods on 'minion', which are 'existingPods' for the candidate.
+	podsOn := func(minion string) []api.Pod {
+		if minion == candidate.Name {
+			return existingPods
+		}
+		return cluster.MachineToPods[minion]
+	}
+	affinity := pod.Spec.Affinity
+	if affinity != nil && affinity.PodAffinity != nil {
+		for _, term := range affinity.PodAffinity.Required {
+			matched, near := false, false
+		minions:
+			for name, minion := range cluster.Minions {
+				pods := podsOn(name)
+				for i := range pods {
+					if !podMatchesTerm(&pods[i], pod.Namespace, term) {
+						continue
+					}
+					matched = true
+					if isNear(minion, candidate, term.TopologyKey) {
+						near = true
+						break minions
+					}
+				}
+			}
+			// The first of a group of pods with affinity for each other can go anywhere.
+			if !near && (matched || !podMatchesTerm(pod, pod.Namespace, term)) {
+				return false
+			}
+		}
+	}
+	for name, minion := range cluster.Minions {
+		pods := podsOn(name)
+		for i := range pods {
+			existing := &pods[i]
+			if affinity != nil && affinity.PodAntiAffinity != nil {
+				for _, term := range affinity.PodAntiAffinity.Required {
+					if podMatchesTerm(existing, pod.Namespace, term) && isNear(minion, candidate, term.TopologyKey) {
+						return false
+					}
+				}
+			}
+			// Anti-affinity is symmetric: the pods already scheduled keep away the pods they select.
+			if existing.Spec.Affinity == nil || existing.Spec.Affinity.PodAntiAffinity == nil {
+				continue
+			}
+			for _, term := range existing.Spec.Affinity.PodAntiAffinity.Required {
+				if podMatchesTerm(pod, existing.Namespace, term) && isNear(minion, candidate, term.TopologyKey) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// CalculatePodAffinityPriority favors the minions satisfying the preferred affinity and
+// anti-affinity terms of a pod. Each pod matching a preferred affinity term near a minion
+// adds the weight of the term to its score, and each pod matching a preferred anti-affinity
+// term subtracts it. Scores are scaled to 0-10.
+func CalculatePodAffinityPriority(pod api.Pod, podLister PodLister, minionLister MinionLister) (HostPriorityList, error) {
+	list, err := minionLister.List()
+	if err != nil {
+		return nil, err
+	}
+	scores := make([]int, len(list.Items))
+	affinity := pod.Spec.Affinity
+	if affinity != nil && (affinity.PodAffinity != nil || affinity.PodAntiAffinity != nil) {
+		pods, minions, err := listPodsAndMinions(podLister, minionLister)
+		if err != nil {
+			return nil, err
+		}
+		addTerms := func(podAffinity *api.PodAffinity, sign int) {
+			if podAffinity == nil {
+				return
+			}
+			for _, term := range podAffinity.Preferred {
+				for i := range pods {
+					if !podMatchesTerm(&pods[i], pod.Namespace, term) {
+						continue
+					}
+					for j := range list.Items {
+						if isPodNear(&pods[i], &list.Items[j], minions, term.TopologyKey) {
+							scores[j] += sign * term.Weight
+						}
+					}
+				}
+			}
+		}
+		addTerms(affinity.PodAffinity, 1)
+		addTerms(affinity.PodAntiAffinity, -1)
+	}
+
+	minScore, maxScore := 0, 0
+	for i, score := range scores {
+		if i == 0 || score < minScore {
+			minScore = score
+		}
+		if i == 0 || score > maxScore {
+			maxScore = score
+		}
+	}
+	result := HostPriorityList{}
+	for i, minion := range list.Items {
+		fScore := float32(0)
+		if maxScore > minScore {
+			fScore = 10 * float32(scores[i]-minScore) / float32(maxScore-minScore)
+		}
+		result = append(result, HostPriority{host: minion.Name, score: int(fScore)})
+	}
+	return result, nil
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// Two minions in each of the east and west zones, and one without a zone.
+func makeZonedMinions() api.NodeList {
+	zoned := func(name, zone string) api.Node {
+		minion := api.Node{ObjectMeta: api.ObjectMeta{Name: name}}
+		if zone != "" {
+			minion.Labels = map[string]string{"zone": zone}
+		}
+		return minion
+	}
+	return api.NodeList{Items: []api.Node{
+		zoned("machine1", "east"),
+		zoned("machine2", "east"),
+		zoned("machine3", "west"),
+		zoned("machine4", "west"),
+		zoned("machine5", ""),
+	}}
+}
+
+func makeAffinityPod(namespace string, podLabels map[string]string, host string, affinity *api.Affinity) api.Pod {
+	return api.Pod{
+		ObjectMeta: api.ObjectMeta{Namespace: namespace, Labels: podLabels},
+		Spec:       api.PodSpec{Affinity: affinity},
+		Status:     api.PodStatus{Host: host},
+	}
+}
+
+func requiredAffinity(selector map[string]string, topologyKey string) *api.Affinity {
+	return &api.Affinity{PodAffinity: &api.PodAffinity{
+		Required: []api.PodAffinityTerm{{Selector: selector, TopologyKey: topologyKey}},
+	}}
+}
+
+func requiredAntiAffinity(selector map[string]string, topologyKey string) *api.Affinity {
+	return &api.Affinity{PodAntiAffinity: &api.PodAffinity{
+		Required: []api.PodAffinityTerm{{Selector: selector, TopologyKey: topologyKey}},
+	}}
+}
+
+func TestPodAffinityPredicate(t *testing.T) {
+	db := map[string]string{"app": "db"}
+	web := map[string]string{"app": "web"}
+	tests := []struct {
+		pod  api.Pod
+		pods []api.Pod
+		fits []string
+		test string
+	}{
+		{
+			pod:  makeAffinityPod("default", web, "", nil),
+			pods: []api.Pod{makeAffinityPod("default", db, "machine1", nil)},
+			fits: []string{"machine1", "machine2", "machine3", "machine4", "machine5"},
+			test: "no affinity",
+		},
+		{
+			pod:  makeAffinityPod("default", web, "", requiredAffinity(db, "")),
+			pods: []api.Pod{makeAffinityPod("default", db, "machine1", nil)},
+			fits: []string{"machine1"},
+			test: "affinity for pods on the same minion",
+		},
+		{
+			pod:  makeAffinityPod("default", web, "", requiredAffinity(db, "zone")),
+			pods: []api.Pod{makeAffinityPod("default", db, "machine1", nil)},
+			fits: []string{"machine1", "machine2"},
+			test: "affinity for pods in the same zone",
+		},
+		{
+			pod:  makeAffinityPod("default", web, "", requiredAffinity(db, "zone")),
+			pods: []api.Pod{makeAffinityPod("other", db, "machine1", nil)},
+			fits: []string{},
+			test: "affinity only matches pods in the same namespace",
+		},
+		{
+			pod:  makeAffinityPod("default", db, "", requiredAffinity(db, "zone")),
+			pods: []api.Pod{},
+			fits: []string{"machine1", "machine2", "machine3", "machine4", "machine5"},
+			test: "first pod of a group with affinity for itself",
+		},
+		{
+			pod:  makeAffinityPod("default", web, "", requiredAntiAffinity(web, "")),
+			pods: []api.Pod{makeAffinityPod("default", web, "machine1", nil), makeAffinityPod("default", web, "machine3", nil)},
+			fits: []string{"machine2", "machine4", "machine5"},
+			test: "anti-affinity on the same minion",
+		},
+		{
+			pod:  makeAffinityPod("default", web, "", requiredAntiAffinity(web, "zone")),
+			pods: []api.Pod{makeAffinityPod("default", web, "machine1", nil)},
+			fits: []string{"machine3", "machine4", "machine5"},
+			test: "anti-affinity in the same zone",
+		},
+		{
+			pod:  makeAffinityPod("default", web, "", nil),
+			pods: []api.Pod{makeAffinityPod("default", db, "machine3", requiredAntiAffinity(web, "zone"))},
+			fits: []string{"machine1", "machine2", "machine5"},
+			test: "anti-affinity of the scheduled pods",
+		},
+	}
+	minions := makeZonedMinions()
+	for _, test := range tests {
+		machineToPods, err := MapPodsToMachines(FakePodLister(test.pods))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cluster := NewClusterSnapshot(machineToPods, minions.Items)
+		fits := []string{}
+		for _, minion := range minions.Items {
+			ok, err := PodFitsAffinity(test.pod, machineToPods[minion.Name], minion.Name, cluster)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.test, err)
+			}
+			if ok {
+				fits = append(fits, minion.Name)
+			}
+		}
+		if !reflect.DeepEqual(test.fits, fits) {
+			t.Errorf("%s: expected the pod to fit on %v, got %v", test.test, test.fits, fits)
+		}
+	}
+
+	cluster := NewClusterSnapshot(map[string][]api.Pod{}, minions.Items)
+	pod := makeAffinityPod("default", web, "", requiredAntiAffinity(web, ""))
+	if _, err := PodFitsAffinity(pod, []api.Pod{}, "missing", cluster); err == nil {
+		t.Errorf("expected an error for a missing minion")
+	}
+}
+
+func TestPodAffinityPredicateExistingPods(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	minions := makeZonedMinions()
+	machineToPods := map[string][]api.Pod{
+		"machine1": {makeAffinityPod("default", web, "machine1", nil)},
+	}
+	cluster := NewClusterSnapshot(machineToPods, minions.Items)
+	pod := makeAffinityPod("default", web, "", requiredAntiAffinity(web, "zone"))
+	// The existing pods of the minion replace its pods in the snapshot.
+	if fits, _ := PodFitsAffinity(pod, []api.Pod{}, "machine1", cluster); !fits {
+		t.Errorf("expected the pod to fit on machine1 without its pods")
+	}
+	if fits, _ := PodFitsAffinity(pod, []api.Pod{}, "machine2", cluster); fits {
+		t.Errorf("expected the pod not to fit next to the pods of machine1")
+	}
+}
+
+func TestPodAffinityPriority(t *testing.T) {
+	db := map[string]string{"app": "db"}
+	web := map[string]string{"app": "web"}
+	pods := []api.Pod{
+		makeAffinityPod("default", db, "machine1", nil),
+		makeAffinityPod("default", db, "machine3", nil),
+		makeAffinityPod("default", web, "machine3", nil),
+		makeAffinityPod("default", web, "machine4", nil),
+	}
+	tests := []struct {
+		affinity     *api.Affinity
+		expectedList HostPriorityList
+		test         string
+	}{
+		{
+			expectedList: []HostPriority{{"machine1", 0}, {"machine2", 0}, {"machine3", 0}, {"machine4", 0}, {"machine5", 0}},
+			test:         "no affinity",
+		},
+		{
+			affinity: &api.Affinity{PodAffinity: &api.PodAffinity{
+				Preferred: []api.PodAffinityTerm{{Selector: db, Weight: 10}},
+			}},
+			expectedList: []HostPriority{{"machine1", 10}, {"machine2", 0}, {"machine3", 10}, {"machine4", 0}, {"machine5", 0}},
+			test:         "affinity for pods on the same minion",
+		},
+		{
+			affinity: &api.Affinity{
+				PodAffinity: &api.PodAffinity{
+					Preferred: []api.PodAffinityTerm{{Selector: db, TopologyKey: "zone", Weight: 10}},
+				},
+				PodAntiAffinity: &api.PodAffinity{
+					Preferred: []api.PodAffinityTerm{{Selector: web, TopologyKey: "zone", Weight: 5}},
+				},
+			},
+			// East scores 10, west 0 and machine5 0 before scaling.
+			expectedList: []HostPriority{{"machine1", 10}, {"machine2", 10}, {"machine3", 0}, {"machine4", 0}, {"machine5", 0}},
+			test:         "affinity and anti-affinity across zones",
+		},
+		{
+			affinity: &api.Affinity{PodAntiAffinity: &api.PodAffinity{
+				Preferred: []api.PodAffinityTerm{{Selector: web, Weight: 5}},
+			}},
+			expectedList: []HostPriority{{"machine1", 10}, {"machine2", 10}, {"machine3", 0}, {"machine4", 0}, {"machine5", 10}},
+			test:         "anti-affinity on the same minion",
+		},
+	}
+	for _, test := range tests {
+		pod := makeAffinityPod("default", nil, "", test.affinity)
+		list, err := CalculatePodAffinityPriority(pod, FakePodLister(pods), FakeMinionLister(makeZonedMinions()))
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(test.expectedList, list) {
+			t.Errorf("%s: expected %#v, got %#v", test.test, test.expectedList, list)
+		}
+	}
+}
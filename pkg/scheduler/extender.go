@@ -0,0 +1,176 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// DefaultExtenderTimeout bounds the requests to an extender that has no timeout configured.
+const DefaultExtenderTimeout = 5 * time.Second
+
+// SchedulerExtender is called by the generic scheduler to take part in decisions that the
+// built-in predicates and priority functions cannot make, e.g. because they depend on state
+// kept outside the cluster.
+type SchedulerExtender interface {
+	// Filter returns the nodes of 'nodes' that the pod fits on.
+	Filter(pod api.Pod, nodes api.NodeList) (api.NodeList, error)
+
+	// Prioritize scores 'nodes' for the pod from 0 to 10, and returns the weight the
+	// scores are multiplied by before they are added to those of the priority functions.
+	Prioritize(pod api.Pod, nodes api.NodeList) (HostPriorityList, int, error)
+
+	// Ignorable reports whether the pod is scheduled without the extender when it fails.
+	Ignorable() bool
+}
+
+// ExtenderArgs is the body of the requests sent to an HTTP extender.
+type ExtenderArgs struct {
+	Pod   api.Pod      `json:"pod"`
+	Nodes api.NodeList `json:"nodes"`
+}
+
+// ExtenderFilterResult is the response of an HTTP extender to a filter request.
+type ExtenderFilterResult struct {
+	// Nodes the pod fits on.
+	Nodes api.NodeList `json:"nodes"`
+	// Error fails the request if set.
+	Error string `json:"error,omitempty"`
+}
+
+// ExtenderHostPriority is the score of a host in the response of an HTTP extender to a
+// prioritize request, which is a list of them.
+type ExtenderHostPriority struct {
+	Host  string `json:"host"`
+	Score int    `json:"score"`
+}
+
+// HTTPExtender is a SchedulerExtender that POSTs ExtenderArgs as JSON to the URL prefix
+// joined with the filter or prioritize verb.
+type HTTPExtender struct {
+	urlPrefix      string
+	filterVerb     string
+	prioritizeVerb string
+	weight         int
+	ignorable      bool
+	client         *http.Client
+}
+
+// NewHTTPExtender creates an extender that filters nodes if 'filterVerb' is set and scores
+// them if 'prioritizeVerb' is set. Requests taking longer than 'timeout' fail.
+func NewHTTPExtender(urlPrefix, filterVerb, prioritizeVerb string, weight int, timeout time.Duration, ignorable bool) *HTTPExtender {
+	if timeout <= 0 {
+		timeout = DefaultExtenderTimeout
+	}
+	return &HTTPExtender{
+		urlPrefix:      strings.TrimRight(urlPrefix, "/"),
+		filterVerb:     filterVerb,
+		prioritizeVerb: prioritizeVerb,
+		weight:         weight,
+		ignorable:      ignorable,
+		client:         &http.Client{Transport: util.NewTimeoutTransport(timeout)},
+	}
+}
+
+func (h *HTTPExtender) Ignorable() bool {
+	return h.ignorable
+}
+
+// Filter returns the nodes the extender accepts. Nodes the extender returns that were not
+// candidates are dropped.
+func (h *HTTPExtender) Filter(pod api.Pod, nodes api.NodeList) (api.NodeList, error) {
+	if h.filterVerb == "" {
+		return nodes, nil
+	}
+	var result ExtenderFilterResult
+	if err := h.send(h.filterVerb, ExtenderArgs{Pod: pod, Nodes: nodes}, &result); err != nil {
+		return api.NodeList{}, err
+	}
+	if result.Error != "" {
+		return api.NodeList{}, fmt.Errorf("extender %s failed to filter: %s", h.urlPrefix, result.Error)
+	}
+	accepted := util.StringSet{}
+	for _, node := range result.Nodes.Items {
+		accepted.Insert(node.Name)
+	}
+	filtered := []api.Node{}
+	for _, node := range nodes.Items {
+		if accepted.Has(node.Name) {
+			filtered = append(filtered, node)
+		}
+	}
+	return api.NodeList{Items: filtered}, nil
+}
+
+// Prioritize returns the scores of the extender for the candidate nodes. It returns no
+// scores if the extender does not prioritize, and an error if a score is not from 0 to 10.
+func (h *HTTPExtender) Prioritize(pod api.Pod, nodes api.NodeList) (HostPriorityList, int, error) {
+	if h.prioritizeVerb == "" || h.weight == 0 {
+		return HostPriorityList{}, 0, nil
+	}
+	var scores []ExtenderHostPriority
+	if err := h.send(h.prioritizeVerb, ExtenderArgs{Pod: pod, Nodes: nodes}, &scores); err != nil {
+		return HostPriorityList{}, 0, err
+	}
+	candidates := util.StringSet{}
+	for _, node := range nodes.Items {
+		candidates.Insert(node.Name)
+	}
+	result := HostPriorityList{}
+	for _, score := range scores {
+		if score.Score < 0 || score.Score > 10 {
+			return HostPriorityList{}, 0, fmt.Errorf("extender %s scored host %s %d, outside of 0-10", h.urlPrefix, score.Host, score.Score)
+		}
+		if candidates.Has(score.Host) {
+			result = append(result, HostPriority{host: score.Host, score: score.Score})
+		}
+	}
+	return result, h.weight, nil
+}
+
+func (h *HTTPExtender) send(verb string, args ExtenderArgs, result interface{}) error {
+	url := h.urlPrefix + "/" + verb
+	body, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("extender request to %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read the response of extender %s: %v", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("extender %s returned %d: %s", url, resp.StatusCode, data)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("invalid response from extender %s: %v", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// fakeExtenderServer fits the pods on the nodes named in 'fits' and scores the
+// nodes with 'scores'.
+type fakeExtenderServer struct {
+	fits   []string
+	scores []ExtenderHostPriority
+	delay  time.Duration
+	status int
+}
+
+func (f *fakeExtenderServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	time.Sleep(f.delay)
+	if f.status != 0 {
+		http.Error(w, "failed", f.status)
+		return
+	}
+	var args ExtenderArgs
+	if err := json.NewDecoder(req.Body).Decode(&args); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch req.URL.Path {
+	case "/scheduler/filter":
+		result := ExtenderFilterResult{}
+		for _, name := range f.fits {
+			result.Nodes.Items = append(result.Nodes.Items, api.Node{ObjectMeta: api.ObjectMeta{Name: name}})
+		}
+		json.NewEncoder(w).Encode(result)
+	case "/scheduler/prioritize":
+		json.NewEncoder(w).Encode(f.scores)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func newTestExtender(fake *fakeExtenderServer, weight int, ignorable bool) (*HTTPExtender, func()) {
+	server := httptest.NewServer(fake)
+	extender := NewHTTPExtender(server.URL+"/scheduler/", "filter", "prioritize", weight, 100*time.Millisecond, ignorable)
+	return extender, server.Close
+}
+
+func TestHTTPExtenderFilter(t *testing.T) {
+	extender, done := newTestExtender(&fakeExtenderServer{fits: []string{"1", "3", "4"}}, 1, false)
+	defer done()
+
+	filtered, err := extender.Filter(api.Pod{}, makeMinionList([]string{"1", "2", "3"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Nodes that were not candidates are dropped.
+	if expected := makeMinionList([]string{"1", "3"}); !reflect.DeepEqual(filtered, expected) {
+		t.Errorf("expected %v, got %v", expected, filtered)
+	}
+}
+
+func TestHTTPExtenderPrioritize(t *testing.T) {
+	fake := &fakeExtenderServer{scores: []ExtenderHostPriority{{"1", 10}, {"2", 5}, {"4", 10}}}
+	extender, done := newTestExtender(fake, 3, false)
+	defer done()
+
+	scores, weight, err := extender.Prioritize(api.Pod{}, makeMinionList([]string{"1", "2"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := HostPriorityList{{host: "1", score: 10}, {host: "2", score: 5}}
+	if weight != 3 || !reflect.DeepEqual(scores, expected) {
+		t.Errorf("expected %v with weight 3, got %v with weight %d", expected, scores, weight)
+	}
+
+	noScores := NewHTTPExtender("http://127.0.0.1:0", "filter", "", 3, 0, false)
+	if scores, weight, err := noScores.Prioritize(api.Pod{}, makeMinionList([]string{"1"})); err != nil || weight != 0 || len(scores) != 0 {
+		t.Errorf("expected no scores from an extender without a prioritize verb, got %v, %d, %v", scores, weight, err)
+	}
+}
+
+func TestHTTPExtenderErrors(t *testing.T) {
+	for _, fake := range []*fakeExtenderServer{
+		{status: http.StatusInternalServerError},
+		{delay: time.Second, fits: []string{"1"}},
+	} {
+		extender, done := newTestExtender(fake, 1, false)
+		if _, err := extender.Filter(api.Pod{}, makeMinionList([]string{"1"})); err == nil {
+			t.Errorf("expected a filter error from %+v", fake)
+		}
+		if _, _, err := extender.Prioritize(api.Pod{}, makeMinionList([]string{"1"})); err == nil {
+			t.Errorf("expected a prioritize error from %+v", fake)
+		}
+		done()
+	}
+
+	for _, scores := range [][]ExtenderHostPriority{{{"1", 11}}, {{"1", 5}, {"2", -1}}} {
+		extender, done := newTestExtender(&fakeExtenderServer{scores: scores}, 1, false)
+		if _, _, err := extender.Prioritize(api.Pod{}, makeMinionList([]string{"1", "2"})); err == nil {
+			t.Errorf("expected an error for scores %v", scores)
+		}
+		done()
+	}
+}
+
+func TestGenericSchedulerWithExtenders(t *testing.T) {
+	tests := []struct {
+		extenders    []*fakeExtenderServer
+		weights      []int
+		ignorable    []bool
+		expectedHost string
+		expectsErr   bool
+		name         string
+	}{
+		{
+			extenders:    []*fakeExtenderServer{{fits: []string{"1", "2"}}},
+			weights:      []int{1},
+			ignorable:    []bool{false},
+			expectedHost: "2",
+			name:         "extender filters the best node",
+		},
+		{
+			extenders: []*fakeExtenderServer{
+				{fits: []string{"1", "2", "3"}, scores: []ExtenderHostPriority{{"1", 10}}},
+				{fits: []string{"1", "2", "3"}, scores: []ExtenderHostPriority{{"2", 10}}},
+			},
+			weights:      []int{1, 5},
+			ignorable:    []bool{false, false},
+			expectedHost: "2",
+			name:         "extender scores are weighted",
+		},
+		{
+			extenders:  []*fakeExtenderServer{{fits: []string{"1", "2", "3"}}, {fits: []string{}}},
+			weights:    []int{1, 1},
+			ignorable:  []bool{false, false},
+			expectsErr: true,
+			name:       "no node fits the extenders",
+		},
+		{
+			extenders:  []*fakeExtenderServer{{status: http.StatusServiceUnavailable}},
+			weights:    []int{1},
+			ignorable:  []bool{false},
+			expectsErr: true,
+			name:       "extender fails",
+		},
+		{
+			extenders: []*fakeExtenderServer{
+				{delay: time.Second, fits: []string{"1"}},
+				{fits: []string{"1", "2"}},
+			},
+			weights:      []int{1, 1},
+			ignorable:    []bool{true, false},
+			expectedHost: "2",
+			name:         "ignorable extender times out",
+		},
+	}
+
+	for _, test := range tests {
+		extenders := []SchedulerExtender{}
+		for i, fake := range test.extenders {
+			extender, done := newTestExtender(fake, test.weights[i], test.ignorable[i])
+			defer done()
+			extenders = append(extenders, extender)
+		}
+		random := rand.New(rand.NewSource(0))
+		scheduler := NewGenericScheduler([]FitPredicate{truePredicate}, []PriorityConfig{{Function: numericPriority, Weight: 1}}, extenders, FakePodLister([]api.Pod{}), random)
+		machine, err := scheduler.Schedule(api.Pod{}, FakeMinionLister(makeMinionList([]string{"1", "2", "3"})))
+		if test.expectsErr {
+			if err == nil {
+				t.Errorf("%s: unexpected non-error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if test.expectedHost != machine {
+			t.Errorf("%s: expected %s, saw %s", test.name, test.expectedHost, machine)
+		}
+	}
+}
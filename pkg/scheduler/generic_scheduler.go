@@ -23,17 +23,32 @@ import (
 	"sync"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/golang/glog"
 )
 
+// FitError is returned by the generic scheduler when the pod fits the predicates on no minion.
+type FitError struct {
+	Pod api.Pod
+}
+
+func (e *FitError) Error() string {
+	return fmt.Sprintf("failed to find a fit for pod: %v", e.Pod.Name)
+}
+
 type genericScheduler struct {
 	predicates   []FitPredicate
 	prioritizers []PriorityConfig
+	extenders    []SchedulerExtender
 	pods         PodLister
 	random       *rand.Rand
 	randomLock   sync.Mutex
 }
 
 func (g *genericScheduler) Schedule(pod api.Pod, minionLister MinionLister) (string, error) {
+	return g.schedule(pod, g.pods, minionLister)
+}
+
+func (g *genericScheduler) schedule(pod api.Pod, podLister PodLister, minionLister MinionLister) (string, error) {
 	minions, err := minionLister.List()
 	if err != nil {
 		return "", err
@@ -42,12 +57,23 @@ func (g *genericScheduler) Schedule(pod api.Pod, minionLister MinionLister) (str
 		return "", fmt.Errorf("no minions available to schedule pods")
 	}
 
-	filteredNodes, err := findNodesThatFit(pod, g.pods, g.predicates, minions)
+	filteredNodes, err := findNodesThatFit(pod, podLister, g.predicates, minions)
+	if err != nil {
+		return "", err
+	}
+	if len(filteredNodes.Items) == 0 {
+		return "", &FitError{Pod: pod}
+	}
+
+	filteredNodes, err = filterWithExtenders(pod, g.extenders, filteredNodes)
 	if err != nil {
 		return "", err
 	}
+	if len(filteredNodes.Items) == 0 {
+		return "", fmt.Errorf("the extenders rejected all the minions pod %v fits on", pod.Name)
+	}
 
-	priorityList, err := prioritizeNodes(pod, g.pods, g.prioritizers, FakeMinionLister(filteredNodes))
+	priorityList, err := prioritizeNodes(pod, podLister, g.prioritizers, g.extenders, FakeMinionLister(filteredNodes))
 	if err != nil {
 		return "", err
 	}
@@ -82,17 +108,11 @@ func findNodesThatFit(pod api.Pod, podLister PodLister, predicates []FitPredicat
 	if err != nil {
 		return api.NodeList{}, err
 	}
+	cluster := NewClusterSnapshot(machineToPods, nodes.Items)
 	for _, node := range nodes.Items {
-		fits := true
-		for _, predicate := range predicates {
-			fit, err := predicate(pod, machineToPods[node.Name], node.Name)
-			if err != nil {
-				return api.NodeList{}, err
-			}
-			if !fit {
-				fits = false
-				break
-			}
+		fits, err := podFitsPredicates(pod, machineToPods[node.Name], node.Name, predicates, cluster)
+		if err != nil {
+			return api.NodeList{}, err
 		}
 		if fits {
 			filtered = append(filtered, node)
@@ -101,13 +121,44 @@ func findNodesThatFit(pod api.Pod, podLister PodLister, predicates []FitPredicat
 	return api.NodeList{Items: filtered}, nil
 }
 
+func podFitsPredicates(pod api.Pod, existingPods []api.Pod, node string, predicates []FitPredicate, cluster *ClusterSnapshot) (bool, error) {
+	for _, predicate := range predicates {
+		fit, err := predicate(pod, existingPods, node, cluster)
+		if err != nil || !fit {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Filters the minions that fit the predicates further by calling the extenders in order.
+// A failing extender fails scheduling unless it is ignorable, in which case it is skipped.
+func filterWithExtenders(pod api.Pod, extenders []SchedulerExtender, nodes api.NodeList) (api.NodeList, error) {
+	for _, extender := range extenders {
+		if len(nodes.Items) == 0 {
+			break
+		}
+		filtered, err := extender.Filter(pod, nodes)
+		if err != nil {
+			if extender.Ignorable() {
+				glog.Warningf("Skipping extender for pod %s: %v", pod.Name, err)
+				continue
+			}
+			return api.NodeList{}, err
+		}
+		nodes = filtered
+	}
+	return nodes, nil
+}
+
 // Prioritizes the minions by running the individual priority functions sequentially.
 // Each priority function is expected to set a score of 0-10
 // 0 is the lowest priority score (least preferred minion) and 10 is the highest
 // Each priority function can also have its own weight
 // The minion scores returned by the priority function are multiplied by the weights to get weighted scores
+// The weighted scores of the extenders are added the same way
 // All scores are finally combined (added) to get the total weighted scores of all minions
-func prioritizeNodes(pod api.Pod, podLister PodLister, priorityConfigs []PriorityConfig, minionLister MinionLister) (HostPriorityList, error) {
+func prioritizeNodes(pod api.Pod, podLister PodLister, priorityConfigs []PriorityConfig, extenders []SchedulerExtender, minionLister MinionLister) (HostPriorityList, error) {
 	result := HostPriorityList{}
 	combinedScores := map[string]int{}
 	for _, priorityConfig := range priorityConfigs {
@@ -125,6 +176,25 @@ func prioritizeNodes(pod api.Pod, podLister PodLister, priorityConfigs []Priorit
 			combinedScores[hostEntry.host] += hostEntry.score * weight
 		}
 	}
+	if len(extenders) > 0 {
+		nodes, err := minionLister.List()
+		if err != nil {
+			return HostPriorityList{}, err
+		}
+		for _, extender := range extenders {
+			prioritizedList, weight, err := extender.Prioritize(pod, nodes)
+			if err != nil {
+				if extender.Ignorable() {
+					glog.Warningf("Skipping extender priorities for pod %s: %v", pod.Name, err)
+					continue
+				}
+				return HostPriorityList{}, err
+			}
+			for _, hostEntry := range prioritizedList {
+				combinedScores[hostEntry.host] += hostEntry.score * weight
+			}
+		}
+	}
 	for host, score := range combinedScores {
 		result = append(result, HostPriority{host: host, score: score})
 	}
@@ -161,10 +231,11 @@ func EqualPriority(pod api.Pod, podLister PodLister, minionLister MinionLister)
 	return result, nil
 }
 
-func NewGenericScheduler(predicates []FitPredicate, prioritizers []PriorityConfig, pods PodLister, random *rand.Rand) Scheduler {
+func NewGenericScheduler(predicates []FitPredicate, prioritizers []PriorityConfig, extenders []SchedulerExtender, pods PodLister, random *rand.Rand) Scheduler {
 	return &genericScheduler{
 		predicates:   predicates,
 		prioritizers: prioritizers,
+		extenders:    extenders,
 		pods:         pods,
 		random:       random,
 	}
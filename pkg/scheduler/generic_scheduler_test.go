@@ -27,15 +27,15 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 )
 
-func falsePredicate(pod api.Pod, existingPods []api.Pod, node string) (bool, error) {
+func falsePredicate(pod api.Pod, existingPods []api.Pod, node string, cluster *ClusterSnapshot) (bool, error) {
 	return false, nil
 }
 
-func truePredicate(pod api.Pod, existingPods []api.Pod, node string) (bool, error) {
+func truePredicate(pod api.Pod, existingPods []api.Pod, node string, cluster *ClusterSnapshot) (bool, error) {
 	return true, nil
 }
 
-func matchesPredicate(pod api.Pod, existingPods []api.Pod, node string) (bool, error) {
+func matchesPredicate(pod api.Pod, existingPods []api.Pod, node string, cluster *ClusterSnapshot) (bool, error) {
 	return pod.Name == node, nil
 }
 
@@ -227,7 +227,7 @@ func TestGenericScheduler(t *testing.T) {
 
 	for _, test := range tests {
 		random := rand.New(rand.NewSource(0))
-		scheduler := NewGenericScheduler(test.predicates, test.prioritizers, FakePodLister([]api.Pod{}), random)
+		scheduler := NewGenericScheduler(test.predicates, test.prioritizers, nil, FakePodLister([]api.Pod{}), random)
 		machine, err := scheduler.Schedule(test.pod, FakeMinionLister(makeMinionList(test.nodes)))
 		if test.expectsErr {
 			if err == nil {
@@ -0,0 +1,58 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+)
+
+// ScheduleGroup schedules the pods one after the other, each of them seeing the pods placed
+// before it as if they were running, and fails if any of them does not fit.
+func (g *genericScheduler) ScheduleGroup(pods []api.Pod, minionLister MinionLister) ([]string, error) {
+	lister := &assumedPodLister{PodLister: g.pods}
+	machines := []string{}
+	for _, pod := range pods {
+		machine, err := g.schedule(pod, lister, minionLister)
+		if err != nil {
+			return nil, err
+		}
+		pod.Status.Host = machine
+		lister.assumed = append(lister.assumed, pod)
+		machines = append(machines, machine)
+	}
+	return machines, nil
+}
+
+// assumedPodLister lists the pods of a PodLister and the pods assumed to run in addition.
+type assumedPodLister struct {
+	PodLister
+	assumed []api.Pod
+}
+
+func (a *assumedPodLister) ListPods(selector labels.Selector) ([]api.Pod, error) {
+	pods, err := a.PodLister.ListPods(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range a.assumed {
+		if selector.Matches(labels.Set(pod.Labels)) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+func TestScheduleGroup(t *testing.T) {
+	tests := []struct {
+		pods             []api.Pod
+		existingPods     []api.Pod
+		expectedMachines []string
+		expectsErr       bool
+		test             string
+	}{
+		{
+			pods:             []api.Pod{priorityPod("a", "", 0, 6), priorityPod("b", "", 0, 6)},
+			expectedMachines: []string{"2", "1"},
+			test:             "members see the members placed before them",
+		},
+		{
+			pods:         []api.Pod{priorityPod("a", "", 0, 6), priorityPod("b", "", 0, 6)},
+			existingPods: []api.Pod{priorityPod("running", "2", 0, 6)},
+			expectsErr:   true,
+			test:         "a member does not fit",
+		},
+		{
+			pods:             []api.Pod{priorityPod("a", "", 0, 2), priorityPod("b", "", 0, 2), priorityPod("c", "", 0, 8)},
+			expectedMachines: []string{"2", "2", "1"},
+			test:             "members share a machine",
+		},
+	}
+
+	node := api.Node{Spec: api.NodeSpec{Capacity: makeResources(10, 20).Capacity}}
+	predicates := []FitPredicate{NewResourceFitPredicate(FakeNodeInfo(node))}
+	for _, test := range tests {
+		scheduler := NewGenericScheduler(predicates, []PriorityConfig{{Function: numericPriority, Weight: 1}}, nil, FakePodLister(test.existingPods), rand.New(rand.NewSource(0)))
+		machines, err := scheduler.(GroupScheduler).ScheduleGroup(test.pods, FakeMinionLister(makeMinionList([]string{"1", "2"})))
+		if test.expectsErr {
+			if err == nil {
+				t.Errorf("%s: unexpected non-error", test.test)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.test, err)
+			continue
+		}
+		if !reflect.DeepEqual(machines, test.expectedMachines) {
+			t.Errorf("%s: expected %v, got %v", test.test, test.expectedMachines, machines)
+		}
+	}
+}
+
+func TestScheduleGroupAntiAffinity(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	member := func(name string) api.Pod {
+		pod := makeAffinityPod("default", web, "", requiredAntiAffinity(web, ""))
+		pod.Name = name
+		return pod
+	}
+	scheduler := NewGenericScheduler([]FitPredicate{PodFitsAffinity}, []PriorityConfig{{Function: numericPriority, Weight: 1}}, nil, FakePodLister{}, rand.New(rand.NewSource(0)))
+	minions := FakeMinionLister(makeMinionList([]string{"1", "2"}))
+	machines, err := scheduler.(GroupScheduler).ScheduleGroup([]api.Pod{member("a"), member("b")}, minions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(machines, []string{"2", "1"}) {
+		t.Errorf("expected the members on different machines, got %v", machines)
+	}
+	if _, err := scheduler.(GroupScheduler).ScheduleGroup([]api.Pod{member("a"), member("b"), member("c")}, minions); err == nil {
+		t.Errorf("expected the third member not to fit")
+	}
+}
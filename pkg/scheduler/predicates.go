@@ -72,7 +72,7 @@ func isVolumeConflict(volume api.Volume, pod *api.Pod) bool {
 // are exclusive so if there is already a volume mounted on that node, another pod can't schedule
 // there. This is GCE specific for now.
 // TODO: migrate this into some per-volume specific code?
-func NoDiskConflict(pod api.Pod, existingPods []api.Pod, node string) (bool, error) {
+func NoDiskConflict(pod api.Pod, existingPods []api.Pod, node string, cluster *ClusterSnapshot) (bool, error) {
 	manifest := &(pod.Spec)
 	for ix := range manifest.Volumes {
 		for podIx := range existingPods {
@@ -103,7 +103,7 @@ func getResourceRequest(pod *api.Pod) resourceRequest {
 }
 
 // PodFitsResources calculates fit based on requested, rather than used resources
-func (r *ResourceFit) PodFitsResources(pod api.Pod, existingPods []api.Pod, node string) (bool, error) {
+func (r *ResourceFit) PodFitsResources(pod api.Pod, existingPods []api.Pod, node string, cluster *ClusterSnapshot) (bool, error) {
 	podRequest := getResourceRequest(&pod)
 	if podRequest.milliCPU == 0 && podRequest.memory == 0 {
 		// no resources requested always fits.
@@ -150,7 +150,7 @@ type NodeSelector struct {
 	info NodeInfo
 }
 
-func (n *NodeSelector) PodSelectorMatches(pod api.Pod, existingPods []api.Pod, node string) (bool, error) {
+func (n *NodeSelector) PodSelectorMatches(pod api.Pod, existingPods []api.Pod, node string, cluster *ClusterSnapshot) (bool, error) {
 	if len(pod.Spec.NodeSelector) == 0 {
 		return true, nil
 	}
@@ -162,14 +162,45 @@ func (n *NodeSelector) PodSelectorMatches(pod api.Pod, existingPods []api.Pod, n
 	return selector.Matches(labels.Set(minion.Labels)), nil
 }
 
-func PodFitsHost(pod api.Pod, existingPods []api.Pod, node string) (bool, error) {
+type NodeLabelChecker struct {
+	info     NodeInfo
+	labels   []string
+	presence bool
+}
+
+// NewNodeLabelPredicate fits nodes that have all of 'labels' if 'presence' is true,
+// and nodes that have none of them otherwise, regardless of the label values.
+func NewNodeLabelPredicate(info NodeInfo, labels []string, presence bool) FitPredicate {
+	checker := &NodeLabelChecker{
+		info:     info,
+		labels:   labels,
+		presence: presence,
+	}
+	return checker.CheckNodeLabelPresence
+}
+
+func (n *NodeLabelChecker) CheckNodeLabelPresence(pod api.Pod, existingPods []api.Pod, node string, cluster *ClusterSnapshot) (bool, error) {
+	minion, err := n.info.GetNodeInfo(node)
+	if err != nil {
+		return false, err
+	}
+	for _, label := range n.labels {
+		_, exists := minion.Labels[label]
+		if exists != n.presence {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func PodFitsHost(pod api.Pod, existingPods []api.Pod, node string, cluster *ClusterSnapshot) (bool, error) {
 	if len(pod.Spec.Host) == 0 {
 		return true, nil
 	}
 	return pod.Spec.Host == node, nil
 }
 
-func PodFitsPorts(pod api.Pod, existingPods []api.Pod, node string) (bool, error) {
+func PodFitsPorts(pod api.Pod, existingPods []api.Pod, node string, cluster *ClusterSnapshot) (bool, error) {
 	existingPorts := getUsedPorts(existingPods...)
 	wantPorts := getUsedPorts(pod)
 	for wport := range wantPorts {
@@ -114,7 +114,7 @@ func TestPodFitsResources(t *testing.T) {
 		node := api.Node{Spec: api.NodeSpec{Capacity: makeResources(10, 20).Capacity}}
 
 		fit := ResourceFit{FakeNodeInfo(node)}
-		fits, err := fit.PodFitsResources(test.pod, test.existingPods, "machine")
+		fits, err := fit.PodFitsResources(test.pod, test.existingPods, "machine", nil)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -160,7 +160,7 @@ func TestPodFitsHost(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		result, err := PodFitsHost(test.pod, []api.Pod{}, test.node)
+		result, err := PodFitsHost(test.pod, []api.Pod{}, test.node, nil)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -217,7 +217,7 @@ func TestPodFitsPorts(t *testing.T) {
 		},
 	}
 	for _, test := range tests {
-		fits, err := PodFitsPorts(test.pod, test.existingPods, "machine")
+		fits, err := PodFitsPorts(test.pod, test.existingPods, "machine", nil)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -299,7 +299,7 @@ func TestDiskConflicts(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		ok, err := NoDiskConflict(test.pod, test.existingPods, "machine")
+		ok, err := NoDiskConflict(test.pod, test.existingPods, "machine", nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -384,7 +384,65 @@ func TestPodFitsSelector(t *testing.T) {
 		node := api.Node{ObjectMeta: api.ObjectMeta{Labels: test.labels}}
 
 		fit := NodeSelector{FakeNodeInfo(node)}
-		fits, err := fit.PodSelectorMatches(test.pod, []api.Pod{}, "machine")
+		fits, err := fit.PodSelectorMatches(test.pod, []api.Pod{}, "machine", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if fits != test.fits {
+			t.Errorf("%s: expected: %v got %v", test.test, test.fits, fits)
+		}
+	}
+}
+
+func TestNodeLabelPresence(t *testing.T) {
+	label := map[string]string{"foo": "bar", "bar": "foo"}
+	tests := []struct {
+		labels   []string
+		presence bool
+		fits     bool
+		test     string
+	}{
+		{
+			labels:   []string{"baz"},
+			presence: true,
+			fits:     false,
+			test:     "label does not match, presence true",
+		},
+		{
+			labels:   []string{"baz"},
+			presence: false,
+			fits:     true,
+			test:     "label does not match, presence false",
+		},
+		{
+			labels:   []string{"foo", "baz"},
+			presence: true,
+			fits:     false,
+			test:     "one label matches, presence true",
+		},
+		{
+			labels:   []string{"foo", "baz"},
+			presence: false,
+			fits:     false,
+			test:     "one label matches, presence false",
+		},
+		{
+			labels:   []string{"foo", "bar"},
+			presence: true,
+			fits:     true,
+			test:     "all labels match, presence true",
+		},
+		{
+			labels:   []string{"foo", "bar"},
+			presence: false,
+			fits:     false,
+			test:     "all labels match, presence false",
+		},
+	}
+	for _, test := range tests {
+		node := api.Node{ObjectMeta: api.ObjectMeta{Labels: label}}
+		fit := NewNodeLabelPredicate(FakeNodeInfo(node), test.labels, test.presence)
+		fits, err := fit(api.Pod{}, []api.Pod{}, "machine", nil)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -0,0 +1,121 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"sort"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// Preempt finds the minion where the pod fits the predicates and the extenders after evicting
+// pods with a lower priority. The minion with the fewest victims is chosen, breaking ties in
+// favor of evicting pods of lower priorities. The extenders only see the minions before eviction.
+func (g *genericScheduler) Preempt(pod api.Pod, minionLister MinionLister) (string, []api.Pod, error) {
+	minions, err := minionLister.List()
+	if err != nil {
+		return "", nil, err
+	}
+	machineToPods, err := MapPodsToMachines(g.pods)
+	if err != nil {
+		return "", nil, err
+	}
+	cluster := NewClusterSnapshot(machineToPods, minions.Items)
+	candidates := api.NodeList{}
+	minionVictims := map[string][]api.Pod{}
+	for _, minion := range minions.Items {
+		victims, err := selectVictims(pod, machineToPods[minion.Name], minion.Name, g.predicates, cluster)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(victims) > 0 {
+			candidates.Items = append(candidates.Items, minion)
+			minionVictims[minion.Name] = victims
+		}
+	}
+	candidates, err = filterWithExtenders(pod, g.extenders, candidates)
+	if err != nil {
+		return "", nil, err
+	}
+	selected := ""
+	var selectedVictims []api.Pod
+	for _, minion := range candidates.Items {
+		victims := minionVictims[minion.Name]
+		if selected == "" || len(victims) < len(selectedVictims) ||
+			(len(victims) == len(selectedVictims) && victims[0].Spec.Priority < selectedVictims[0].Spec.Priority) {
+			selected = minion.Name
+			selectedVictims = victims
+		}
+	}
+	return selected, selectedVictims, nil
+}
+
+// Returns the pods to evict from 'node' for the pod to fit, sorted by decreasing priority,
+// or none if the pod does not fit even without all the pods of a lower priority. Evicting all
+// of them is the starting point, from which the pods are spared greedily by decreasing
+// priority as long as the pod still fits, so that no victim could have been spared. This is
+// not always the fewest pods: a pod of a higher priority is spared even if evicting it alone
+// would have spared several pods of lower priorities.
+func selectVictims(pod api.Pod, existingPods []api.Pod, node string, predicates []FitPredicate, cluster *ClusterSnapshot) ([]api.Pod, error) {
+	remaining := []api.Pod{}
+	candidates := []api.Pod{}
+	for _, existing := range existingPods {
+		if existing.Spec.Priority < pod.Spec.Priority {
+			candidates = append(candidates, existing)
+		} else {
+			remaining = append(remaining, existing)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	fits, err := podFitsPredicates(pod, remaining, node, predicates, cluster)
+	if err != nil || !fits {
+		return nil, err
+	}
+	sort.Sort(byDecreasingPriority(candidates))
+	victims := []api.Pod{}
+	for _, candidate := range candidates {
+		remaining = append(remaining, candidate)
+		fits, err := podFitsPredicates(pod, remaining, node, predicates, cluster)
+		if err != nil {
+			return nil, err
+		}
+		if !fits {
+			remaining = remaining[:len(remaining)-1]
+			victims = append(victims, candidate)
+		}
+	}
+	return victims, nil
+}
+
+type byDecreasingPriority []api.Pod
+
+func (p byDecreasingPriority) Len() int {
+	return len(p)
+}
+
+func (p byDecreasingPriority) Less(i, j int) bool {
+	if p[i].Spec.Priority == p[j].Spec.Priority {
+		return p[i].Name < p[j].Name
+	}
+	return p[i].Spec.Priority > p[j].Spec.Priority
+}
+
+func (p byDecreasingPriority) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
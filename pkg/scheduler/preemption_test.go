@@ -0,0 +1,242 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+func priorityPod(name, host string, priority, cpu int) api.Pod {
+	pod := newResourcePod(resourceRequest{milliCPU: cpu * 1000})
+	pod.Name = name
+	pod.Status.Host = host
+	pod.Spec.Priority = priority
+	return pod
+}
+
+func TestPreempt(t *testing.T) {
+	tests := []struct {
+		pod             api.Pod
+		pods            []api.Pod
+		minions         []string
+		expectedMachine string
+		expectedVictims []string
+		test            string
+	}{
+		{
+			pod: priorityPod("new", "", 1, 5),
+			pods: []api.Pod{
+				priorityPod("a", "machine1", 0, 4),
+				priorityPod("b", "machine1", 0, 4),
+				priorityPod("c", "machine2", 0, 8),
+			},
+			minions:         []string{"machine1", "machine2"},
+			expectedMachine: "machine1",
+			expectedVictims: []string{"b"},
+			test:            "spares the pods that leave room",
+		},
+		{
+			pod: priorityPod("new", "", 1, 6),
+			pods: []api.Pod{
+				priorityPod("a", "machine1", 0, 4),
+				priorityPod("b", "machine1", 0, 4),
+				priorityPod("c", "machine2", 0, 3),
+				priorityPod("d", "machine2", 0, 3),
+				priorityPod("e", "machine2", 0, 3),
+			},
+			minions:         []string{"machine2", "machine1"},
+			expectedMachine: "machine1",
+			expectedVictims: []string{"b"},
+			test:            "fewest victims",
+		},
+		{
+			pod: priorityPod("new", "", 5, 5),
+			pods: []api.Pod{
+				priorityPod("a", "machine1", 2, 8),
+				priorityPod("b", "machine2", 1, 8),
+			},
+			minions:         []string{"machine1", "machine2"},
+			expectedMachine: "machine2",
+			expectedVictims: []string{"b"},
+			test:            "victims with the lowest priority",
+		},
+		{
+			pod: priorityPod("new", "", 5, 5),
+			pods: []api.Pod{
+				priorityPod("a", "machine1", 1, 3),
+				priorityPod("b", "machine1", 3, 3),
+				priorityPod("c", "machine1", 2, 3),
+			},
+			minions:         []string{"machine1"},
+			expectedMachine: "machine1",
+			expectedVictims: []string{"c", "a"},
+			test:            "spares pods with a higher priority first",
+		},
+		{
+			pod: priorityPod("new", "", 5, 5),
+			pods: []api.Pod{
+				priorityPod("a", "machine1", 3, 5),
+				priorityPod("b", "machine1", 2, 2),
+				priorityPod("c", "machine1", 1, 2),
+			},
+			minions:         []string{"machine1"},
+			expectedMachine: "machine1",
+			// Evicting a alone would be enough, but victims are not spared to save
+			// pods of a higher priority.
+			expectedVictims: []string{"b", "c"},
+			test:            "not the fewest victims on a minion",
+		},
+		{
+			pod: priorityPod("new", "", 1, 5),
+			pods: []api.Pod{
+				priorityPod("a", "machine1", 1, 8),
+				priorityPod("b", "machine2", 2, 8),
+			},
+			minions: []string{"machine1", "machine2"},
+			test:    "pods of equal or higher priority are not preempted",
+		},
+		{
+			pod: priorityPod("new", "", 1, 20),
+			pods: []api.Pod{
+				priorityPod("a", "machine1", 0, 8),
+			},
+			minions: []string{"machine1"},
+			test:    "does not fit without any pod",
+		},
+	}
+
+	node := api.Node{Spec: api.NodeSpec{Capacity: makeResources(10, 20).Capacity}}
+	predicates := []FitPredicate{NewResourceFitPredicate(FakeNodeInfo(node))}
+	for _, test := range tests {
+		scheduler := NewGenericScheduler(predicates, []PriorityConfig{{Function: EqualPriority, Weight: 1}}, nil, FakePodLister(test.pods), rand.New(rand.NewSource(0)))
+		minions := FakeMinionLister(makeMinionList(test.minions))
+		if _, err := scheduler.Schedule(test.pod, minions); err == nil {
+			t.Errorf("%s: expected the pod not to fit", test.test)
+		} else if _, ok := err.(*FitError); !ok {
+			t.Errorf("%s: expected a fit error, got %v", test.test, err)
+		}
+		machine, victims, err := scheduler.(Preemptor).Preempt(test.pod, minions)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.test, err)
+			continue
+		}
+		names := []string{}
+		for _, victim := range victims {
+			names = append(names, victim.Name)
+		}
+		if machine != test.expectedMachine || (len(names) > 0 || len(test.expectedVictims) > 0) && !reflect.DeepEqual(names, test.expectedVictims) {
+			t.Errorf("%s: expected %v on %q, got %v on %q", test.test, test.expectedVictims, test.expectedMachine, names, machine)
+		}
+	}
+}
+
+func TestPreemptAntiAffinity(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	webPod := func(name, host string, priority int) api.Pod {
+		pod := priorityPod(name, host, priority, 1)
+		pod.Labels = web
+		return pod
+	}
+	pod := webPod("new", "", 1)
+	pod.Spec.Affinity = requiredAntiAffinity(web, "")
+	pods := []api.Pod{webPod("a", "machine1", 0), webPod("b", "machine2", 2)}
+
+	node := api.Node{Spec: api.NodeSpec{Capacity: makeResources(10, 20).Capacity}}
+	predicates := []FitPredicate{NewResourceFitPredicate(FakeNodeInfo(node)), PodFitsAffinity}
+	scheduler := NewGenericScheduler(predicates, []PriorityConfig{{Function: EqualPriority, Weight: 1}}, nil, FakePodLister(pods), rand.New(rand.NewSource(0)))
+	minions := FakeMinionLister(makeMinionList([]string{"machine1", "machine2"}))
+	if _, err := scheduler.Schedule(pod, minions); err == nil {
+		t.Fatalf("expected the pod not to fit next to the other web pods")
+	}
+	machine, victims, err := scheduler.(Preemptor).Preempt(pod, minions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if machine != "machine1" || len(victims) != 1 || victims[0].Name != "a" {
+		t.Errorf("expected to evict a from machine1, got %v on %q", victims, machine)
+	}
+}
+
+func TestPreemptWithExtenders(t *testing.T) {
+	pod := priorityPod("new", "", 1, 5)
+	pods := []api.Pod{
+		priorityPod("a", "machine1", 0, 4),
+		priorityPod("b", "machine1", 0, 4),
+		priorityPod("c", "machine2", 0, 8),
+	}
+	tests := []struct {
+		fits            []string
+		expectedMachine string
+		expectedVictims []string
+		test            string
+	}{
+		{
+			fits:            []string{"machine1", "machine2"},
+			expectedMachine: "machine1",
+			expectedVictims: []string{"b"},
+			test:            "extender accepting all the minions",
+		},
+		{
+			fits:            []string{"machine2"},
+			expectedMachine: "machine2",
+			expectedVictims: []string{"c"},
+			test:            "extender rejecting the minion with the fewest victims",
+		},
+		{
+			fits: []string{},
+			test: "extender rejecting all the minions",
+		},
+	}
+	node := api.Node{Spec: api.NodeSpec{Capacity: makeResources(10, 20).Capacity}}
+	predicates := []FitPredicate{NewResourceFitPredicate(FakeNodeInfo(node))}
+	minions := FakeMinionLister(makeMinionList([]string{"machine1", "machine2"}))
+	for _, test := range tests {
+		extender, done := newTestExtender(&fakeExtenderServer{fits: test.fits}, 1, false)
+		scheduler := NewGenericScheduler(predicates, []PriorityConfig{{Function: EqualPriority, Weight: 1}}, []SchedulerExtender{extender}, FakePodLister(pods), rand.New(rand.NewSource(0)))
+		machine, victims, err := scheduler.(Preemptor).Preempt(pod, minions)
+		done()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.test, err)
+			continue
+		}
+		names := []string{}
+		for _, victim := range victims {
+			names = append(names, victim.Name)
+		}
+		if machine != test.expectedMachine || (len(names) > 0 || len(test.expectedVictims) > 0) && !reflect.DeepEqual(names, test.expectedVictims) {
+			t.Errorf("%s: expected %v on %q, got %v on %q", test.test, test.expectedVictims, test.expectedMachine, names, machine)
+		}
+	}
+}
+
+func TestScheduleExtendersRejectAll(t *testing.T) {
+	extender, done := newTestExtender(&fakeExtenderServer{fits: []string{}}, 1, false)
+	defer done()
+	scheduler := NewGenericScheduler([]FitPredicate{truePredicate}, []PriorityConfig{{Function: EqualPriority, Weight: 1}}, []SchedulerExtender{extender}, FakePodLister{}, rand.New(rand.NewSource(0)))
+	_, err := scheduler.Schedule(priorityPod("new", "", 1, 1), FakeMinionLister(makeMinionList([]string{"machine1"})))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	// Preempting cannot make room on minions the extenders reject.
+	if _, ok := err.(*FitError); ok {
+		t.Errorf("expected an error other than a fit error, got %v", err)
+	}
+}
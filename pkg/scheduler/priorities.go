@@ -80,3 +80,23 @@ func LeastRequestedPriority(pod api.Pod, podLister PodLister, minionLister Minio
 	}
 	return list, nil
 }
+
+// NewNodeLabelPriority returns a priority function that favors nodes that have 'label'
+// if 'presence' is true, and nodes that do not have it otherwise.
+func NewNodeLabelPriority(label string, presence bool) PriorityFunction {
+	return func(pod api.Pod, podLister PodLister, minionLister MinionLister) (HostPriorityList, error) {
+		minions, err := minionLister.List()
+		if err != nil {
+			return nil, err
+		}
+		result := HostPriorityList{}
+		for _, minion := range minions.Items {
+			score := 0
+			if _, exists := minion.Labels[label]; exists == presence {
+				score = 10
+			}
+			result = append(result, HostPriority{host: minion.Name, score: score})
+		}
+		return result, nil
+	}
+}
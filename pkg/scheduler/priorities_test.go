@@ -239,3 +239,45 @@ func TestLeastRequested(t *testing.T) {
 		}
 	}
 }
+
+func TestNodeLabelPriority(t *testing.T) {
+	nodes := []api.Node{
+		{ObjectMeta: api.ObjectMeta{Name: "machine1", Labels: map[string]string{"foo": "bar"}}},
+		{ObjectMeta: api.ObjectMeta{Name: "machine2", Labels: map[string]string{"bar": "foo"}}},
+	}
+	tests := []struct {
+		label        string
+		presence     bool
+		expectedList HostPriorityList
+		test         string
+	}{
+		{
+			label:        "foo",
+			presence:     true,
+			expectedList: []HostPriority{{"machine1", 10}, {"machine2", 0}},
+			test:         "prefer nodes with the label",
+		},
+		{
+			label:        "foo",
+			presence:     false,
+			expectedList: []HostPriority{{"machine1", 0}, {"machine2", 10}},
+			test:         "prefer nodes without the label",
+		},
+		{
+			label:        "baz",
+			presence:     true,
+			expectedList: []HostPriority{{"machine1", 0}, {"machine2", 0}},
+			test:         "no node has the label",
+		},
+	}
+	for _, test := range tests {
+		prioritizer := NewNodeLabelPriority(test.label, test.presence)
+		list, err := prioritizer(api.Pod{}, FakePodLister([]api.Pod{}), FakeMinionLister(api.NodeList{Items: nodes}))
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(test.expectedList, list) {
+			t.Errorf("%s: expected %#v, got %#v", test.test, test.expectedList, list)
+		}
+	}
+}
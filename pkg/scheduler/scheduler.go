@@ -25,3 +25,18 @@ import (
 type Scheduler interface {
 	Schedule(api.Pod, MinionLister) (selectedMachine string, err error)
 }
+
+// Preemptor is an interface implemented by things that know how to make room for pods
+// that fit on no machine by evicting pods with a lower priority.
+type Preemptor interface {
+	// Preempt returns the machine to make room on and the pods to evict from it. The
+	// machine is empty if evicting pods does not make the pod fit anywhere.
+	Preempt(api.Pod, MinionLister) (selectedMachine string, victims []api.Pod, err error)
+}
+
+// GroupScheduler is an interface implemented by things that know how to schedule a group
+// of pods onto machines together.
+type GroupScheduler interface {
+	// ScheduleGroup returns the machine of each pod, or an error if any of them does not fit.
+	ScheduleGroup([]api.Pod, MinionLister) (selectedMachines []string, err error)
+}
@@ -62,6 +62,56 @@ func CalculateSpreadPriority(pod api.Pod, podLister PodLister, minionLister Mini
 	return result, nil
 }
 
+// NewLabelSpreadPriority returns a priority function that spreads pods with the same
+// labels across the values of the minion label 'label', e.g. a zone. Minions in the
+// groups hosting fewer of these pods score higher. Minions without the label get
+// the lowest score.
+func NewLabelSpreadPriority(label string) PriorityFunction {
+	return func(pod api.Pod, podLister PodLister, minionLister MinionLister) (HostPriorityList, error) {
+		pods, err := podLister.ListPods(labels.SelectorFromSet(pod.Labels))
+		if err != nil {
+			return nil, err
+		}
+		minions, err := minionLister.List()
+		if err != nil {
+			return nil, err
+		}
+
+		// Value of the label of each minion that has it.
+		values := map[string]string{}
+		for _, minion := range minions.Items {
+			if value, ok := minion.Labels[label]; ok {
+				values[minion.Name] = value
+			}
+		}
+		numPods := 0
+		counts := map[string]int{}
+		for _, pod := range pods {
+			value, ok := values[pod.Status.Host]
+			if !ok {
+				continue
+			}
+			counts[value]++
+			numPods++
+		}
+
+		result := []HostPriority{}
+		for _, minion := range minions.Items {
+			value, ok := values[minion.Name]
+			if !ok {
+				result = append(result, HostPriority{host: minion.Name, score: 0})
+				continue
+			}
+			var fScore float32 = 10.0
+			if numPods > 0 {
+				fScore = 10 * (float32(numPods-counts[value]) / float32(numPods))
+			}
+			result = append(result, HostPriority{host: minion.Name, score: int(fScore)})
+		}
+		return result, nil
+	}
+}
+
 func NewSpreadingScheduler(podLister PodLister, minionLister MinionLister, predicates []FitPredicate, random *rand.Rand) Scheduler {
-	return NewGenericScheduler(predicates, []PriorityConfig{{Function: CalculateSpreadPriority, Weight: 1}}, podLister, random)
+	return NewGenericScheduler(predicates, []PriorityConfig{{Function: CalculateSpreadPriority, Weight: 1}}, nil, podLister, random)
 }
@@ -109,3 +109,56 @@ func TestSpreadPriority(t *testing.T) {
 		}
 	}
 }
+
+func TestLabelSpreadPriority(t *testing.T) {
+	labels1 := map[string]string{"foo": "bar"}
+	zone := func(name string) map[string]string {
+		return map[string]string{"zone": name}
+	}
+	nodes := []api.Node{
+		{ObjectMeta: api.ObjectMeta{Name: "machine1", Labels: zone("east")}},
+		{ObjectMeta: api.ObjectMeta{Name: "machine2", Labels: zone("east")}},
+		{ObjectMeta: api.ObjectMeta{Name: "machine3", Labels: zone("west")}},
+		{ObjectMeta: api.ObjectMeta{Name: "machine4"}},
+	}
+	onHost := func(host string, labels map[string]string) api.Pod {
+		return api.Pod{ObjectMeta: api.ObjectMeta{Labels: labels}, Status: api.PodStatus{Host: host}}
+	}
+	tests := []struct {
+		pods         []api.Pod
+		expectedList HostPriorityList
+		test         string
+	}{
+		{
+			expectedList: []HostPriority{{"machine1", 10}, {"machine2", 10}, {"machine3", 10}, {"machine4", 0}},
+			test:         "nothing scheduled",
+		},
+		{
+			pods:         []api.Pod{onHost("machine1", labels1)},
+			expectedList: []HostPriority{{"machine1", 0}, {"machine2", 0}, {"machine3", 10}, {"machine4", 0}},
+			test:         "one pod in the east zone",
+		},
+		{
+			pods: []api.Pod{
+				onHost("machine1", labels1),
+				onHost("machine2", labels1),
+				onHost("machine3", labels1),
+				onHost("machine3", map[string]string{"bar": "foo"}),
+				onHost("machine4", labels1),
+			},
+			expectedList: []HostPriority{{"machine1", 3}, {"machine2", 3}, {"machine3", 6}, {"machine4", 0}},
+			test:         "two pods in the east zone and one in the west zone",
+		},
+	}
+	for _, test := range tests {
+		prioritizer := NewLabelSpreadPriority("zone")
+		pod := api.Pod{ObjectMeta: api.ObjectMeta{Labels: labels1}}
+		list, err := prioritizer(pod, FakePodLister(test.pods), FakeMinionLister(api.NodeList{Items: nodes}))
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(test.expectedList, list) {
+			t.Errorf("%s: expected %#v, got %#v", test.test, test.expectedList, list)
+		}
+	}
+}
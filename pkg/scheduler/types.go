@@ -20,8 +20,41 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 )
 
-// FitPredicate is a function that indicates if a pod fits into an existing node.
-type FitPredicate func(pod api.Pod, existingPods []api.Pod, node string) (bool, error)
+// FitPredicate is a function that indicates if a pod fits into an existing node. 'existingPods'
+// are the pods on the node, and 'cluster' the rest of the cluster as seen by the scheduling pass,
+// for the predicates that depend on more than the node.
+type FitPredicate func(pod api.Pod, existingPods []api.Pod, node string, cluster *ClusterSnapshot) (bool, error)
+
+// ClusterSnapshot is the state of the cluster seen by a scheduling pass. It is built once per
+// pass rather than by each predicate for each node.
+type ClusterSnapshot struct {
+	// Pods keyed by the minion they run on. The pods of the node checked by a predicate are
+	// its 'existingPods' instead, e.g. without the pods preemption would evict.
+	MachineToPods map[string][]api.Pod
+	// Minions keyed by name.
+	Minions map[string]*api.Node
+	// Whether any pod has required anti-affinity terms.
+	requiredAntiAffinity bool
+}
+
+// NewClusterSnapshot indexes the pods and minions of a scheduling pass.
+func NewClusterSnapshot(machineToPods map[string][]api.Pod, minions []api.Node) *ClusterSnapshot {
+	cluster := &ClusterSnapshot{
+		MachineToPods: machineToPods,
+		Minions:       make(map[string]*api.Node, len(minions)),
+	}
+	for i := range minions {
+		cluster.Minions[minions[i].Name] = &minions[i]
+	}
+	for _, pods := range machineToPods {
+		for i := range pods {
+			if hasRequiredAntiAffinity(&pods[i]) {
+				cluster.requiredAntiAffinity = true
+			}
+		}
+	}
+	return cluster
+}
 
 // HostPriority represents the priority of scheduling to a particular host, lower priority is better.
 type HostPriority struct {
@@ -13,22 +13,22 @@
 // limitations under the License.
 
 // Interface to periodically retrieve stats from all nodes in a cluster and provide
-// aggregated summary. Current implementation report node-level stats.
-// TODO(jnagal): Extend to report pod and container level stats summary.
+// aggregated summary. Reports node, pod and container level stats. Hour and day
+// usage are derived from a bounded history of minute samples and hourly rollups kept
+// for each of them, which is optionally checkpointed to a local file to survive restarts.
 
 package statscollector
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/golang/glog"
 )
 
-// We poll each node for stats every minute.
-const pollInterval = 1 * time.Minute
-
 type Aggregator interface {
 	// Start polling.
 	Start() error
@@ -39,26 +39,102 @@ type Aggregator interface {
 	// Get usage stats summary for the whole node.
 	// Returns a map with hostname as key and NodeData as value.
 	GetNodeStats() (map[string]NodeData, error)
+
+	// Get usage stats summary for all pods.
+	// Returns a map with "namespace/pod" as key and PodData as value.
+	GetPodStats() (map[string]PodData, error)
+
+	// Get usage stats summary for all containers.
+	// Returns a map with "namespace/pod/container" as key and ContainerData as value.
+	GetContainerStats() (map[string]ContainerData, error)
+
+	// Get the failed polls of each node and the poll latencies.
+	GetPollStats() (PollStats, error)
 }
 
 type aggregator struct {
-	dataLock         sync.RWMutex
-	nodeApi          NodeApi
-	clusterApi       Cluster
-	nodes            map[string]NodeData
+	dataLock   sync.RWMutex
+	nodeApi    NodeApi
+	clusterApi Cluster
+	nodes      map[string]NodeData
+	// Usage history retained for each node, keyed by hostname.
+	history map[string]*usageHistory
+	// Pod stats are derived from the stats of their containers.
+	pods       map[string]PodData
+	containers map[string]ContainerData
+	// Usage history retained for each container, keyed by "namespace/pod/container".
+	containerHistory map[string]*usageHistory
+	// Failed polls of each node, keyed by hostname. Dropped along with the node.
+	pollFailures map[string]PollFailures
+	// Time taken to poll each node, and each round of polls.
+	nodeLatency  LatencyHistogram
+	roundLatency LatencyHistogram
+	pollInterval time.Duration
+	// Time allowed for each node to respond.
+	pollTimeout time.Duration
+	// Maximum number of nodes polled concurrently.
+	pollWorkers int
+	// Upper bound of the random offset from the start of a round at which each node is polled.
+	pollJitter time.Duration
+	// Percentiles reported in addition to mean, max and 90th percentile.
+	percentiles []float64
+	// File the history is checkpointed to. Checkpointing is disabled if empty.
+	checkpointFile     string
+	checkpointInterval time.Duration
+	// Checkpoints older than this are discarded on startup.
+	checkpointMaxAge time.Duration
+	clock            util.Clock
 	housekeepingChan chan error
 }
 
-// Create a new aggregator.
-func New(node NodeApi, cluster Cluster) (Aggregator, error) {
+// Create a new aggregator that polls each node for stats every 'pollInterval'.
+// Up to 'pollWorkers' nodes are polled concurrently, and each node is given 'pollTimeout' to respond.
+// Usage is reported as mean, max, 90th percentile and the additional 'percentiles'.
+// If 'checkpointFile' is set, the history is written to it every 'checkpointInterval'
+// and restored from it on Start unless the checkpoint is older than 'checkpointMaxAge'.
+func New(node NodeApi, cluster Cluster, pollInterval, pollTimeout time.Duration, pollWorkers int, percentiles []float64, checkpointFile string, checkpointInterval, checkpointMaxAge time.Duration) (Aggregator, error) {
 	if node == nil || cluster == nil {
 		return nil, fmt.Errorf("nil node or cluster driver.")
 	}
+	if pollInterval <= 0 || pollInterval > hourWindow {
+		return nil, fmt.Errorf("invalid poll interval %v", pollInterval)
+	}
+	if pollTimeout <= 0 || pollTimeout > pollInterval {
+		return nil, fmt.Errorf("invalid poll timeout %v, must be positive and at most the poll interval", pollTimeout)
+	}
+	if pollWorkers <= 0 {
+		return nil, fmt.Errorf("invalid number of poll workers %d", pollWorkers)
+	}
+	for _, p := range percentiles {
+		if p <= 0 || p >= 100 {
+			return nil, fmt.Errorf("invalid percentile %v", p)
+		}
+	}
+	if checkpointFile != "" && checkpointInterval <= 0 {
+		return nil, fmt.Errorf("invalid checkpoint interval %v", checkpointInterval)
+	}
 
 	newAggregator := &aggregator{
-		nodes:      make(map[string]NodeData, 0),
-		nodeApi:    node,
-		clusterApi: cluster,
+		nodes:              make(map[string]NodeData, 0),
+		history:            make(map[string]*usageHistory, 0),
+		pods:               make(map[string]PodData, 0),
+		containers:         make(map[string]ContainerData, 0),
+		containerHistory:   make(map[string]*usageHistory, 0),
+		pollFailures:       make(map[string]PollFailures, 0),
+		nodeLatency:        newLatencyHistogram(pollLatencyBounds),
+		roundLatency:       newLatencyHistogram(pollLatencyBounds),
+		nodeApi:            node,
+		clusterApi:         cluster,
+		pollInterval:       pollInterval,
+		pollTimeout:        pollTimeout,
+		pollWorkers:        pollWorkers,
+		percentiles:        percentiles,
+		checkpointFile:     checkpointFile,
+		checkpointInterval: checkpointInterval,
+		checkpointMaxAge:   checkpointMaxAge,
+		// Leave the remainder of the interval for the slowest node to respond.
+		pollJitter: pollInterval - pollTimeout,
+		clock:      util.RealClock{},
 	}
 
 	return newAggregator, nil
@@ -66,6 +142,12 @@ func New(node NodeApi, cluster Cluster) (Aggregator, error) {
 
 func (self *aggregator) Start() error {
 	self.housekeepingChan = make(chan error)
+	if self.checkpointFile != "" {
+		// A bad checkpoint only costs the history, start without it.
+		if err := self.restoreCheckpoint(); err != nil {
+			glog.Errorf("Failed to restore checkpoint: %s", err)
+		}
+	}
 	// process first update now.
 	self.doUpdate()
 	go self.periodicHousekeeping(self.housekeepingChan)
@@ -79,6 +161,9 @@ func (self *aggregator) Stop() error {
 	if err != nil {
 		return err
 	}
+	if self.checkpointFile != "" {
+		return self.writeCheckpoint()
+	}
 	return nil
 }
 
@@ -97,11 +182,20 @@ func (self *aggregator) doUpdate() {
 }
 
 func (self *aggregator) periodicHousekeeping(quit chan error) {
-	ticker := time.Tick(pollInterval)
+	ticker := time.Tick(self.pollInterval)
+	// Never fires if checkpointing is disabled.
+	var checkpointTicker <-chan time.Time
+	if self.checkpointFile != "" {
+		checkpointTicker = time.Tick(self.checkpointInterval)
+	}
 	for {
 		select {
 		case <-ticker:
 			self.doUpdate()
+		case <-checkpointTicker:
+			if err := self.writeCheckpoint(); err != nil {
+				glog.Errorf("Failed to checkpoint history: %s", err)
+			}
 		case <-quit:
 			quit <- nil
 			glog.Infof("Exiting housekeeping")
@@ -118,70 +212,294 @@ func (self *aggregator) detectNodes() error {
 	self.dataLock.Lock()
 	defer self.dataLock.Unlock()
 	for _, node := range nodes {
-		_, ok := self.nodes[node.Name]
+		data, ok := self.nodes[node.Name]
 		if !ok {
 			self.nodes[node.Name] = NodeData{
 				Id: node,
 			}
+			self.history[node.Name] = newUsageHistory(self.pollInterval)
+			continue
 		}
+		// Pick up label changes.
+		data.Id.Labels = node.Labels
+		self.nodes[node.Name] = data
 	}
 	return nil
 }
 
-func (self *aggregator) fixCpuUsage(capacity Capacity, resource Resource) Resource {
+func capPercentiles(p Percentiles, limit uint64) Percentiles {
+	if p.Mean > limit {
+		p.Mean = limit
+	}
+	if p.Max > limit {
+		p.Max = limit
+	}
+	if p.Ninety > limit {
+		p.Ninety = limit
+	}
+	if len(p.Values) > 0 {
+		values := make(map[string]uint64, len(p.Values))
+		for name, value := range p.Values {
+			if value > limit {
+				value = limit
+			}
+			values[name] = value
+		}
+		p.Values = values
+	}
+	return p
+}
+
+func (self *aggregator) fixCpuUsage(capacity Capacity, stats *DerivedStats) {
 	// Due to the time difference between recording a timestamp and cpu usage,
 	// cpu rate can go over machine capacity by a fraction. Ceil them off here.
-	if resource.Cpu.Mean > capacity.Cpu {
-		resource.Cpu.Mean = capacity.Cpu
+	if capacity.Cpu == 0 {
+		// Capacity is not known yet.
+		return
 	}
-	if resource.Cpu.Max > capacity.Cpu {
-		resource.Cpu.Max = capacity.Cpu
+	stats.MinuteUsage.Cpu = capPercentiles(stats.MinuteUsage.Cpu, capacity.Cpu)
+	stats.HourUsage.Cpu = capPercentiles(stats.HourUsage.Cpu, capacity.Cpu)
+	stats.DayUsage.Cpu = capPercentiles(stats.DayUsage.Cpu, capacity.Cpu)
+}
+
+// Summarize a distribution into the configured percentiles.
+func (self *aggregator) summarize(usage UsageSketch, valid bool) Resource {
+	if !valid {
+		return Resource{Valid: false}
+	}
+	return Resource{
+		Valid:  true,
+		Cpu:    usage.Cpu.Percentiles(self.percentiles),
+		Memory: usage.Memory.Percentiles(self.percentiles),
+	}
+}
+
+// Derive hour and day usage from the retained history.
+func (self *aggregator) deriveUsage(stats *DerivedStats, history *usageHistory, now time.Time) {
+	history.expire(now)
+	stats.HourUsage = self.summarize(history.getHourUsage(now, self.pollInterval))
+	stats.DayUsage = self.summarize(history.getDayUsage(now, self.pollInterval))
+}
+
+// Record a new minute sample and update the derived stats.
+func (self *aggregator) recordUsage(stats *DerivedStats, history *usageHistory, now time.Time, usage UsageSketch) {
+	stats.MinuteUsage = self.summarize(usage, true)
+	stats.LastUpdate = now
+	history.add(now, usage)
+	self.deriveUsage(stats, history, now)
+}
+
+// Update usage for all containers running on the node.
+func (self *aggregator) updateContainerStats(id NodeId, now time.Time, usage map[ContainerId]UsageSketch) {
+	for containerId, sketch := range usage {
+		key := containerId.Key()
+		history, ok := self.containerHistory[key]
+		if !ok {
+			history = newUsageHistory(self.pollInterval)
+			self.containerHistory[key] = history
+		}
+		container := self.containers[key]
+		container.Id = containerId
+		container.Node = id.Name
+		self.recordUsage(&container.Stats, history, now, sketch)
+		self.containers[key] = container
 	}
-	if resource.Cpu.Ninety > capacity.Cpu {
-		resource.Cpu.Ninety = capacity.Cpu
+}
+
+// Mark containers that were not updated in this round as stale and drop the
+// ones that have not been updated in the past hour.
+func (self *aggregator) expireContainerStats(now time.Time) {
+	for key, container := range self.containers {
+		if container.Stats.LastUpdate.Equal(now) {
+			continue
+		}
+		if now.Sub(container.Stats.LastUpdate) > hourWindow {
+			delete(self.containers, key)
+			delete(self.containerHistory, key)
+			continue
+		}
+		container.Stats.MinuteUsage.Valid = false
+		self.deriveUsage(&container.Stats, self.containerHistory[key], now)
+		self.containers[key] = container
 	}
-	return resource
+}
+
+// Sum two resources. The sum is only valid if both are valid.
+func addResources(a, b Resource) Resource {
+	if !a.Valid || !b.Valid {
+		return Resource{Valid: false}
+	}
+	return Resource{
+		Valid:  true,
+		Cpu:    AddPercentiles(a.Cpu, b.Cpu),
+		Memory: AddPercentiles(a.Memory, b.Memory),
+	}
+}
+
+// Rebuild pod stats from the stats of their containers. A pod window is only
+// valid if the window is valid for all of its containers.
+func (self *aggregator) updatePodStats() {
+	pods := make(map[string]PodData, len(self.pods))
+	for _, container := range self.containers {
+		key := container.Id.PodKey()
+		pod, ok := pods[key]
+		if !ok {
+			pod = PodData{
+				Namespace: container.Id.Namespace,
+				Name:      container.Id.Pod,
+				Stats: DerivedStats{
+					MinuteUsage: Resource{Valid: true},
+					HourUsage:   Resource{Valid: true},
+					DayUsage:    Resource{Valid: true},
+				},
+			}
+		}
+		pod.Containers = append(pod.Containers, container.Id.Container)
+		if !container.Stats.LastUpdate.Before(pod.Stats.LastUpdate) {
+			pod.Node = container.Node
+			pod.Stats.LastUpdate = container.Stats.LastUpdate
+		}
+		pod.Stats.MinuteUsage = addResources(pod.Stats.MinuteUsage, container.Stats.MinuteUsage)
+		pod.Stats.HourUsage = addResources(pod.Stats.HourUsage, container.Stats.HourUsage)
+		pod.Stats.DayUsage = addResources(pod.Stats.DayUsage, container.Stats.DayUsage)
+		pods[key] = pod
+	}
+	for _, pod := range pods {
+		sort.Strings(pod.Containers)
+	}
+	self.pods = pods
 }
 
 func (self *aggregator) updateStats() error {
-	// TODO(jnagal): Don't hold lock while making client calls.
+	now := self.clock.Now()
+	self.dataLock.RLock()
+	requests := make([]pollRequest, 0, len(self.nodes))
+	for _, node := range self.nodes {
+		requests = append(requests, pollRequest{
+			id: node.Id,
+			// Update Capacity before usage.
+			needsCapacity: node.Capacity.Cpu == 0,
+		})
+	}
+	self.dataLock.RUnlock()
+
+	start := time.Now()
+	results := self.pollNodes(requests)
+	latency := time.Since(start)
+
 	self.dataLock.Lock()
 	defer self.dataLock.Unlock()
-	for _, node := range self.nodes {
-		// Update Capacity before usage.
-		if node.Capacity.Cpu == 0 {
-			glog.Infof("updating capacity for node %s", node.Id.Name)
-			capacity, err := self.nodeApi.MachineSpec(node.Id)
-			if err != nil {
-				glog.Errorf("Failed to update capacity for node %s", node.Id.Name)
-			} else {
-				node.Capacity = capacity
-			}
-		}
-		resource, err := self.nodeApi.UpdateStats(node.Id)
-		if err != nil {
-			glog.Errorf("Failed to update stats for node %s", node.Id.Name)
-			// Mark old data as stale.
-			node.Stats.MinuteUsage.Valid = false
-			// Drop nodes that have not been updated in the past hour.
-			if time.Since(node.Stats.LastUpdate).Hours() > 1 {
-				glog.Errorf("Node %s presumed dead", node.Id.Name)
-				delete(self.nodes, node.Id.Name)
-			}
-			continue
+	self.roundLatency.observe(latency)
+	for _, result := range results {
+		self.mergeResult(result, now)
+	}
+	self.expireContainerStats(now)
+	self.updatePodStats()
+	return nil
+}
+
+// Merge the result of polling a node. Must be called with dataLock held.
+func (self *aggregator) mergeResult(result pollResult, now time.Time) {
+	node, ok := self.nodes[result.id.Name]
+	if !ok {
+		// Node was removed while it was being polled.
+		return
+	}
+	self.nodeLatency.observe(result.latency)
+	self.countFailures(result)
+	history := self.history[node.Id.Name]
+	if result.capacity != nil {
+		glog.Infof("updated capacity for node %s", node.Id.Name)
+		node.Capacity = *result.capacity
+	}
+	if result.err != nil {
+		glog.Errorf("Failed to update stats for node %s: %s", node.Id.Name, result.err)
+		// Mark old data as stale.
+		node.Stats.MinuteUsage.Valid = false
+		// Drop nodes that have not been updated in the past hour.
+		if now.Sub(node.Stats.LastUpdate) > hourWindow {
+			glog.Errorf("Node %s presumed dead", node.Id.Name)
+			delete(self.nodes, node.Id.Name)
+			delete(self.history, node.Id.Name)
+			delete(self.pollFailures, node.Id.Name)
+			return
 		}
-		node.Stats.MinuteUsage = self.fixCpuUsage(node.Capacity, resource)
-		// TODO: Calculate hour/day stats by storing minute stats.
-		node.Stats.HourUsage.Valid = false
-		node.Stats.DayUsage.Valid = false
-		node.Stats.LastUpdate = time.Now()
+		// Windows keep reporting the retained samples while the node is unreachable.
+		self.deriveUsage(&node.Stats, history, now)
+		self.fixCpuUsage(node.Capacity, &node.Stats)
 		self.nodes[node.Id.Name] = node
+		return
 	}
-	return nil
+	self.recordUsage(&node.Stats, history, now, result.usage)
+	self.fixCpuUsage(node.Capacity, &node.Stats)
+	self.nodes[node.Id.Name] = node
+	if result.containerErr != nil {
+		glog.Errorf("Failed to update container stats for node %s: %s", node.Id.Name, result.containerErr)
+		return
+	}
+	self.updateContainerStats(node.Id, now, result.containers)
+}
+
+// Count the failed requests of a poll. Must be called with dataLock held.
+func (self *aggregator) countFailures(result pollResult) {
+	failures := self.pollFailures[result.id.Name]
+	if result.capacityErr != nil {
+		failures.Capacity++
+	}
+	if result.err != nil {
+		failures.Usage++
+	}
+	if result.containerErr != nil {
+		failures.Containers++
+	}
+	self.pollFailures[result.id.Name] = failures
 }
 
+// Returns a copy of the node stats that is safe to use without holding any locks.
 func (self *aggregator) GetNodeStats() (map[string]NodeData, error) {
 	self.dataLock.RLock()
 	defer self.dataLock.RUnlock()
-	return self.nodes, nil
+	nodes := make(map[string]NodeData, len(self.nodes))
+	for name, node := range self.nodes {
+		nodes[name] = node
+	}
+	return nodes, nil
+}
+
+// Returns a copy of the pod stats that is safe to use without holding any locks.
+func (self *aggregator) GetPodStats() (map[string]PodData, error) {
+	self.dataLock.RLock()
+	defer self.dataLock.RUnlock()
+	pods := make(map[string]PodData, len(self.pods))
+	for key, pod := range self.pods {
+		pod.Containers = append([]string(nil), pod.Containers...)
+		pods[key] = pod
+	}
+	return pods, nil
+}
+
+// Returns a copy of the container stats that is safe to use without holding any locks.
+func (self *aggregator) GetContainerStats() (map[string]ContainerData, error) {
+	self.dataLock.RLock()
+	defer self.dataLock.RUnlock()
+	containers := make(map[string]ContainerData, len(self.containers))
+	for key, container := range self.containers {
+		containers[key] = container
+	}
+	return containers, nil
+}
+
+// Returns a copy of the poll stats that is safe to use without holding any locks.
+func (self *aggregator) GetPollStats() (PollStats, error) {
+	self.dataLock.RLock()
+	defer self.dataLock.RUnlock()
+	failures := make(map[string]PollFailures, len(self.pollFailures))
+	for name, nodeFailures := range self.pollFailures {
+		failures[name] = nodeFailures
+	}
+	return PollStats{
+		Failures:     failures,
+		NodeLatency:  self.nodeLatency.copy(),
+		RoundLatency: self.roundLatency.copy(),
+	}, nil
 }
@@ -0,0 +1,410 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statscollector
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+const testNode = "minion-0"
+
+func newTestAggregator(t *testing.T) (*aggregator, *fakeNodeApi, *util.FakeClock) {
+	return newTestAggregatorWithNodes(t, 1)
+}
+
+func newTestAggregatorWithNodes(t *testing.T, numNodes int) (*aggregator, *fakeNodeApi, *util.FakeClock) {
+	cluster, err := NewFakeCluster(numNodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeApi, err := NewFakeNodeApi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(nodeApi, cluster, time.Minute, 100*time.Millisecond, 4, []float64{50, 95, 99}, "", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &util.FakeClock{Time: time.Date(2014, 10, 1, 0, 0, 0, 0, time.UTC)}
+	agg := a.(*aggregator)
+	agg.clock = clock
+	agg.pollJitter = 0
+	return agg, nodeApi.(*fakeNodeApi), clock
+}
+
+// Poll 'count' times, advancing the clock by a minute before each poll.
+func poll(agg *aggregator, clock *util.FakeClock, count int) {
+	for i := 0; i < count; i++ {
+		clock.Time = clock.Time.Add(time.Minute)
+		agg.doUpdate()
+	}
+}
+
+// Samples reported on each poll. Low usage has a mean of 100, a max of 400 and
+// a 90th percentile of 150, high usage is three times as much.
+var (
+	lowUsage  = fakeUsage{cpu: []uint64{50, 50, 50, 50, 50, 50, 50, 100, 150, 400}}
+	highUsage = fakeUsage{cpu: []uint64{150, 150, 150, 150, 150, 150, 150, 300, 450, 1200}}
+)
+
+// Check the cpu usage of 'usage'. The mean and max are exact, the 90th
+// percentile is within the sketch accuracy.
+func checkCpuUsage(t *testing.T, name string, usage Resource, mean, max, ninety uint64) {
+	if !usage.Valid {
+		t.Errorf("%s should be valid", name)
+		return
+	}
+	if usage.Cpu.Mean != mean || usage.Cpu.Max != max || !approxEqual(usage.Cpu.Ninety, ninety) {
+		t.Errorf("%s cpu is %+v, expected mean %d, max %d and 90th percentile %d", name, usage.Cpu, mean, max, ninety)
+	}
+}
+
+func getNode(t *testing.T, agg *aggregator) NodeData {
+	nodes, err := agg.GetNodeStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, ok := nodes[testNode]
+	if !ok {
+		t.Fatalf("node %s not found in %+v", testNode, nodes)
+	}
+	return node
+}
+
+func TestHourUsageRollsOver(t *testing.T) {
+	agg, nodeApi, clock := newTestAggregator(t)
+	nodeApi.usage = lowUsage
+
+	poll(agg, clock, 59)
+	node := getNode(t, agg)
+	if !node.Stats.MinuteUsage.Valid {
+		t.Errorf("minute usage should be valid")
+	}
+	if node.Stats.HourUsage.Valid {
+		t.Errorf("hour usage should not be valid before an hour of samples: %+v", node.Stats.HourUsage)
+	}
+
+	poll(agg, clock, 1)
+	node = getNode(t, agg)
+	checkCpuUsage(t, "hour usage", node.Stats.HourUsage, 100, 400, 150)
+	if len(node.Stats.HourUsage.Cpu.Values) != 3 {
+		t.Errorf("expected the configured percentiles, got %+v", node.Stats.HourUsage.Cpu.Values)
+	}
+
+	// Half the hour at a higher usage.
+	nodeApi.usage = highUsage
+	poll(agg, clock, 30)
+	node = getNode(t, agg)
+	checkCpuUsage(t, "hour usage", node.Stats.HourUsage, 200, 1200, 400)
+
+	// Older samples fall out of the window.
+	poll(agg, clock, 30)
+	node = getNode(t, agg)
+	checkCpuUsage(t, "hour usage", node.Stats.HourUsage, 300, 1200, 450)
+	if node.Stats.DayUsage.Valid {
+		t.Errorf("day usage should not be valid before a day of samples: %+v", node.Stats.DayUsage)
+	}
+}
+
+func TestDayUsageRollsOver(t *testing.T) {
+	agg, nodeApi, clock := newTestAggregator(t)
+	nodeApi.usage = lowUsage
+	poll(agg, clock, 23*60)
+	nodeApi.usage = highUsage
+	poll(agg, clock, 60)
+
+	node := getNode(t, agg)
+	if !node.Stats.DayUsage.Valid {
+		t.Fatalf("day usage should be valid after a day of samples")
+	}
+	if node.Stats.DayUsage.Cpu.Max != 1200 {
+		t.Errorf("day cpu max is %d, expected 1200", node.Stats.DayUsage.Cpu.Max)
+	}
+	// The day is dominated by the low usage samples.
+	if !approxEqual(node.Stats.DayUsage.Cpu.Ninety, 400) {
+		t.Errorf("day cpu 90p is %d, expected 400", node.Stats.DayUsage.Cpu.Ninety)
+	}
+
+	// A day later only the high usage samples are left.
+	poll(agg, clock, 23*60)
+	node = getNode(t, agg)
+	checkCpuUsage(t, "day usage", node.Stats.DayUsage, 300, 1200, 450)
+	history := agg.history[testNode]
+	if history.minutes.count != history.minutes.size || history.hours.count != history.hours.size {
+		t.Errorf("expected full history buffers, got %d of %d samples and %d of %d rollups", history.minutes.count, history.minutes.size, history.hours.count, history.hours.size)
+	}
+}
+
+func TestUsageToleratesOutages(t *testing.T) {
+	agg, nodeApi, clock := newTestAggregator(t)
+	nodeApi.usage = lowUsage
+	poll(agg, clock, 60)
+
+	nodeApi.unreachable[testNode] = true
+	poll(agg, clock, 10)
+	node := getNode(t, agg)
+	if node.Stats.MinuteUsage.Valid {
+		t.Errorf("minute usage should be stale during an outage")
+	}
+	checkCpuUsage(t, "hour usage", node.Stats.HourUsage, 100, 400, 150)
+
+	// Recover with a higher usage. The gap is skipped when computing the hour window.
+	delete(nodeApi.unreachable, testNode)
+	nodeApi.usage = highUsage
+	poll(agg, clock, 50)
+	node = getNode(t, agg)
+	checkCpuUsage(t, "hour usage", node.Stats.HourUsage, 300, 1200, 450)
+
+	// Nodes unreachable for over an hour are dropped along with their history.
+	nodeApi.unreachable[testNode] = true
+	poll(agg, clock, 61)
+	nodes, err := agg.GetNodeStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := nodes[testNode]; ok {
+		t.Errorf("node %s should have been dropped", testNode)
+	}
+	delete(nodeApi.unreachable, testNode)
+	poll(agg, clock, 1)
+	node = getNode(t, agg)
+	if node.Stats.HourUsage.Valid {
+		t.Errorf("hour usage should be invalid after the node is re-added: %+v", node.Stats.HourUsage)
+	}
+}
+
+func TestPodAndContainerStats(t *testing.T) {
+	agg, nodeApi, clock := newTestAggregator(t)
+	nodeApi.containerUsage = lowUsage
+	poll(agg, clock, 60)
+
+	containers, err := agg.GetContainerStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(containers) != 2 {
+		t.Fatalf("expected 2 containers, got %+v", containers)
+	}
+	container, ok := containers["default/pod-minion-0/container-1"]
+	if !ok {
+		t.Fatalf("container not found in %+v", containers)
+	}
+	if container.Node != testNode {
+		t.Errorf("container is on node %s, expected %s", container.Node, testNode)
+	}
+	checkCpuUsage(t, "container hour usage", container.Stats.HourUsage, 100, 400, 150)
+
+	pods, err := agg.GetPodStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod, ok := pods["default/pod-minion-0"]
+	if !ok {
+		t.Fatalf("pod not found in %+v", pods)
+	}
+	if len(pod.Containers) != 2 || pod.Containers[0] != "container-0" || pod.Containers[1] != "container-1" {
+		t.Errorf("unexpected pod containers %v", pod.Containers)
+	}
+	// Pod usage is the sum of the usage of its containers.
+	checkCpuUsage(t, "pod hour usage", pod.Stats.HourUsage, 200, 800, 300)
+
+	// Containers that are no longer reported go stale and are eventually dropped.
+	nodeApi.numContainers = 1
+	poll(agg, clock, 1)
+	container = agg.containers["default/pod-minion-0/container-1"]
+	if container.Stats.MinuteUsage.Valid || !container.Stats.HourUsage.Valid {
+		t.Errorf("expected stale minute usage and valid hour usage, got %+v", container.Stats)
+	}
+	poll(agg, clock, 60)
+	containers, err = agg.GetContainerStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := containers["default/pod-minion-0/container-1"]; ok {
+		t.Errorf("container should have been dropped: %+v", containers)
+	}
+	pod = agg.pods["default/pod-minion-0"]
+	if len(pod.Containers) != 1 || pod.Stats.MinuteUsage.Cpu.Mean != 100 {
+		t.Errorf("unexpected pod %+v", pod)
+	}
+}
+
+func TestSlowNodeDoesNotBlockPolling(t *testing.T) {
+	agg, nodeApi, clock := newTestAggregatorWithNodes(t, 10)
+	poll(agg, clock, 1)
+	nodeApi.delay[testNode] = 5 * time.Second
+
+	start := time.Now()
+	poll(agg, clock, 1)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("polling took %v, expected the slow node to time out", elapsed)
+	}
+	nodes, err := agg.GetNodeStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 10 {
+		t.Fatalf("expected 10 nodes, got %d", len(nodes))
+	}
+	for name, node := range nodes {
+		if name == testNode {
+			if node.Stats.MinuteUsage.Valid {
+				t.Errorf("slow node %s should not have valid usage", name)
+			}
+			continue
+		}
+		if !node.Stats.MinuteUsage.Valid {
+			t.Errorf("node %s should have valid usage", name)
+		}
+	}
+}
+
+func TestSlowContainersKeepNodeUsage(t *testing.T) {
+	agg, nodeApi, clock := newTestAggregator(t)
+	poll(agg, clock, 1)
+	nodeApi.containerDelay[testNode] = 5 * time.Second
+
+	start := time.Now()
+	poll(agg, clock, 1)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("polling took %v, expected the slow containers to time out", elapsed)
+	}
+	node := getNode(t, agg)
+	if !node.Stats.MinuteUsage.Valid || !node.Stats.LastUpdate.Equal(clock.Time) {
+		t.Errorf("node usage should have been updated: %+v", node.Stats)
+	}
+	failures := agg.pollFailures[testNode]
+	if failures.Usage != 0 || failures.Containers != 1 {
+		t.Errorf("expected only the containers to fail, got %+v", failures)
+	}
+}
+
+func TestPollJitterFitsInInterval(t *testing.T) {
+	agg, nodeApi, clock := newTestAggregatorWithNodes(t, 20)
+	agg.pollInterval = 400 * time.Millisecond
+	agg.pollTimeout = 50 * time.Millisecond
+	agg.pollJitter = agg.pollInterval - agg.pollTimeout
+	for i := 0; i < 20; i++ {
+		nodeApi.delay[fmt.Sprintf("minion-%d", i)] = 30 * time.Millisecond
+	}
+
+	start := time.Now()
+	poll(agg, clock, 1)
+	if elapsed := time.Since(start); elapsed > agg.pollInterval {
+		t.Errorf("polling took %v, longer than the interval %v", elapsed, agg.pollInterval)
+	}
+	nodes, err := agg.GetNodeStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, node := range nodes {
+		if !node.Stats.MinuteUsage.Valid {
+			t.Errorf("node %s should have valid usage", name)
+		}
+	}
+
+	// Each of the 4 workers polls 5 nodes, which may take 250ms.
+	offsets := agg.pollOffsets(20, 4)
+	for i, offset := range offsets {
+		if offset < 0 || offset >= 150*time.Millisecond || i > 0 && offset < offsets[i-1] {
+			t.Errorf("unexpected offsets %v", offsets)
+			break
+		}
+	}
+	// With the default flags, 200 nodes leave no room to spread the polls.
+	agg.pollInterval, agg.pollTimeout, agg.pollWorkers = time.Minute, 20*time.Second, 20
+	agg.pollJitter = agg.pollInterval - agg.pollTimeout
+	for _, offset := range agg.pollOffsets(200, 20) {
+		if offset != 0 {
+			t.Fatalf("expected no offsets, got %v", offset)
+		}
+	}
+}
+
+func TestReadersNotBlockedDuringPolling(t *testing.T) {
+	agg, nodeApi, clock := newTestAggregatorWithNodes(t, 2)
+	poll(agg, clock, 1)
+	nodeApi.delay[testNode] = 5 * time.Second
+
+	done := make(chan struct{})
+	go func() {
+		poll(agg, clock, 1)
+		close(done)
+	}()
+	// Give the poll a chance to start.
+	time.Sleep(20 * time.Millisecond)
+	start := time.Now()
+	if _, err := agg.GetNodeStats(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("reading stats took %v while polling", elapsed)
+	}
+	<-done
+}
+
+func TestGetStatsReturnsCopy(t *testing.T) {
+	agg, _, clock := newTestAggregator(t)
+	poll(agg, clock, 1)
+	nodes, err := agg.GetNodeStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(nodes, testNode)
+	pods, err := agg.GetPodStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pods["default/pod-minion-0"].Containers[0] = "modified"
+	getNode(t, agg)
+	if agg.pods["default/pod-minion-0"].Containers[0] != "container-0" {
+		t.Errorf("modifying the returned pod stats changed the aggregator state")
+	}
+}
+
+func TestPollStats(t *testing.T) {
+	agg, nodeApi, clock := newTestAggregatorWithNodes(t, 2)
+	poll(agg, clock, 2)
+	nodeApi.unreachable[testNode] = true
+	poll(agg, clock, 3)
+
+	stats, err := agg.GetPollStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failures := stats.Failures[testNode]; failures.Usage != 3 || failures.Containers != 3 || failures.Capacity != 0 {
+		t.Errorf("unexpected failures for %s: %+v", testNode, failures)
+	}
+	if failures := stats.Failures["minion-1"]; failures != (PollFailures{}) {
+		t.Errorf("unexpected failures for minion-1: %+v", failures)
+	}
+	if stats.NodeLatency.Count != 10 || stats.RoundLatency.Count != 5 {
+		t.Errorf("expected 10 node polls over 5 rounds, got %d and %d", stats.NodeLatency.Count, stats.RoundLatency.Count)
+	}
+
+	// Counters are dropped along with the node.
+	poll(agg, clock, 61)
+	stats, err = agg.GetPollStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stats.Failures[testNode]; ok {
+		t.Errorf("failures of dropped node %s should have been removed", testNode)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1 contains the versioned types served by the statscollector query API.
+// Clients should depend on these types rather than the internal statscollector types.
+package v1
+
+import (
+	"time"
+)
+
+const Version = "v1"
+
+// Usage windows.
+const (
+	WindowMinute = "minute"
+	WindowHour   = "hour"
+	WindowDay    = "day"
+)
+
+// Percentile names.
+const (
+	PercentileMean   = "mean"
+	PercentileMax    = "max"
+	PercentileNinety = "90"
+)
+
+// Percentiles maps a percentile name to its value.
+type Percentiles map[string]uint64
+
+type Capacity struct {
+	// Number of available cpus in milliCpus.
+	Cpu uint64 `json:"cpu"`
+	// Amount of memory in bytes.
+	Memory uint64 `json:"memory"`
+}
+
+type Usage struct {
+	// Set to false if there is not enough data for the window.
+	Valid bool `json:"valid"`
+	// Cpu rate in milliCpus/second.
+	Cpu Percentiles `json:"cpu,omitempty"`
+	// Memory working set in bytes.
+	Memory Percentiles `json:"memory,omitempty"`
+}
+
+type Node struct {
+	Name    string            `json:"name"`
+	Address string            `json:"address"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	// Capacity of the node.
+	Capacity Capacity `json:"capacity"`
+	// Time of the last successful stats update from the node.
+	LastUpdate time.Time `json:"last_update"`
+	// Usage keyed by window.
+	Usage map[string]Usage `json:"usage"`
+}
+
+type NodeList struct {
+	Version string `json:"version"`
+	Items   []Node `json:"items"`
+}
+
+type NodeResponse struct {
+	Version string `json:"version"`
+	Node    Node   `json:"node"`
+}
+
+type WindowSummary struct {
+	// Number of nodes with valid usage in the window.
+	NumNodes int `json:"num_nodes"`
+	// Capacity of the nodes with valid usage in the window.
+	Capacity Capacity `json:"capacity"`
+	// Sum of the usage of the nodes with valid usage in the window.
+	// Mean is exact, while the other percentiles are upper bounds.
+	Usage Usage `json:"usage"`
+}
+
+type ClusterSummary struct {
+	Version string `json:"version"`
+	// Number of nodes matching the request.
+	NumNodes int `json:"num_nodes"`
+	// Total capacity of the nodes matching the request.
+	Capacity Capacity `json:"capacity"`
+	// Summary keyed by window.
+	Windows map[string]WindowSummary `json:"windows"`
+}
+
+type Error struct {
+	Version string `json:"version"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,214 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Checkpoints the retained minute samples and hourly rollups to a local file so that
+// hour and day usage survive restarts. The checkpoint is a gzipped gob stream in which
+// each sample is stored as a pair of sketches rather than raw stats.
+
+package statscollector
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// Version of the checkpoint format. Checkpoints written with a different version are discarded.
+const checkpointVersion = 2
+
+type historyCheckpoint struct {
+	FirstSample time.Time
+	Minutes     []usageSample
+	Hours       []usageSample
+}
+
+type nodeCheckpoint struct {
+	Id         NodeId
+	Capacity   Capacity
+	LastUpdate time.Time
+	History    historyCheckpoint
+}
+
+type containerCheckpoint struct {
+	Id         ContainerId
+	Node       string
+	LastUpdate time.Time
+	History    historyCheckpoint
+}
+
+type checkpoint struct {
+	Version int
+	// Time at which the checkpoint was taken.
+	Timestamp  time.Time
+	Nodes      []nodeCheckpoint
+	Containers []containerCheckpoint
+}
+
+func newHistoryCheckpoint(history *usageHistory) historyCheckpoint {
+	if history == nil {
+		return historyCheckpoint{}
+	}
+	// Retained sketches are never modified, so they can be shared with the checkpoint.
+	return historyCheckpoint{
+		FirstSample: history.firstSample,
+		Minutes:     history.minutes.since(time.Time{}),
+		Hours:       history.hours.since(time.Time{}),
+	}
+}
+
+func (self *aggregator) restoreHistory(saved historyCheckpoint) *usageHistory {
+	history := newUsageHistory(self.pollInterval)
+	for _, sample := range saved.Minutes {
+		history.minutes.add(sample.Timestamp, sample.Usage)
+	}
+	for _, sample := range saved.Hours {
+		history.hours.add(sample.Timestamp, sample.Usage)
+	}
+	if history.hours.count > 0 {
+		history.firstSample = saved.FirstSample
+	}
+	return history
+}
+
+// Take a checkpoint of the retained history. Holds the read lock while copying.
+func (self *aggregator) getCheckpoint() *checkpoint {
+	self.dataLock.RLock()
+	defer self.dataLock.RUnlock()
+	result := &checkpoint{
+		Version:    checkpointVersion,
+		Timestamp:  self.clock.Now(),
+		Nodes:      make([]nodeCheckpoint, 0, len(self.nodes)),
+		Containers: make([]containerCheckpoint, 0, len(self.containers)),
+	}
+	for name, node := range self.nodes {
+		result.Nodes = append(result.Nodes, nodeCheckpoint{
+			Id:         node.Id,
+			Capacity:   node.Capacity,
+			LastUpdate: node.Stats.LastUpdate,
+			History:    newHistoryCheckpoint(self.history[name]),
+		})
+	}
+	for key, container := range self.containers {
+		result.Containers = append(result.Containers, containerCheckpoint{
+			Id:         container.Id,
+			Node:       container.Node,
+			LastUpdate: container.Stats.LastUpdate,
+			History:    newHistoryCheckpoint(self.containerHistory[key]),
+		})
+	}
+	return result
+}
+
+// Write a checkpoint to the checkpoint file. The file is replaced atomically.
+func (self *aggregator) writeCheckpoint() error {
+	data := self.getCheckpoint()
+	dir, name := filepath.Split(self.checkpointFile)
+	file, err := ioutil.TempFile(dir, name+".tmp")
+	if err != nil {
+		return err
+	}
+	// No-op once the file is renamed.
+	defer os.Remove(file.Name())
+	writer := gzip.NewWriter(file)
+	err = gob.NewEncoder(writer).Encode(data)
+	if err == nil {
+		err = writer.Close()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint %s: %s", file.Name(), err)
+	}
+	if err := os.Rename(file.Name(), self.checkpointFile); err != nil {
+		return err
+	}
+	glog.V(1).Infof("Checkpointed %d nodes and %d containers to %s", len(data.Nodes), len(data.Containers), self.checkpointFile)
+	return nil
+}
+
+func readCheckpoint(path string) (*checkpoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	data := &checkpoint{}
+	if err := gob.NewDecoder(reader).Decode(data); err != nil {
+		return nil, err
+	}
+	if data.Version != checkpointVersion {
+		return nil, fmt.Errorf("unsupported checkpoint version %d", data.Version)
+	}
+	return data, nil
+}
+
+// Restore the history from the checkpoint file, if there is a recent enough one.
+// The restored usage is derived as if the nodes had been unreachable since the checkpoint.
+func (self *aggregator) restoreCheckpoint() error {
+	data, err := readCheckpoint(self.checkpointFile)
+	if os.IsNotExist(err) {
+		glog.Infof("No checkpoint found at %s", self.checkpointFile)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint %s: %s", self.checkpointFile, err)
+	}
+	now := self.clock.Now()
+	if age := now.Sub(data.Timestamp); age > self.checkpointMaxAge {
+		glog.Infof("Discarding checkpoint %s taken %v ago", self.checkpointFile, age)
+		return nil
+	}
+
+	self.dataLock.Lock()
+	defer self.dataLock.Unlock()
+	for _, saved := range data.Nodes {
+		node := NodeData{
+			Id:       saved.Id,
+			Capacity: saved.Capacity,
+		}
+		node.Stats.LastUpdate = saved.LastUpdate
+		history := self.restoreHistory(saved.History)
+		self.deriveUsage(&node.Stats, history, now)
+		self.fixCpuUsage(node.Capacity, &node.Stats)
+		self.nodes[node.Id.Name] = node
+		self.history[node.Id.Name] = history
+	}
+	for _, saved := range data.Containers {
+		container := ContainerData{
+			Id:   saved.Id,
+			Node: saved.Node,
+		}
+		container.Stats.LastUpdate = saved.LastUpdate
+		history := self.restoreHistory(saved.History)
+		self.deriveUsage(&container.Stats, history, now)
+		key := container.Id.Key()
+		self.containers[key] = container
+		self.containerHistory[key] = history
+	}
+	self.updatePodStats()
+	glog.Infof("Restored %d nodes and %d containers from checkpoint %s taken at %v", len(data.Nodes), len(data.Containers), self.checkpointFile, data.Timestamp)
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statscollector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// Create an aggregator that checkpoints to a file in 'dir'.
+func newCheckpointingAggregator(t *testing.T, dir string) (*aggregator, *fakeNodeApi, *util.FakeClock) {
+	agg, nodeApi, clock := newTestAggregator(t)
+	agg.checkpointFile = filepath.Join(dir, "checkpoint")
+	agg.checkpointInterval = 5 * time.Minute
+	agg.checkpointMaxAge = time.Hour
+	return agg, nodeApi, clock
+}
+
+func TestCheckpointRestoresHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statscollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	agg, nodeApi, clock := newCheckpointingAggregator(t, dir)
+	nodeApi.usage = lowUsage
+	nodeApi.containerUsage = lowUsage
+	poll(agg, clock, 60)
+	if err := agg.writeCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, restoredNodeApi, restoredClock := newCheckpointingAggregator(t, dir)
+	restoredNodeApi.usage = lowUsage
+	restoredClock.Time = clock.Time.Add(5 * time.Minute)
+	if err := restored.restoreCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	node := getNode(t, restored)
+	if node.Stats.MinuteUsage.Valid {
+		t.Errorf("minute usage should not be valid until the node is polled")
+	}
+	if node.Capacity.Cpu != 8000 {
+		t.Errorf("capacity was not restored: %+v", node.Capacity)
+	}
+	checkCpuUsage(t, "restored hour usage", node.Stats.HourUsage, 100, 400, 150)
+	if _, ok := restored.containers["default/pod-minion-0/container-0"]; !ok {
+		t.Errorf("container history was not restored: %+v", restored.containers)
+	}
+	if _, ok := restored.pods["default/pod-minion-0"]; !ok {
+		t.Errorf("pod stats were not restored: %+v", restored.pods)
+	}
+
+	// Polling resumes on top of the restored history.
+	poll(restored, restoredClock, 1)
+	node = getNode(t, restored)
+	if !node.Stats.MinuteUsage.Valid {
+		t.Errorf("minute usage should be valid after polling")
+	}
+	checkCpuUsage(t, "hour usage", node.Stats.HourUsage, 100, 400, 150)
+}
+
+func TestStaleCheckpointDiscarded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statscollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	agg, _, clock := newCheckpointingAggregator(t, dir)
+	poll(agg, clock, 60)
+	if err := agg.writeCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, _, restoredClock := newCheckpointingAggregator(t, dir)
+	restoredClock.Time = clock.Time.Add(2 * time.Hour)
+	if err := restored.restoreCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if len(restored.nodes) != 0 {
+		t.Errorf("stale checkpoint should have been discarded, got %+v", restored.nodes)
+	}
+}
+
+func TestBadCheckpointIgnored(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statscollector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	agg, _, _ := newCheckpointingAggregator(t, dir)
+	// A missing checkpoint is not an error.
+	if err := agg.restoreCheckpoint(); err != nil {
+		t.Errorf("unexpected error for a missing checkpoint: %s", err)
+	}
+	if err := ioutil.WriteFile(agg.checkpointFile, []byte("not a checkpoint"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := agg.restoreCheckpoint(); err == nil {
+		t.Errorf("expected an error for a corrupt checkpoint")
+	}
+	if len(agg.nodes) != 0 {
+		t.Errorf("no nodes should be restored from a corrupt checkpoint, got %+v", agg.nodes)
+	}
+}
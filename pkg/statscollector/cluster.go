@@ -62,7 +62,7 @@ func (self *kubeCluster) GetNodesList() ([]NodeId, error) {
 		return self.nodesList, nil
 	}
 	nodesList := make([]NodeId, 0)
-	minions, err := self.client.Minions().List()
+	minions, err := self.client.Nodes().List()
 	if err != nil {
 		return nil, err
 	}
@@ -72,6 +72,7 @@ func (self *kubeCluster) GetNodesList() ([]NodeId, error) {
 			node := NodeId{
 				Name:    minion.Name,
 				Address: addrs[0].String(),
+				Labels:  minion.Labels,
 			}
 			nodesList = append(nodesList, node)
 		} else {
@@ -95,6 +96,10 @@ func NewFakeCluster(clusterSize int) (Cluster, error) {
 		node := NodeId{
 			Name:    name,
 			Address: host,
+			// Spread the fake nodes over two zones.
+			Labels: map[string]string{
+				"zone": "zone-" + strconv.Itoa(i%2),
+			},
 		}
 		nodes = append(nodes, node)
 	}
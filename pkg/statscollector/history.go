@@ -0,0 +1,207 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Bounded history of usage samples used to derive hour and day usage.
+
+package statscollector
+
+import (
+	"time"
+)
+
+const (
+	hourWindow = time.Hour
+	dayWindow  = 24 * time.Hour
+)
+
+type usageSample struct {
+	Timestamp time.Time
+	Usage     UsageSketch
+}
+
+// Ring buffer of usage samples, oldest first. Holds at most 'size' samples.
+// The buffer grows on demand so that short-lived containers do not pay for a full buffer.
+type sampleRing struct {
+	samples []usageSample
+	// Maximum number of samples retained.
+	size int
+	// Index of the oldest sample.
+	start int
+	// Number of valid samples in the buffer.
+	count int
+}
+
+func newSampleRing(size int) *sampleRing {
+	return &sampleRing{
+		size: size,
+	}
+}
+
+// Grow the buffer, unrolling the samples so that the oldest sample is first.
+func (self *sampleRing) grow() {
+	newLen := 2 * len(self.samples)
+	if newLen == 0 {
+		newLen = 16
+	}
+	if newLen > self.size {
+		newLen = self.size
+	}
+	samples := make([]usageSample, newLen)
+	for i := 0; i < self.count; i++ {
+		samples[i] = self.samples[(self.start+i)%len(self.samples)]
+	}
+	self.samples = samples
+	self.start = 0
+}
+
+// Add a new sample, evicting the oldest one if the buffer is full.
+func (self *sampleRing) add(timestamp time.Time, usage UsageSketch) {
+	if self.count == len(self.samples) && len(self.samples) < self.size {
+		self.grow()
+	}
+	idx := (self.start + self.count) % len(self.samples)
+	self.samples[idx] = usageSample{
+		Timestamp: timestamp,
+		Usage:     usage,
+	}
+	if self.count < len(self.samples) {
+		self.count++
+	} else {
+		self.start = (self.start + 1) % len(self.samples)
+	}
+}
+
+// Returns the newest sample, or nil if there is none.
+func (self *sampleRing) last() *usageSample {
+	if self.count == 0 {
+		return nil
+	}
+	return &self.samples[(self.start+self.count-1)%len(self.samples)]
+}
+
+// Drop all samples recorded before 'cutoff'.
+func (self *sampleRing) expire(cutoff time.Time) {
+	for self.count > 0 && self.samples[self.start].Timestamp.Before(cutoff) {
+		self.samples[self.start] = usageSample{}
+		self.start = (self.start + 1) % len(self.samples)
+		self.count--
+	}
+}
+
+// Returns the samples recorded after 'since', oldest first.
+func (self *sampleRing) since(since time.Time) []usageSample {
+	result := make([]usageSample, 0, self.count)
+	for i := 0; i < self.count; i++ {
+		sample := self.samples[(self.start+i)%len(self.samples)]
+		if sample.Timestamp.After(since) {
+			result = append(result, sample)
+		}
+	}
+	return result
+}
+
+// Returns the merged distribution of the samples recorded after 'since'.
+func (self *sampleRing) merge(since time.Time) (UsageSketch, bool) {
+	samples := self.since(since)
+	if len(samples) == 0 {
+		return UsageSketch{}, false
+	}
+	usage := UsageSketch{
+		Cpu:    NewSketch(),
+		Memory: NewSketch(),
+	}
+	for _, sample := range samples {
+		usage.Cpu.Merge(sample.Usage.Cpu)
+		usage.Memory.Merge(sample.Usage.Memory)
+	}
+	return usage, true
+}
+
+// Returns a new distribution holding the samples of both 'a' and 'b'.
+func mergeUsage(a, b UsageSketch) UsageSketch {
+	usage := UsageSketch{
+		Cpu:    NewSketch(),
+		Memory: NewSketch(),
+	}
+	usage.Cpu.Merge(a.Cpu)
+	usage.Cpu.Merge(b.Cpu)
+	usage.Memory.Merge(a.Memory)
+	usage.Memory.Merge(b.Memory)
+	return usage
+}
+
+// History of the usage of a node or container. The samples of the last hour are kept
+// at the poll interval to derive the hour usage, and rolled up by hour for the last day
+// to derive the day usage, so that deriving either merges a bounded number of sketches.
+// Retained sketches are never modified: the rollup of the hour in progress is replaced
+// by a new one on every sample.
+type usageHistory struct {
+	minutes *sampleRing
+	// Rollups timestamped with the start of their hour.
+	hours *sampleRing
+	// Time at which the first sample was recorded. Windows are only valid once they are fully covered.
+	firstSample time.Time
+}
+
+func newUsageHistory(pollInterval time.Duration) *usageHistory {
+	return &usageHistory{
+		minutes: newSampleRing(int(hourWindow/pollInterval) + 1),
+		hours:   newSampleRing(int(dayWindow/hourWindow) + 1),
+	}
+}
+
+// Add a new sample to the minute samples and to the rollup of its hour.
+func (self *usageHistory) add(timestamp time.Time, usage UsageSketch) {
+	if self.hours.count == 0 {
+		self.firstSample = timestamp
+	}
+	self.minutes.add(timestamp, usage)
+	hour := timestamp.Truncate(hourWindow)
+	if last := self.hours.last(); last != nil && last.Timestamp.Equal(hour) {
+		last.Usage = mergeUsage(last.Usage, usage)
+		return
+	}
+	self.hours.add(hour, usage)
+}
+
+// Drop the samples that fell out of the windows ending at 'now'.
+func (self *usageHistory) expire(now time.Time) {
+	self.minutes.expire(now.Add(-hourWindow))
+	self.hours.expire(now.Add(-dayWindow))
+}
+
+// Returns whether the history covers the entire 'window' ending at 'now'.
+func (self *usageHistory) covers(now time.Time, window, pollInterval time.Duration) bool {
+	return self.hours.count > 0 && now.Sub(self.firstSample)+pollInterval >= window
+}
+
+// Returns the merged distribution for the hour ending at 'now'.
+// Returns false until the history covers the entire hour. Gaps in the
+// samples due to node outages are tolerated; the missing minutes are ignored.
+func (self *usageHistory) getHourUsage(now time.Time, pollInterval time.Duration) (UsageSketch, bool) {
+	if !self.covers(now, hourWindow, pollInterval) {
+		return UsageSketch{}, false
+	}
+	return self.minutes.merge(now.Add(-hourWindow))
+}
+
+// Returns the merged distribution for the day ending at 'now', from the rollups of
+// the hours that start within the day. Returns false until the history covers the
+// entire day.
+func (self *usageHistory) getDayUsage(now time.Time, pollInterval time.Duration) (UsageSketch, bool) {
+	if !self.covers(now, dayWindow, pollInterval) {
+		return UsageSketch{}, false
+	}
+	return self.hours.merge(now.Add(-dayWindow))
+}
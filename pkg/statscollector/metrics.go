@@ -0,0 +1,197 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Exports the aggregated stats and the health of the collector in the plain
+// text exposition format scraped by monitoring systems. Node metrics are
+// labeled with the node name, usage metrics with the window and percentile.
+
+package statscollector
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/golang/glog"
+)
+
+// Upper bounds of the poll latency buckets.
+var pollLatencyBounds = []time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	60 * time.Second,
+}
+
+func newLatencyHistogram(bounds []time.Duration) LatencyHistogram {
+	return LatencyHistogram{
+		Bounds: bounds,
+		Counts: make([]uint64, len(bounds)),
+	}
+}
+
+// Record an observation of 'latency'.
+func (self *LatencyHistogram) observe(latency time.Duration) {
+	for i, bound := range self.Bounds {
+		if latency <= bound {
+			self.Counts[i]++
+		}
+	}
+	self.Count++
+	self.Sum += latency
+}
+
+// Returns a copy that does not share the bucket counts.
+func (self LatencyHistogram) copy() LatencyHistogram {
+	self.Counts = append([]uint64(nil), self.Counts...)
+	return self
+}
+
+const metricsContentType = "text/plain; version=0.0.4"
+
+// A label of a metric sample. Labels are written in the given order.
+type metricLabel struct {
+	name  string
+	value string
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Accumulates metrics in the text exposition format.
+type metricsWriter struct {
+	buffer bytes.Buffer
+}
+
+func (self *metricsWriter) header(name, metricType, help string) {
+	fmt.Fprintf(&self.buffer, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func (self *metricsWriter) sample(name string, labels []metricLabel, value float64) {
+	self.buffer.WriteString(name)
+	if len(labels) > 0 {
+		self.buffer.WriteString("{")
+		for i, label := range labels {
+			if i > 0 {
+				self.buffer.WriteString(",")
+			}
+			fmt.Fprintf(&self.buffer, "%s=\"%s\"", label.name, labelValueEscaper.Replace(label.value))
+		}
+		self.buffer.WriteString("}")
+	}
+	fmt.Fprintf(&self.buffer, " %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (self *metricsWriter) histogram(name, help string, histogram LatencyHistogram) {
+	self.header(name, "histogram", help)
+	for i, bound := range histogram.Bounds {
+		le := strconv.FormatFloat(bound.Seconds(), 'g', -1, 64)
+		self.sample(name+"_bucket", []metricLabel{{"le", le}}, float64(histogram.Counts[i]))
+	}
+	self.sample(name+"_bucket", []metricLabel{{"le", "+Inf"}}, float64(histogram.Count))
+	self.sample(name+"_sum", nil, histogram.Sum.Seconds())
+	self.sample(name+"_count", nil, float64(histogram.Count))
+}
+
+// Write the usage of all the valid windows of 'nodes' for one resource.
+func (self *metricsWriter) usage(name, help string, nodes []NodeData, resource func(Resource) Percentiles) {
+	self.header(name, "gauge", help)
+	for _, node := range nodes {
+		for _, window := range allWindows {
+			usage := getWindow(node.Stats, window)
+			if !usage.Valid {
+				continue
+			}
+			percentiles := toV1Percentiles(resource(usage), nil)
+			names := make([]string, 0, len(percentiles))
+			for name := range percentiles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, percentile := range names {
+				labels := []metricLabel{{"node", node.Id.Name}, {"window", window}, {"percentile", percentile}}
+				self.sample(name, labels, float64(percentiles[percentile]))
+			}
+		}
+	}
+}
+
+func (self *Server) handleMetrics(w http.ResponseWriter, req *http.Request) {
+	nodes, err := self.getNodes(queryOptions{selector: labels.Everything()})
+	if err != nil {
+		glog.Errorf("Failed to get node stats for metrics: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pollStats, err := self.aggregator.GetPollStats()
+	if err != nil {
+		glog.Errorf("Failed to get poll stats for metrics: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	now := self.clock.Now()
+
+	var out metricsWriter
+	out.header("statscollector_node_cpu_capacity_millicores", "gauge", "Cpu capacity of the node in milliCpus.")
+	for _, node := range nodes {
+		out.sample("statscollector_node_cpu_capacity_millicores", []metricLabel{{"node", node.Id.Name}}, float64(node.Capacity.Cpu))
+	}
+	out.header("statscollector_node_memory_capacity_bytes", "gauge", "Memory capacity of the node in bytes.")
+	for _, node := range nodes {
+		out.sample("statscollector_node_memory_capacity_bytes", []metricLabel{{"node", node.Id.Name}}, float64(node.Capacity.Memory))
+	}
+	out.usage("statscollector_node_cpu_usage_millicores", "Cpu usage of the node in milliCpus over each window.", nodes,
+		func(r Resource) Percentiles { return r.Cpu })
+	out.usage("statscollector_node_memory_usage_bytes", "Memory working set of the node in bytes over each window.", nodes,
+		func(r Resource) Percentiles { return r.Memory })
+	out.header("statscollector_node_last_update_age_seconds", "gauge", "Time since the stats of the node were last updated.")
+	for _, node := range nodes {
+		if node.Stats.LastUpdate.IsZero() {
+			continue
+		}
+		out.sample("statscollector_node_last_update_age_seconds", []metricLabel{{"node", node.Id.Name}}, now.Sub(node.Stats.LastUpdate).Seconds())
+	}
+
+	out.header("statscollector_node_poll_failures_total", "counter", "Number of failed requests to the node since it was detected.")
+	for _, node := range nodes {
+		failures := pollStats.Failures[node.Id.Name]
+		for _, kind := range []struct {
+			name  string
+			count uint64
+		}{
+			{"capacity", failures.Capacity},
+			{"usage", failures.Usage},
+			{"containers", failures.Containers},
+		} {
+			out.sample("statscollector_node_poll_failures_total", []metricLabel{{"node", node.Id.Name}, {"request", kind.name}}, float64(kind.count))
+		}
+	}
+	out.histogram("statscollector_node_poll_duration_seconds", "Time taken to poll a node, bounded by the poll timeout.", pollStats.NodeLatency)
+	out.histogram("statscollector_poll_round_duration_seconds", "Time taken to poll all the nodes.", pollStats.RoundLatency)
+
+	w.Header().Set("Content-Type", metricsContentType)
+	w.Write(out.buffer.Bytes())
+}
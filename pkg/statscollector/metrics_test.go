@@ -0,0 +1,122 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statscollector
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	histogram := newLatencyHistogram([]time.Duration{time.Second, 10 * time.Second})
+	for _, latency := range []time.Duration{500 * time.Millisecond, time.Second, 5 * time.Second, time.Minute} {
+		histogram.observe(latency)
+	}
+	if histogram.Counts[0] != 2 || histogram.Counts[1] != 3 || histogram.Count != 4 {
+		t.Errorf("unexpected bucket counts %+v", histogram)
+	}
+	if histogram.Sum != 66500*time.Millisecond {
+		t.Errorf("expected a sum of 66.5s, got %v", histogram.Sum)
+	}
+	copied := histogram.copy()
+	histogram.observe(time.Millisecond)
+	if copied.Counts[0] != 2 {
+		t.Errorf("copy shares the bucket counts with the histogram")
+	}
+}
+
+func getMetrics(t *testing.T) string {
+	fake := newFakeAggregator()
+	node := fake.nodes["node-a"]
+	node.Id.Name = "node-\"a\""
+	node.Stats.LastUpdate = time.Date(2014, 10, 1, 0, 0, 0, 0, time.UTC)
+	fake.nodes["node-a"] = node
+	fake.pollStats = PollStats{
+		Failures: map[string]PollFailures{
+			"node-b": {Usage: 3, Containers: 4},
+		},
+		NodeLatency:  newLatencyHistogram([]time.Duration{100 * time.Millisecond, time.Second}),
+		RoundLatency: newLatencyHistogram(pollLatencyBounds),
+	}
+	fake.pollStats.NodeLatency.observe(50 * time.Millisecond)
+	fake.pollStats.NodeLatency.observe(2 * time.Second)
+
+	server := NewServer(fake)
+	server.clock = &util.FakeClock{Time: node.Stats.LastUpdate.Add(90 * time.Second)}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	resp, err := http.Get(httpServer.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /metrics returned %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("unexpected content type %q", contentType)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := getMetrics(t)
+	expected := []string{
+		"# TYPE statscollector_node_cpu_capacity_millicores gauge",
+		`statscollector_node_cpu_capacity_millicores{node="node-b"} 2000`,
+		`statscollector_node_memory_capacity_bytes{node="node-\"a\""} 10000`,
+		`statscollector_node_cpu_usage_millicores{node="node-\"a\"",window="hour",percentile="99.9"} 250`,
+		`statscollector_node_cpu_usage_millicores{node="node-b",window="minute",percentile="90"} 200`,
+		`statscollector_node_memory_usage_bytes{node="node-b",window="minute",percentile="max"} 3000`,
+		`statscollector_node_last_update_age_seconds{node="node-\"a\""} 90`,
+		`statscollector_node_poll_failures_total{node="node-b",request="usage"} 3`,
+		`statscollector_node_poll_failures_total{node="node-b",request="containers"} 4`,
+		`statscollector_node_poll_failures_total{node="node-\"a\"",request="usage"} 0`,
+		"# TYPE statscollector_node_poll_duration_seconds histogram",
+		`statscollector_node_poll_duration_seconds_bucket{le="0.1"} 1`,
+		`statscollector_node_poll_duration_seconds_bucket{le="1"} 1`,
+		`statscollector_node_poll_duration_seconds_bucket{le="+Inf"} 2`,
+		"statscollector_node_poll_duration_seconds_sum 2.05",
+		"statscollector_node_poll_duration_seconds_count 2",
+		"statscollector_poll_round_duration_seconds_count 0",
+	}
+	for _, line := range expected {
+		if !strings.Contains(metrics, line+"\n") {
+			t.Errorf("metrics do not contain %q:\n%s", line, metrics)
+		}
+	}
+	// Invalid windows and nodes that were never updated are left out.
+	unexpected := []string{
+		`window="day"`,
+		`statscollector_node_cpu_usage_millicores{node="node-b",window="hour"`,
+		`statscollector_node_last_update_age_seconds{node="node-b"}`,
+	}
+	for _, text := range unexpected {
+		if strings.Contains(metrics, text) {
+			t.Errorf("metrics should not contain %q:\n%s", text, metrics)
+		}
+	}
+}
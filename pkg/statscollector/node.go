@@ -12,19 +12,23 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Interface to provide machine capacity and raw stats (60 per-second samples).
-// TODO(rjnagal): Extend to retrieve pod stats.
+// Interface to provide machine capacity and raw stats (60 per-second samples) for
+// the machine and for every container running on it.
 
 package statscollector
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"net/url"
 	"strconv"
+	"time"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/golang/glog"
 	cadvisor "github.com/google/cadvisor/info"
 )
@@ -33,18 +37,24 @@ import (
 const numStatsPerUpdate = 60
 
 type NodeApi interface {
-	UpdateStats(NodeId) (Resource, error)
+	// Returns the distribution of the node usage since the last update.
+	UpdateStats(NodeId) (UsageSketch, error)
 	MachineSpec(NodeId) (Capacity, error)
+	// Returns usage for all containers running on the node.
+	UpdateContainerStats(NodeId) (map[ContainerId]UsageSketch, error)
 }
 
 type nodeApi struct {
 	// Kubelet port used for retrieving node stats.
 	kubeletPort int
+	client      *http.Client
 }
 
-func NewKubeNodeApi(kubeletPort int) (NodeApi, error) {
+// Create a NodeApi talking to the kubelets. Each request to a kubelet is given 'timeout' to complete.
+func NewKubeNodeApi(kubeletPort int, timeout time.Duration) (NodeApi, error) {
 	return &nodeApi{
 		kubeletPort: kubeletPort,
+		client:      &http.Client{Transport: util.NewTimeoutTransport(timeout)},
 	}, nil
 }
 
@@ -68,7 +78,7 @@ func (self *nodeApi) getKubeletAddress(id NodeId) string {
 func (self *nodeApi) MachineSpec(id NodeId) (Capacity, error) {
 	var machineInfo cadvisor.MachineInfo
 	url := self.getKubeletAddress(id) + "/spec"
-	resp, err := http.Get(url)
+	resp, err := self.client.Get(url)
 	if err != nil {
 		return Capacity{}, err
 	}
@@ -84,43 +94,139 @@ func (self *nodeApi) MachineSpec(id NodeId) (Capacity, error) {
 	}, nil
 }
 
-func (self *nodeApi) getMachineStats(id NodeId) ([]*cadvisor.ContainerStats, error) {
+// Retrieve raw stats from the kubelet stats handler at 'path'.
+func (self *nodeApi) getStats(id NodeId, path string) ([]*cadvisor.ContainerStats, error) {
 	var containerInfo cadvisor.ContainerInfo
-	values := url.Values{}
-	values.Add("num_stats", strconv.Itoa(numStatsPerUpdate))
-	url := self.getKubeletAddress(id) + "/stats" + "?" + values.Encode()
-	resp, err := http.Get(url)
+	request, err := json.Marshal(cadvisor.ContainerInfoRequest{NumStats: numStatsPerUpdate})
+	if err != nil {
+		return []*cadvisor.ContainerStats{}, err
+	}
+	// The kubelet reads the stats request from the body irrespective of the method.
+	req, err := http.NewRequest("GET", self.getKubeletAddress(id)+path, bytes.NewBuffer(request))
+	if err != nil {
+		return []*cadvisor.ContainerStats{}, err
+	}
+	resp, err := self.client.Do(req)
 	if err != nil {
 		return []*cadvisor.ContainerStats{}, err
 	}
 	err = GetValueFromResponse(resp, &containerInfo)
 	if err != nil {
-		glog.Errorf("Updating Stats for minion %s with ip %s failed - %s\n", id.Name, id.Address, err)
+		glog.Errorf("Updating Stats %s for minion %s with ip %s failed - %s\n", path, id.Name, id.Address, err)
 		return []*cadvisor.ContainerStats{}, err
 	}
 
 	return containerInfo.Stats, nil
 }
 
-func (self *nodeApi) UpdateStats(id NodeId) (Resource, error) {
+func (self *nodeApi) getMachineStats(id NodeId) ([]*cadvisor.ContainerStats, error) {
+	return self.getStats(id, "/stats/")
+}
+
+func (self *nodeApi) getBoundPods(id NodeId) ([]api.BoundPod, error) {
+	var boundPods api.BoundPods
+	resp, err := self.client.Get(self.getKubeletAddress(id) + "/boundPods")
+	if err != nil {
+		return []api.BoundPod{}, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return []api.BoundPod{}, err
+	}
+	err = latest.Codec.DecodeInto(body, &boundPods)
+	if err != nil {
+		return []api.BoundPod{}, fmt.Errorf("Got '%s': %v", string(body), err)
+	}
+	return boundPods.Items, nil
+}
+
+func (self *nodeApi) UpdateStats(id NodeId) (UsageSketch, error) {
 	stats, err := self.getMachineStats(id)
 	if err != nil {
-		return Resource{}, err
+		return UsageSketch{}, err
 	}
+	return GetSketches(stats), nil
+}
 
-	cpu, memory := GetPercentiles(stats)
-	return Resource{
-		Valid:  true,
-		Cpu:    cpu,
-		Memory: memory,
-	}, nil
+func (self *nodeApi) UpdateContainerStats(id NodeId) (map[ContainerId]UsageSketch, error) {
+	pods, err := self.getBoundPods(id)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[ContainerId]UsageSketch)
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			containerId := ContainerId{
+				Namespace: pod.Namespace,
+				Pod:       pod.Name,
+				PodUID:    pod.UID,
+				Container: container.Name,
+			}
+			path := fmt.Sprintf("/stats/%s/%s/%s/%s", pod.Namespace, pod.Name, pod.UID, container.Name)
+			stats, err := self.getStats(id, path)
+			if err != nil {
+				// The container may not have started yet. Skip it for this update.
+				glog.V(2).Infof("Skipping container %s of node %s: %s", containerId.Key(), id.Name, err)
+				continue
+			}
+			result[containerId] = GetSketches(stats)
+		}
+	}
+	return result, nil
+}
+
+// Raw samples reported by the fake node api on each update.
+type fakeUsage struct {
+	cpu    []uint64
+	memory []uint64
+}
+
+func (self fakeUsage) sketch() UsageSketch {
+	usage := UsageSketch{
+		Cpu:    NewSketch(),
+		Memory: NewSketch(),
+	}
+	for _, value := range self.cpu {
+		usage.Cpu.Add(value)
+	}
+	for _, value := range self.memory {
+		usage.Memory.Add(value)
+	}
+	return usage
 }
 
 type fakeNodeApi struct {
+	// Usage reported for every node.
+	usage fakeUsage
+	// Usage reported for every container.
+	containerUsage fakeUsage
+	// Number of containers in the single pod running on each node.
+	numContainers int
+	// Nodes for which UpdateStats fails, simulating an outage.
+	unreachable map[string]bool
+	// Time taken by UpdateStats to respond for each node, simulating slow nodes.
+	delay map[string]time.Duration
+	// Time taken by UpdateContainerStats to respond for each node.
+	containerDelay map[string]time.Duration
 }
 
 func NewFakeNodeApi() (NodeApi, error) {
-	return &fakeNodeApi{}, nil
+	const GB = 1024 * 1024 * 1024
+	return &fakeNodeApi{
+		usage: fakeUsage{
+			cpu:    []uint64{5, 5, 5, 5, 5, 10, 10, 15, 123, 161},
+			memory: []uint64{1 * GB, 1 * GB, 1 * GB, 1 * GB, 1 * GB, 1 * GB, 2 * GB, 4 * GB, 7 * GB, 9 * GB},
+		},
+		containerUsage: fakeUsage{
+			cpu:    []uint64{2, 2, 2, 2, 2, 5, 5, 7, 60, 80},
+			memory: []uint64{GB / 2, GB / 2, GB / 2, GB / 2, GB / 2, GB / 2, 1 * GB, 2 * GB, 3 * GB, 4 * GB},
+		},
+		numContainers:  2,
+		unreachable:    make(map[string]bool),
+		delay:          make(map[string]time.Duration),
+		containerDelay: make(map[string]time.Duration),
+	}, nil
 }
 
 func (self *fakeNodeApi) MachineSpec(id NodeId) (Capacity, error) {
@@ -130,20 +236,28 @@ func (self *fakeNodeApi) MachineSpec(id NodeId) (Capacity, error) {
 	}, nil
 }
 
-func (self *fakeNodeApi) UpdateStats(id NodeId) (Resource, error) {
-	cpu := Percentiles{
-		Mean:   15,
-		Max:    161,
-		Ninety: 123,
+func (self *fakeNodeApi) UpdateStats(id NodeId) (UsageSketch, error) {
+	time.Sleep(self.delay[id.Name])
+	if self.unreachable[id.Name] {
+		return UsageSketch{}, fmt.Errorf("node %s unreachable", id.Name)
 	}
-	memory := Percentiles{
-		Mean:   1073741824,
-		Max:    9663676416,
-		Ninety: 7516192768,
+	return self.usage.sketch(), nil
+}
+
+func (self *fakeNodeApi) UpdateContainerStats(id NodeId) (map[ContainerId]UsageSketch, error) {
+	time.Sleep(self.containerDelay[id.Name])
+	if self.unreachable[id.Name] {
+		return nil, fmt.Errorf("node %s unreachable", id.Name)
 	}
-	return Resource{
-		Valid:  true,
-		Cpu:    cpu,
-		Memory: memory,
-	}, nil
+	result := make(map[ContainerId]UsageSketch)
+	for i := 0; i < self.numContainers; i++ {
+		containerId := ContainerId{
+			Namespace: api.NamespaceDefault,
+			Pod:       "pod-" + id.Name,
+			PodUID:    "uid-" + id.Name,
+			Container: "container-" + strconv.Itoa(i),
+		}
+		result[containerId] = self.containerUsage.sketch()
+	}
+	return result, nil
 }
@@ -0,0 +1,189 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Polls nodes concurrently over a bounded pool of workers. No aggregator locks
+// are held while talking to the nodes.
+
+package statscollector
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// Result of polling a single node.
+type pollResult struct {
+	id NodeId
+	// Set if the capacity was requested and successfully retrieved.
+	capacity     *Capacity
+	capacityErr  error
+	usage        UsageSketch
+	err          error
+	containers   map[ContainerId]UsageSketch
+	containerErr error
+	// Time taken to poll the node, bounded by the poll timeout.
+	latency time.Duration
+}
+
+type pollRequest struct {
+	id            NodeId
+	needsCapacity bool
+	// Delay from the start of the round before polling the node.
+	offset time.Duration
+}
+
+// Poll all the requested nodes and return once every node has responded or timed out.
+// The polls are spread over the round to avoid hitting all the nodes at the same
+// instant, each node starting at a random offset from the start of the round.
+func (self *aggregator) pollNodes(requests []pollRequest) []pollResult {
+	workers := self.pollWorkers
+	if workers > len(requests) {
+		workers = len(requests)
+	}
+	start := time.Now()
+	offsets := self.pollOffsets(len(requests), workers)
+	pending := make(chan pollRequest, len(requests))
+	for i, request := range requests {
+		request.offset = offsets[i]
+		pending <- request
+	}
+	close(pending)
+
+	results := make(chan pollResult, len(requests))
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for request := range pending {
+				if wait := request.offset - time.Since(start); wait > 0 {
+					time.Sleep(wait)
+				}
+				results <- self.pollNodeWithTimeout(request)
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	polled := make([]pollResult, 0, len(requests))
+	for result := range results {
+		polled = append(polled, result)
+	}
+	return polled
+}
+
+// Returns increasing random offsets from the start of a round for 'count' polls over
+// 'workers' workers. The offsets are at most the poll jitter, and leave enough of the
+// interval for each worker to poll its share of the nodes one after another, even if
+// all of them time out, so that rounds do not overrun the interval.
+func (self *aggregator) pollOffsets(count, workers int) []time.Duration {
+	offsets := make([]time.Duration, count)
+	if count == 0 {
+		return offsets
+	}
+	perWorker := (count + workers - 1) / workers
+	spread := self.pollInterval - time.Duration(perWorker)*self.pollTimeout
+	if spread > self.pollJitter {
+		spread = self.pollJitter
+	}
+	if spread <= 0 {
+		return offsets
+	}
+	for i := range offsets {
+		offsets[i] = time.Duration(rand.Int63n(int64(spread)))
+	}
+	sort.Sort(byDuration(offsets))
+	return offsets
+}
+
+type byDuration []time.Duration
+
+func (self byDuration) Len() int           { return len(self) }
+func (self byDuration) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+func (self byDuration) Less(i, j int) bool { return self[i] < self[j] }
+
+// Result of polling the containers of a node.
+type containerPollResult struct {
+	containers map[ContainerId]UsageSketch
+	err        error
+}
+
+// Poll a node, giving up if it does not respond within the poll timeout. The node usage
+// is kept if only its containers fail to respond in time.
+func (self *aggregator) pollNodeWithTimeout(request pollRequest) pollResult {
+	// Buffered so that a late response does not block the polling goroutine forever.
+	nodeDone := make(chan pollResult, 1)
+	containersDone := make(chan containerPollResult, 1)
+	start := time.Now()
+	go self.pollNode(request, nodeDone, containersDone)
+	timeout := time.After(self.pollTimeout)
+	var result pollResult
+	select {
+	case result = <-nodeDone:
+	case <-timeout:
+		err := fmt.Errorf("timed out after %v", self.pollTimeout)
+		result = pollResult{
+			id:           request.id,
+			err:          err,
+			containerErr: err,
+			latency:      self.pollTimeout,
+		}
+		if request.needsCapacity {
+			result.capacityErr = err
+		}
+		return result
+	}
+	if result.err == nil {
+		select {
+		case containers := <-containersDone:
+			result.containers, result.containerErr = containers.containers, containers.err
+		case <-timeout:
+			result.containerErr = fmt.Errorf("container stats timed out after %v", self.pollTimeout)
+		}
+	}
+	result.latency = time.Since(start)
+	return result
+}
+
+// Poll the capacity and usage of a node and send them to 'node', then poll the usage of
+// its containers and send it to 'containers'. The containers are not polled if the node
+// usage could not be retrieved.
+func (self *aggregator) pollNode(request pollRequest, node chan<- pollResult, containers chan<- containerPollResult) {
+	result := pollResult{id: request.id}
+	if request.needsCapacity {
+		capacity, err := self.nodeApi.MachineSpec(request.id)
+		if err != nil {
+			glog.Errorf("Failed to update capacity for node %s: %s", request.id.Name, err)
+			result.capacityErr = err
+		} else {
+			result.capacity = &capacity
+		}
+	}
+	result.usage, result.err = self.nodeApi.UpdateStats(request.id)
+	if result.err != nil {
+		result.containerErr = result.err
+	}
+	node <- result
+	if result.err != nil {
+		return
+	}
+	usage, err := self.nodeApi.UpdateContainerStats(request.id)
+	containers <- containerPollResult{usage, err}
+}
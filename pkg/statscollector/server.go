@@ -0,0 +1,360 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// HTTP API serving the aggregated stats.
+//
+// /api/v1/nodes                List of nodes.
+// /api/v1/nodes/<name>         A single node.
+// /api/v1/summary              Cluster-wide summary.
+// /metrics                     Node stats and poll health in the plain text exposition format.
+//
+// All the v1 requests accept the following query parameters:
+//   window=minute,hour,day     Usage windows to return. Defaults to all.
+//   percentiles=mean,max,90    Percentiles to return. Defaults to all the collected percentiles.
+//   labels=<selector>          Only consider nodes matching the label selector.
+//
+// The unversioned /stats, /stats/pods and /stats/containers requests return the
+// internal types and are kept for existing clients.
+
+package statscollector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/statscollector/api/v1"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+const apiV1Prefix = "/api/" + v1.Version
+
+var allWindows = []string{v1.WindowMinute, v1.WindowHour, v1.WindowDay}
+
+type Server struct {
+	aggregator Aggregator
+	mux        *http.ServeMux
+	clock      util.Clock
+}
+
+// Create a server for the stats collected by 'aggregator'.
+func NewServer(aggregator Aggregator) *Server {
+	server := &Server{
+		aggregator: aggregator,
+		mux:        http.NewServeMux(),
+		clock:      util.RealClock{},
+	}
+	server.mux.HandleFunc("/stats", server.handleStats)
+	server.mux.HandleFunc("/stats/pods", server.handlePodStats)
+	server.mux.HandleFunc("/stats/containers", server.handleContainerStats)
+	server.mux.HandleFunc(apiV1Prefix+"/nodes", server.handleNodes)
+	server.mux.HandleFunc(apiV1Prefix+"/nodes/", server.handleNode)
+	server.mux.HandleFunc(apiV1Prefix+"/summary", server.handleSummary)
+	server.mux.HandleFunc("/metrics", server.handleMetrics)
+	return server
+}
+
+func (self *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	self.mux.ServeHTTP(w, req)
+}
+
+// Options parsed from the query parameters of a v1 request.
+type queryOptions struct {
+	windows     []string
+	percentiles []string
+	selector    labels.Selector
+}
+
+// Split a comma separated list of values, checking that each of them is one of 'valid'.
+// Returns all the valid values if 'value' is empty.
+func parseList(name, value string, valid []string) ([]string, error) {
+	if value == "" {
+		return valid, nil
+	}
+	result := []string{}
+	for _, item := range strings.Split(value, ",") {
+		found := false
+		for _, v := range valid {
+			if item == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid %s %q, must be one of %v", name, item, valid)
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// Parse the requested percentiles. Returns nil if all percentiles are requested.
+func parsePercentiles(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	result := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item != v1.PercentileMean && item != v1.PercentileMax {
+			p, err := ParsePercentiles(item)
+			if err != nil {
+				return nil, err
+			}
+			item = PercentileName(p[0])
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func parseQueryOptions(req *http.Request) (queryOptions, error) {
+	query := req.URL.Query()
+	windows, err := parseList("window", query.Get("window"), allWindows)
+	if err != nil {
+		return queryOptions{}, err
+	}
+	percentiles, err := parsePercentiles(query.Get("percentiles"))
+	if err != nil {
+		return queryOptions{}, err
+	}
+	selector, err := labels.ParseSelector(query.Get("labels"))
+	if err != nil {
+		return queryOptions{}, fmt.Errorf("invalid label selector: %s", err)
+	}
+	return queryOptions{
+		windows:     windows,
+		percentiles: percentiles,
+		selector:    selector,
+	}, nil
+}
+
+func toV1Percentiles(p Percentiles, percentiles []string) v1.Percentiles {
+	all := make(v1.Percentiles, len(p.Values)+3)
+	all[v1.PercentileMean] = p.Mean
+	all[v1.PercentileMax] = p.Max
+	all[v1.PercentileNinety] = p.Ninety
+	for name, value := range p.Values {
+		all[name] = value
+	}
+	if percentiles == nil {
+		return all
+	}
+	// Percentiles that are not collected are left out.
+	result := make(v1.Percentiles, len(percentiles))
+	for _, name := range percentiles {
+		if value, ok := all[name]; ok {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+func toV1Usage(resource Resource, percentiles []string) v1.Usage {
+	if !resource.Valid {
+		return v1.Usage{Valid: false}
+	}
+	return v1.Usage{
+		Valid:  true,
+		Cpu:    toV1Percentiles(resource.Cpu, percentiles),
+		Memory: toV1Percentiles(resource.Memory, percentiles),
+	}
+}
+
+func getWindow(stats DerivedStats, window string) Resource {
+	switch window {
+	case v1.WindowMinute:
+		return stats.MinuteUsage
+	case v1.WindowHour:
+		return stats.HourUsage
+	case v1.WindowDay:
+		return stats.DayUsage
+	}
+	return Resource{}
+}
+
+func toV1Node(node NodeData, options queryOptions) v1.Node {
+	usage := make(map[string]v1.Usage, len(options.windows))
+	for _, window := range options.windows {
+		usage[window] = toV1Usage(getWindow(node.Stats, window), options.percentiles)
+	}
+	return v1.Node{
+		Name:    node.Id.Name,
+		Address: node.Id.Address,
+		Labels:  node.Id.Labels,
+		Capacity: v1.Capacity{
+			Cpu:    node.Capacity.Cpu,
+			Memory: node.Capacity.Memory,
+		},
+		LastUpdate: node.Stats.LastUpdate,
+		Usage:      usage,
+	}
+}
+
+// Returns the nodes matching the label selector, sorted by name.
+func (self *Server) getNodes(options queryOptions) ([]NodeData, error) {
+	nodes, err := self.aggregator.GetNodeStats()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(nodes))
+	for name, node := range nodes {
+		if options.selector.Matches(labels.Set(node.Id.Labels)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	result := make([]NodeData, 0, len(names))
+	for _, name := range names {
+		result = append(result, nodes[name])
+	}
+	return result, nil
+}
+
+func (self *Server) handleNodes(w http.ResponseWriter, req *http.Request) {
+	options, err := parseQueryOptions(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	nodes, err := self.getNodes(options)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	list := v1.NodeList{
+		Version: v1.Version,
+		Items:   make([]v1.Node, 0, len(nodes)),
+	}
+	for _, node := range nodes {
+		list.Items = append(list.Items, toV1Node(node, options))
+	}
+	writeJson(w, list)
+}
+
+func (self *Server) handleNode(w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(req.URL.Path, apiV1Prefix+"/nodes/")
+	if name == "" || strings.Contains(name, "/") {
+		writeError(w, http.StatusNotFound, fmt.Errorf("invalid node name %q", name))
+		return
+	}
+	options, err := parseQueryOptions(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	nodes, err := self.aggregator.GetNodeStats()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	node, ok := nodes[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("node %q not found", name))
+		return
+	}
+	writeJson(w, v1.NodeResponse{
+		Version: v1.Version,
+		Node:    toV1Node(node, options),
+	})
+}
+
+func (self *Server) handleSummary(w http.ResponseWriter, req *http.Request) {
+	options, err := parseQueryOptions(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	nodes, err := self.getNodes(options)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	summary := v1.ClusterSummary{
+		Version:  v1.Version,
+		NumNodes: len(nodes),
+		Windows:  make(map[string]v1.WindowSummary, len(options.windows)),
+	}
+	for _, node := range nodes {
+		summary.Capacity.Cpu += node.Capacity.Cpu
+		summary.Capacity.Memory += node.Capacity.Memory
+	}
+	for _, window := range options.windows {
+		windowSummary := v1.WindowSummary{}
+		total := Resource{}
+		for _, node := range nodes {
+			usage := getWindow(node.Stats, window)
+			if !usage.Valid {
+				continue
+			}
+			windowSummary.NumNodes++
+			windowSummary.Capacity.Cpu += node.Capacity.Cpu
+			windowSummary.Capacity.Memory += node.Capacity.Memory
+			total.Valid = true
+			total.Cpu = AddPercentiles(total.Cpu, usage.Cpu)
+			total.Memory = AddPercentiles(total.Memory, usage.Memory)
+		}
+		windowSummary.Usage = toV1Usage(total, options.percentiles)
+		summary.Windows[window] = windowSummary
+	}
+	writeJson(w, summary)
+}
+
+func (self *Server) handleStats(w http.ResponseWriter, req *http.Request) {
+	nodeData, err := self.aggregator.GetNodeStats()
+	writeStats(w, nodeData, err)
+}
+
+func (self *Server) handlePodStats(w http.ResponseWriter, req *http.Request) {
+	podData, err := self.aggregator.GetPodStats()
+	writeStats(w, podData, err)
+}
+
+func (self *Server) handleContainerStats(w http.ResponseWriter, req *http.Request) {
+	containerData, err := self.aggregator.GetContainerStats()
+	writeStats(w, containerData, err)
+}
+
+func writeStats(w http.ResponseWriter, data interface{}, err error) {
+	if err != nil {
+		glog.Infof("Failed to get data from statscollector: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJson(w, data)
+}
+
+func writeJson(w http.ResponseWriter, data interface{}) {
+	out, err := json.Marshal(data)
+	if err != nil {
+		glog.Errorf("Failed to marshal response %+v: %s", data, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	out, _ := json.Marshal(v1.Error{
+		Version: v1.Version,
+		Message: err.Error(),
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(out)
+}
@@ -0,0 +1,161 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statscollector
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/statscollector/api/v1"
+)
+
+type fakeAggregator struct {
+	nodes     map[string]NodeData
+	pollStats PollStats
+}
+
+func (self *fakeAggregator) Start() error { return nil }
+func (self *fakeAggregator) Stop() error  { return nil }
+func (self *fakeAggregator) GetNodeStats() (map[string]NodeData, error) {
+	return self.nodes, nil
+}
+func (self *fakeAggregator) GetPodStats() (map[string]PodData, error) {
+	return map[string]PodData{}, nil
+}
+func (self *fakeAggregator) GetContainerStats() (map[string]ContainerData, error) {
+	return map[string]ContainerData{}, nil
+}
+func (self *fakeAggregator) GetPollStats() (PollStats, error) {
+	return self.pollStats, nil
+}
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(NewServer(newFakeAggregator()))
+}
+
+func newFakeAggregator() *fakeAggregator {
+	usage := Resource{
+		Valid:  true,
+		Cpu:    Percentiles{Mean: 100, Max: 300, Ninety: 200, Values: map[string]uint64{"99.9": 250}},
+		Memory: Percentiles{Mean: 1000, Max: 3000, Ninety: 2000},
+	}
+	nodes := map[string]NodeData{
+		"node-a": {
+			Id:       NodeId{Name: "node-a", Address: "1.0.0.1", Labels: map[string]string{"zone": "east"}},
+			Capacity: Capacity{Cpu: 1000, Memory: 10000},
+			Stats:    DerivedStats{MinuteUsage: usage, HourUsage: usage},
+		},
+		"node-b": {
+			Id:       NodeId{Name: "node-b", Address: "1.0.0.2", Labels: map[string]string{"zone": "west"}},
+			Capacity: Capacity{Cpu: 2000, Memory: 20000},
+			Stats:    DerivedStats{MinuteUsage: usage},
+		},
+	}
+	return &fakeAggregator{nodes: nodes}
+}
+
+func getJson(t *testing.T, url string, expectedStatus int, result interface{}) {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != expectedStatus {
+		t.Fatalf("GET %s returned %d, expected %d", url, resp.StatusCode, expectedStatus)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		t.Fatalf("failed to decode response of %s: %s", url, err)
+	}
+}
+
+func TestListNodes(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	var list v1.NodeList
+	getJson(t, server.URL+"/api/v1/nodes", http.StatusOK, &list)
+	if list.Version != v1.Version || len(list.Items) != 2 {
+		t.Fatalf("unexpected node list %+v", list)
+	}
+	if list.Items[0].Name != "node-a" || list.Items[1].Name != "node-b" {
+		t.Errorf("nodes not sorted by name: %+v", list.Items)
+	}
+	if len(list.Items[0].Usage) != 3 || len(list.Items[0].Usage[v1.WindowHour].Cpu) != 4 {
+		t.Errorf("expected all windows and percentiles, got %+v", list.Items[0].Usage)
+	}
+
+	list = v1.NodeList{}
+	getJson(t, server.URL+"/api/v1/nodes?labels=zone%3Dwest&window=minute&percentiles=90,99.90", http.StatusOK, &list)
+	if len(list.Items) != 1 || list.Items[0].Name != "node-b" {
+		t.Fatalf("expected only node-b, got %+v", list.Items)
+	}
+	expected := map[string]v1.Usage{
+		v1.WindowMinute: {
+			Valid:  true,
+			Cpu:    v1.Percentiles{v1.PercentileNinety: 200, "99.9": 250},
+			Memory: v1.Percentiles{v1.PercentileNinety: 2000},
+		},
+	}
+	if !reflect.DeepEqual(list.Items[0].Usage, expected) {
+		t.Errorf("usage is %+v, expected %+v", list.Items[0].Usage, expected)
+	}
+}
+
+func TestGetNode(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	var node v1.NodeResponse
+	getJson(t, server.URL+"/api/v1/nodes/node-b?window=hour", http.StatusOK, &node)
+	if node.Node.Name != "node-b" || node.Node.Capacity.Cpu != 2000 {
+		t.Errorf("unexpected node %+v", node)
+	}
+	if node.Node.Usage[v1.WindowHour].Valid {
+		t.Errorf("hour usage of node-b should be invalid")
+	}
+
+	var apiErr v1.Error
+	getJson(t, server.URL+"/api/v1/nodes/node-c", http.StatusNotFound, &apiErr)
+	getJson(t, server.URL+"/api/v1/nodes?window=week", http.StatusBadRequest, &apiErr)
+	getJson(t, server.URL+"/api/v1/nodes?percentiles=median", http.StatusBadRequest, &apiErr)
+	if apiErr.Message == "" {
+		t.Errorf("expected an error message")
+	}
+}
+
+func TestClusterSummary(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	var summary v1.ClusterSummary
+	getJson(t, server.URL+"/api/v1/summary?window=minute,hour&percentiles=mean,max", http.StatusOK, &summary)
+	if summary.NumNodes != 2 || summary.Capacity.Cpu != 3000 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	minute := summary.Windows[v1.WindowMinute]
+	if minute.NumNodes != 2 || minute.Usage.Cpu[v1.PercentileMean] != 200 || minute.Usage.Cpu[v1.PercentileMax] != 600 {
+		t.Errorf("unexpected minute summary %+v", minute)
+	}
+	hour := summary.Windows[v1.WindowHour]
+	if hour.NumNodes != 1 || hour.Capacity.Cpu != 1000 || hour.Usage.Memory[v1.PercentileMean] != 1000 {
+		t.Errorf("unexpected hour summary %+v", hour)
+	}
+	if _, ok := summary.Windows[v1.WindowDay]; ok {
+		t.Errorf("day window was not requested")
+	}
+}
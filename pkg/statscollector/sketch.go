@@ -0,0 +1,275 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Mergeable streaming quantile sketch.
+//
+// Samples are counted in logarithmically sized buckets, so any quantile is
+// reported within a fixed relative error of the true value. Sketches covering
+// different periods are merged by adding their bucket counts, which avoids
+// sorting raw samples when computing percentiles over long windows.
+
+package statscollector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Relative error of the quantiles reported by a sketch.
+const sketchRelativeAccuracy = 0.01
+
+// Version of the binary encoding of a sketch.
+const sketchEncodingVersion = 1
+
+var (
+	sketchGamma    = (1 + sketchRelativeAccuracy) / (1 - sketchRelativeAccuracy)
+	sketchLogGamma = math.Log(sketchGamma)
+)
+
+type sketchBin struct {
+	index int32
+	count uint32
+}
+
+type Sketch struct {
+	// Bins sorted by index. Bin i counts samples in (gamma^(i-1), gamma^i].
+	bins []sketchBin
+	// Number of zero valued samples, which do not fit in any bin.
+	zeroCount uint64
+	count     uint64
+	sum       float64
+	min       uint64
+	max       uint64
+}
+
+func NewSketch() *Sketch {
+	return &Sketch{}
+}
+
+func sketchIndex(value uint64) int32 {
+	return int32(math.Ceil(math.Log(float64(value)) / sketchLogGamma))
+}
+
+// Returns the value reported for samples in bin 'index'.
+func sketchValue(index int32) float64 {
+	return 2 * math.Pow(sketchGamma, float64(index)) / (1 + sketchGamma)
+}
+
+// Add a sample to the sketch.
+func (self *Sketch) Add(value uint64) {
+	if self.count == 0 || value < self.min {
+		self.min = value
+	}
+	if value > self.max {
+		self.max = value
+	}
+	self.count++
+	self.sum += float64(value)
+	if value == 0 {
+		self.zeroCount++
+		return
+	}
+	index := sketchIndex(value)
+	i := sort.Search(len(self.bins), func(i int) bool { return self.bins[i].index >= index })
+	if i < len(self.bins) && self.bins[i].index == index {
+		self.bins[i].count++
+		return
+	}
+	self.bins = append(self.bins, sketchBin{})
+	copy(self.bins[i+1:], self.bins[i:])
+	self.bins[i] = sketchBin{index: index, count: 1}
+}
+
+// Merge 'other' into the sketch.
+func (self *Sketch) Merge(other *Sketch) {
+	if other == nil || other.count == 0 {
+		return
+	}
+	if self.count == 0 || other.min < self.min {
+		self.min = other.min
+	}
+	if other.max > self.max {
+		self.max = other.max
+	}
+	self.count += other.count
+	self.sum += other.sum
+	self.zeroCount += other.zeroCount
+
+	merged := make([]sketchBin, 0, len(self.bins)+len(other.bins))
+	i, j := 0, 0
+	for i < len(self.bins) || j < len(other.bins) {
+		switch {
+		case j == len(other.bins) || (i < len(self.bins) && self.bins[i].index < other.bins[j].index):
+			merged = append(merged, self.bins[i])
+			i++
+		case i == len(self.bins) || other.bins[j].index < self.bins[i].index:
+			merged = append(merged, other.bins[j])
+			j++
+		default:
+			merged = append(merged, sketchBin{index: self.bins[i].index, count: self.bins[i].count + other.bins[j].count})
+			i++
+			j++
+		}
+	}
+	self.bins = merged
+}
+
+// Number of samples in the sketch.
+func (self *Sketch) Count() uint64 {
+	return self.count
+}
+
+// Exact average of the samples.
+func (self *Sketch) Mean() uint64 {
+	if self.count == 0 {
+		return 0
+	}
+	return uint64(self.sum / float64(self.count))
+}
+
+// Exact maximum of the samples.
+func (self *Sketch) Max() uint64 {
+	return self.max
+}
+
+// Returns the 'q' quantile, with 0 <= q <= 1.
+func (self *Sketch) Quantile(q float64) uint64 {
+	if self.count == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(self.count-1))
+	if rank < self.zeroCount {
+		return 0
+	}
+	seen := self.zeroCount
+	value := float64(self.max)
+	for _, bin := range self.bins {
+		seen += uint64(bin.count)
+		if seen > rank {
+			value = sketchValue(bin.index)
+			break
+		}
+	}
+	// The exact bounds are known, keep the estimate within them.
+	if value > float64(self.max) {
+		return self.max
+	}
+	if value < float64(self.min) {
+		return self.min
+	}
+	return uint64(value + 0.5)
+}
+
+// Summarize the sketch. Values holds the requested percentiles, keyed by PercentileName.
+func (self *Sketch) Percentiles(percentiles []float64) Percentiles {
+	result := Percentiles{
+		Mean:   self.Mean(),
+		Max:    self.Max(),
+		Ninety: self.Quantile(0.9),
+	}
+	if len(percentiles) > 0 {
+		result.Values = make(map[string]uint64, len(percentiles))
+		for _, p := range percentiles {
+			result.Values[PercentileName(p)] = self.Quantile(p / 100)
+		}
+	}
+	return result
+}
+
+// Name of the percentile 'p' as used in Percentiles.Values, e.g. "99" or "99.9".
+func PercentileName(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// Encode the sketch in a compact binary form. Bin indexes are delta encoded,
+// so a sketch takes a few bytes per occupied bin.
+func (self *Sketch) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 1+5*binary.MaxVarintLen64+8+len(self.bins)*2*binary.MaxVarintLen32)
+	buf[0] = sketchEncodingVersion
+	n := 1
+	n += binary.PutUvarint(buf[n:], self.count)
+	n += binary.PutUvarint(buf[n:], self.zeroCount)
+	n += binary.PutUvarint(buf[n:], self.min)
+	n += binary.PutUvarint(buf[n:], self.max)
+	binary.LittleEndian.PutUint64(buf[n:], math.Float64bits(self.sum))
+	n += 8
+	n += binary.PutUvarint(buf[n:], uint64(len(self.bins)))
+	last := int32(0)
+	for _, bin := range self.bins {
+		n += binary.PutVarint(buf[n:], int64(bin.index-last))
+		n += binary.PutUvarint(buf[n:], uint64(bin.count))
+		last = bin.index
+	}
+	return buf[:n], nil
+}
+
+// Decode a sketch encoded by MarshalBinary.
+func (self *Sketch) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != sketchEncodingVersion {
+		return fmt.Errorf("unsupported sketch encoding")
+	}
+	data = data[1:]
+	var err error
+	readUvarint := func() uint64 {
+		value, n := binary.Uvarint(data)
+		if n <= 0 {
+			err = fmt.Errorf("truncated sketch")
+			return 0
+		}
+		data = data[n:]
+		return value
+	}
+	decoded := Sketch{}
+	decoded.count = readUvarint()
+	decoded.zeroCount = readUvarint()
+	decoded.min = readUvarint()
+	decoded.max = readUvarint()
+	if err != nil || len(data) < 8 {
+		return fmt.Errorf("truncated sketch")
+	}
+	decoded.sum = math.Float64frombits(binary.LittleEndian.Uint64(data))
+	data = data[8:]
+	numBins := readUvarint()
+	if err != nil || numBins > uint64(len(data)) {
+		return fmt.Errorf("truncated sketch")
+	}
+	decoded.bins = make([]sketchBin, 0, numBins)
+	last := int32(0)
+	total := decoded.zeroCount
+	for i := uint64(0); i < numBins; i++ {
+		delta, n := binary.Varint(data)
+		if n <= 0 {
+			return fmt.Errorf("truncated sketch")
+		}
+		data = data[n:]
+		count := readUvarint()
+		if err != nil {
+			return err
+		}
+		if i > 0 && delta <= 0 {
+			return fmt.Errorf("sketch bins out of order")
+		}
+		last += int32(delta)
+		decoded.bins = append(decoded.bins, sketchBin{index: last, count: uint32(count)})
+		total += count
+	}
+	if total != decoded.count {
+		return fmt.Errorf("sketch holds %d samples, expected %d", total, decoded.count)
+	}
+	*self = decoded
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statscollector
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func newSketchWith(values ...uint64) *Sketch {
+	sketch := NewSketch()
+	for _, value := range values {
+		sketch.Add(value)
+	}
+	return sketch
+}
+
+func TestSketch90Percentile(t *testing.T) {
+	sketch := NewSketch()
+	for i := uint64(1); i <= 100; i++ {
+		sketch.Add(i)
+	}
+	if p := sketch.Quantile(0.9); !approxEqual(p, 90) {
+		t.Errorf("90th percentile is %d, should be 90.", p)
+	}
+	for i := uint64(101); i <= 105; i++ {
+		sketch.Add(i)
+	}
+	if p := sketch.Quantile(0.9); !approxEqual(p, 95) {
+		t.Errorf("90th percentile is %d, should be 95.", p)
+	}
+}
+
+func TestSketchSmallSamples(t *testing.T) {
+	for _, samples := range [][]uint64{{5}, {5, 10}, {0, 0}, {1, 2, 3, 4, 5, 6, 7, 8, 9, 10}} {
+		sketch := newSketchWith(samples...)
+		expected := samples[len(samples)-1]
+		if p := sketch.Quantile(1); p != expected {
+			t.Errorf("100th percentile of %v is %d, should be %d.", samples, p, expected)
+		}
+		if p := sketch.Quantile(0); p != samples[0] {
+			t.Errorf("0th percentile of %v is %d, should be %d.", samples, p, samples[0])
+		}
+	}
+	empty := NewSketch()
+	if empty.Quantile(0.5) != 0 || empty.Mean() != 0 || empty.Max() != 0 {
+		t.Errorf("empty sketch should report zeros")
+	}
+}
+
+func TestSketchMean(t *testing.T) {
+	sketch := NewSketch()
+	for i := uint64(1); i < 100; i++ {
+		sketch.Add(i)
+	}
+	if mean := sketch.Mean(); mean != 50 {
+		t.Errorf("Mean is %d, should be 50", mean)
+	}
+}
+
+func TestSketchMergeMatchesSingleSketch(t *testing.T) {
+	all := NewSketch()
+	merged := NewSketch()
+	values := make([]uint64, 0, 10000)
+	for part := 0; part < 10; part++ {
+		sketch := NewSketch()
+		for i := 0; i < 1000; i++ {
+			value := uint64(rand.Int63n(1 << 34))
+			values = append(values, value)
+			sketch.Add(value)
+			all.Add(value)
+		}
+		merged.Merge(sketch)
+	}
+	sort.Sort(uint64s(values))
+	for _, q := range []float64{0.5, 0.9, 0.95, 0.99} {
+		if merged.Quantile(q) != all.Quantile(q) {
+			t.Errorf("merged quantile %v is %d, expected %d", q, merged.Quantile(q), all.Quantile(q))
+		}
+		exact := values[int(q*float64(len(values)-1))]
+		if !approxEqual(merged.Quantile(q), exact) {
+			t.Errorf("quantile %v is %d, exact value is %d", q, merged.Quantile(q), exact)
+		}
+	}
+	if merged.Count() != 10000 || merged.Max() != values[len(values)-1] || merged.Mean() != all.Mean() {
+		t.Errorf("merged sketch count %d, max %d, mean %d do not match", merged.Count(), merged.Max(), merged.Mean())
+	}
+}
+
+type uint64s []uint64
+
+func (a uint64s) Len() int           { return len(a) }
+func (a uint64s) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a uint64s) Less(i, j int) bool { return a[i] < a[j] }
+
+func TestSketchBinaryEncoding(t *testing.T) {
+	sketch := newSketchWith(0, 0, 3, 5, 5, 1000, 1<<40)
+	data, err := sketch.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := NewSketch()
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sketch, decoded) {
+		t.Errorf("decoded sketch %+v, expected %+v", decoded, sketch)
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Errorf("expected an error decoding a truncated sketch")
+	}
+}
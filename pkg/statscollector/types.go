@@ -25,6 +25,8 @@ type Percentiles struct {
 	Max uint64 `json:"max"`
 	// 90th percentile over the collected sample.
 	Ninety uint64 `json:"ninety"`
+	// Configured percentiles over the collected sample, keyed by percentile (e.g. "50", "99.9").
+	Values map[string]uint64 `json:"values,omitempty"`
 }
 
 type Capacity struct {
@@ -34,12 +36,20 @@ type Capacity struct {
 	Memory uint64 `json:"memory"`
 }
 
+// Distribution of the raw samples collected over a period.
+type UsageSketch struct {
+	// Cpu rate in milliCpus/second.
+	Cpu *Sketch
+	// Memory working set in bytes.
+	Memory *Sketch
+}
+
 type Resource struct {
 	// Set to false if data is invalid.
 	Valid bool `json:"valid"`
-	// Mean, Max, and 90p cpu rate value in milliCpus/seconds. Converted to milliCpus to avoid floats.
+	// Mean, Max, 90p and configured percentiles of cpu rate in milliCpus/seconds. Converted to milliCpus to avoid floats.
 	Cpu Percentiles `json:"cpu"`
-	// Mean, Max, and 90p memory size in bytes.
+	// Mean, Max, 90p and configured percentiles of memory size in bytes.
 	Memory Percentiles `json:"memory"`
 }
 
@@ -49,6 +59,9 @@ type NodeId struct {
 
 	// Host ip for the node api.
 	Address string `json:"address"`
+
+	// Labels attached to the node.
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 type DerivedStats struct {
@@ -68,3 +81,72 @@ type NodeData struct {
 	Id       NodeId       `json:"id"`
 	Stats    DerivedStats `json:"stats"`
 }
+
+type ContainerId struct {
+	// Namespace of the pod the container belongs to.
+	Namespace string `json:"namespace"`
+	// Pod name.
+	Pod string `json:"pod"`
+	// Pod UID, used to address the container on the kubelet.
+	PodUID string `json:"pod_uid"`
+	// Container name within the pod.
+	Container string `json:"container"`
+}
+
+// Key of the pod in the summaries returned by the aggregator.
+func (self ContainerId) PodKey() string {
+	return self.Namespace + "/" + self.Pod
+}
+
+// Key of the container in the summaries returned by the aggregator.
+func (self ContainerId) Key() string {
+	return self.PodKey() + "/" + self.Container
+}
+
+type ContainerData struct {
+	Id ContainerId `json:"id"`
+	// Hostname of the node running the container.
+	Node  string       `json:"node"`
+	Stats DerivedStats `json:"stats"`
+}
+
+type PodData struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Hostname of the node running the pod.
+	Node string `json:"node"`
+	// Names of the containers in the pod.
+	Containers []string `json:"containers"`
+	// Pod usage is the sum of its containers usage. Mean is exact, while max and 90p are upper bounds.
+	Stats DerivedStats `json:"stats"`
+}
+
+// Number of failed polls of a node since it was detected.
+type PollFailures struct {
+	// Failed requests for the machine capacity.
+	Capacity uint64 `json:"capacity"`
+	// Failed requests for the node usage, including timeouts.
+	Usage uint64 `json:"usage"`
+	// Failed requests for the container usage, including the ones skipped because the node usage failed.
+	Containers uint64 `json:"containers"`
+}
+
+// Cumulative histogram of poll durations.
+type LatencyHistogram struct {
+	// Upper bounds of the buckets, in increasing order.
+	Bounds []time.Duration `json:"bounds"`
+	// Number of observations at most the bound of each bucket.
+	Counts []uint64 `json:"counts"`
+	// Total number and duration of the observations.
+	Count uint64        `json:"count"`
+	Sum   time.Duration `json:"sum"`
+}
+
+type PollStats struct {
+	// Failed polls keyed by hostname.
+	Failures map[string]PollFailures `json:"failures"`
+	// Time taken to poll each node, bounded by the poll timeout.
+	NodeLatency LatencyHistogram `json:"node_latency"`
+	// Time taken by each round of polls of all the nodes.
+	RoundLatency LatencyHistogram `json:"round_latency"`
+}
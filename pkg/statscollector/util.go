@@ -12,13 +12,14 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Utility methods to calculate percentiles from raw data.
+// Utility methods to summarize raw data.
 
 package statscollector
 
 import (
-	"math"
-	"sort"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang/glog"
@@ -28,49 +29,14 @@ import (
 const milliSecondsToNanoSeconds = 1000000
 const secondsToMilliSeconds = 1000
 
-type uint64Slice []uint64
-
-func (a uint64Slice) Len() int           { return len(a) }
-func (a uint64Slice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a uint64Slice) Less(i, j int) bool { return a[i] < a[j] }
-
-// Get 90th percentile of the provided samples. Round to integer.
-func Get90Percentile(samples uint64Slice) uint64 {
-	count := len(samples)
-	if count == 0 {
-		return 0
-	}
-	sort.Sort(samples)
-	n := float64(0.9 * (float64(count) + 1))
-	idx, frac := math.Modf(n)
-	index := int(idx)
-	percentile := float64(samples[index-1])
-	if index > 1 || index < count {
-		percentile += frac * float64(samples[index]-samples[index-1])
-	}
-	return uint64(percentile)
-}
-
-// Add new sample to existing average.
-func GetMean(mean float64, value uint64, count uint64) float64 {
-	if count < 1 {
-		return 0
-	}
-	c := float64(count)
-	v := float64(value)
-	return (mean*(c-1) + v) / c
-}
-
-func GetPercentiles(stats []*cadvisor.ContainerStats) (Percentiles, Percentiles) {
+// Build cpu rate and memory working set sketches from raw stats.
+func GetSketches(stats []*cadvisor.ContainerStats) UsageSketch {
 	lastCpu := uint64(0)
 	lastTime := time.Time{}
-	memorySamples := make(uint64Slice, 0, len(stats))
-	cpuSamples := make(uint64Slice, 0, len(stats)-1)
-	numSamples := 0
-	memoryMean := float64(0)
-	cpuMean := float64(0)
-	memoryPercentiles := Percentiles{}
-	cpuPercentiles := Percentiles{}
+	usage := UsageSketch{
+		Cpu:    NewSketch(),
+		Memory: NewSketch(),
+	}
 	for _, stat := range stats {
 		var elapsed int64
 		time := stat.Timestamp
@@ -81,38 +47,72 @@ func GetPercentiles(stats []*cadvisor.ContainerStats) (Percentiles, Percentiles)
 				continue
 			}
 		}
-		numSamples++
 		cpuNs := stat.Cpu.Usage.Total
 		// Ignore actual usage and only focus on working set.
 		memory := stat.Memory.WorkingSet
-		if memory > memoryPercentiles.Max {
-			memoryPercentiles.Max = memory
-		}
 		glog.V(2).Infof("Read sample: cpu %d, memory %d", cpuNs, memory)
-		memoryMean = GetMean(memoryMean, memory, uint64(numSamples))
-		memorySamples = append(memorySamples, memory)
+		usage.Memory.Add(memory)
 		if lastTime.IsZero() {
 			lastCpu = cpuNs
 			lastTime = time
 			continue
 		}
-		cpuRate := (cpuNs - lastCpu) * secondsToMilliSeconds / uint64(elapsed)
-		if cpuRate < 0 {
-			glog.Infof("cpu rate too small: %f ns", cpuRate)
+		if cpuNs < lastCpu {
+			glog.Infof("cpu usage went backwards: %d ns to %d ns", lastCpu, cpuNs)
+			lastCpu = cpuNs
+			lastTime = time
 			continue
 		}
+		cpuRate := (cpuNs - lastCpu) * secondsToMilliSeconds / uint64(elapsed)
 		glog.V(2).Infof("Adding cpu rate sample : %d", cpuRate)
 		lastCpu = cpuNs
 		lastTime = time
-		cpuSamples = append(cpuSamples, cpuRate)
-		if cpuRate > cpuPercentiles.Max {
-			cpuPercentiles.Max = cpuRate
+		usage.Cpu.Add(cpuRate)
+	}
+	return usage
+}
+
+// Summarize raw stats into cpu and memory percentiles.
+func GetPercentiles(stats []*cadvisor.ContainerStats, percentiles []float64) (Percentiles, Percentiles) {
+	usage := GetSketches(stats)
+	return usage.Cpu.Percentiles(percentiles), usage.Memory.Percentiles(percentiles)
+}
+
+// Parse a comma separated list of percentiles, e.g. "50,95,99".
+func ParsePercentiles(value string) ([]float64, error) {
+	result := []float64{}
+	if value == "" {
+		return result, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		p, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid percentile %q: %s", item, err)
+		}
+		if p <= 0 || p >= 100 {
+			return nil, fmt.Errorf("invalid percentile %q, must be between 0 and 100", item)
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// Add two sets of percentiles. Mean of the sum is exact, while max and other
+// percentiles of the sum are upper bounds since the samples are not correlated.
+func AddPercentiles(a, b Percentiles) Percentiles {
+	result := Percentiles{
+		Mean:   a.Mean + b.Mean,
+		Max:    a.Max + b.Max,
+		Ninety: a.Ninety + b.Ninety,
+	}
+	if len(a.Values) > 0 || len(b.Values) > 0 {
+		result.Values = make(map[string]uint64, len(b.Values))
+		for name, value := range a.Values {
+			result.Values[name] = value
+		}
+		for name, value := range b.Values {
+			result.Values[name] += value
 		}
-		cpuMean = GetMean(cpuMean, cpuRate, uint64(numSamples-1))
 	}
-	cpuPercentiles.Mean = uint64(cpuMean)
-	memoryPercentiles.Mean = uint64(memoryMean)
-	cpuPercentiles.Ninety = Get90Percentile(cpuSamples)
-	memoryPercentiles.Ninety = Get90Percentile(memorySamples)
-	return cpuPercentiles, memoryPercentiles
+	return result
 }
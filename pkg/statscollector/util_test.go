@@ -23,37 +23,17 @@ import (
 
 const Nanosecond = 1000000000
 
-func Test90Percentile(t *testing.T) {
-	N := 100
-	stats := make(uint64Slice, 0, N)
-	for i := 1; i <= N; i++ {
-		stats = append(stats, uint64(i))
-	}
-	p := Get90Percentile(stats)
-	if p != 90 {
-		t.Errorf("90th percentile is %d, should be 90.", p)
-	}
-	// 90p should be between 94 and 95. Promoted to 95.
-	N = 105
-	for i := 101; i <= N; i++ {
-		stats = append(stats, uint64(i))
-	}
-	p = Get90Percentile(stats)
-	if p != 95 {
-		t.Errorf("90th percentile is %d, should be 95.", p)
+// Returns true if 'actual' is within the sketch accuracy of 'expected'.
+func approxEqual(actual, expected uint64) bool {
+	delta := float64(actual) - float64(expected)
+	if delta < 0 {
+		delta = -delta
 	}
+	return delta <= 2*sketchRelativeAccuracy*float64(expected)
 }
 
-func TestMean(t *testing.T) {
-	var i, N uint64
-	N = 100
-	mean := float64(0)
-	for i = 1; i < N; i++ {
-		mean = GetMean(mean, i, i)
-	}
-	if mean != 50.0 {
-		t.Errorf("Mean is %f, should be 50.0", mean)
-	}
+func approxEqualPercentiles(actual, expected Percentiles) bool {
+	return actual.Mean == expected.Mean && actual.Max == expected.Max && approxEqual(actual.Ninety, expected.Ninety)
 }
 
 func TestAggregates(t *testing.T) {
@@ -63,9 +43,9 @@ func TestAggregates(t *testing.T) {
 	stats := make([]*info.ContainerStats, 0, N)
 	for i = 1; i < N; i++ {
 		s := &info.ContainerStats{
-			Cpu:       &info.CpuStats{},
+			Cpu:       info.CpuStats{},
 			Timestamp: ct.Add(time.Duration(i) * time.Second),
-			Memory: &info.MemoryStats{
+			Memory: info.MemoryStats{
 				// Memory grows by a KB every second.
 				WorkingSet: i * 1024,
 			},
@@ -74,25 +54,26 @@ func TestAggregates(t *testing.T) {
 		s.Cpu.Usage.Total = i * Nanosecond
 		stats = append(stats, s)
 	}
-	cpu, mem := GetPercentiles(stats)
+	cpu, mem := GetPercentiles(stats, nil)
 	// Cpu mean, max, and 90p should all be 1000 ms/s.
 	cpuExpected := Percentiles{
 		Mean:   1000,
 		Max:    1000,
 		Ninety: 1000,
 	}
-	if cpu != cpuExpected {
+	if !approxEqualPercentiles(cpu, cpuExpected) {
 		t.Errorf("cpu stats are %+v. Expected %+v", cpu, cpuExpected)
 	}
 	memExpected := Percentiles{
 		Mean:   50 * 1024,
 		Max:    99 * 1024,
-		Ninety: 90 * 1024,
+		Ninety: 89 * 1024,
 	}
-	if mem != memExpected {
+	if !approxEqualPercentiles(mem, memExpected) {
 		t.Errorf("memory stats are mean %+v. Expected %+v", mem, memExpected)
 	}
 }
+
 func TestSamplesCloseInTimeIgnored(t *testing.T) {
 	N := uint64(100)
 	var i uint64
@@ -100,9 +81,9 @@ func TestSamplesCloseInTimeIgnored(t *testing.T) {
 	stats := make([]*info.ContainerStats, 0, N*2)
 	for i = 1; i < N; i++ {
 		s1 := &info.ContainerStats{
-			Cpu:       &info.CpuStats{},
+			Cpu:       info.CpuStats{},
 			Timestamp: ct.Add(time.Duration(i) * time.Second),
-			Memory: &info.MemoryStats{
+			Memory: info.MemoryStats{
 				// Memory grows by a KB every second.
 				WorkingSet: i * 1024,
 			},
@@ -113,32 +94,71 @@ func TestSamplesCloseInTimeIgnored(t *testing.T) {
 
 		// Add another dummy sample too close in time to the last one.
 		s2 := &info.ContainerStats{
-			Cpu: &info.CpuStats{},
+			Cpu: info.CpuStats{},
 			// Add extra millisecond.
 			Timestamp: ct.Add(time.Duration(i) * time.Second).Add(time.Duration(1) * time.Millisecond),
-			Memory: &info.MemoryStats{
+			Memory: info.MemoryStats{
 				WorkingSet: i * 1024 * 1024,
 			},
 		}
 		s2.Cpu.Usage.Total = i * 100 * Nanosecond
 		stats = append(stats, s2)
 	}
-	cpu, mem := GetPercentiles(stats)
+	cpu, mem := GetPercentiles(stats, nil)
 	// Cpu mean, max, and 90p should all be 1000 ms/s. All high-value samples are discarded.
 	cpuExpected := Percentiles{
 		Mean:   1000,
 		Max:    1000,
 		Ninety: 1000,
 	}
-	if cpu != cpuExpected {
+	if !approxEqualPercentiles(cpu, cpuExpected) {
 		t.Errorf("cpu stats are %+v. Expected %+v", cpu, cpuExpected)
 	}
 	memExpected := Percentiles{
 		Mean:   50 * 1024,
 		Max:    99 * 1024,
-		Ninety: 90 * 1024,
+		Ninety: 89 * 1024,
 	}
-	if mem != memExpected {
+	if !approxEqualPercentiles(mem, memExpected) {
 		t.Errorf("memory stats are mean %+v. Expected %+v", mem, memExpected)
 	}
 }
+
+func TestConfiguredPercentiles(t *testing.T) {
+	ct := time.Now()
+	stats := make([]*info.ContainerStats, 0, 101)
+	for i := uint64(0); i <= 100; i++ {
+		s := &info.ContainerStats{
+			Timestamp: ct.Add(time.Duration(i) * time.Second),
+			Memory: info.MemoryStats{
+				WorkingSet: i * 1024,
+			},
+		}
+		stats = append(stats, s)
+	}
+	_, mem := GetPercentiles(stats, []float64{50, 99.9})
+	if len(mem.Values) != 2 {
+		t.Fatalf("expected 2 configured percentiles, got %+v", mem.Values)
+	}
+	if !approxEqual(mem.Values["50"], 50*1024) {
+		t.Errorf("50th percentile is %d, expected %d", mem.Values["50"], 50*1024)
+	}
+	if !approxEqual(mem.Values["99.9"], 100*1024) {
+		t.Errorf("99.9th percentile is %d, expected %d", mem.Values["99.9"], 100*1024)
+	}
+}
+
+func TestParsePercentiles(t *testing.T) {
+	percentiles, err := ParsePercentiles("50, 95,99.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(percentiles) != 3 || percentiles[0] != 50 || percentiles[1] != 95 || percentiles[2] != 99.9 {
+		t.Errorf("unexpected percentiles %v", percentiles)
+	}
+	for _, invalid := range []string{"abc", "0", "100", "50,"} {
+		if _, err := ParsePercentiles(invalid); err == nil {
+			t.Errorf("expected an error parsing %q", invalid)
+		}
+	}
+}
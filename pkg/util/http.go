@@ -0,0 +1,67 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// NewTimeoutTransport returns an http.RoundTripper that gives each request
+// 'timeout' to complete, from dialing to reading the end of the response body.
+// A zero timeout means no timeout.
+func NewTimeoutTransport(timeout time.Duration) http.RoundTripper {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if timeout == 0 {
+		return transport
+	}
+	transport.Dial = func(network, addr string) (net.Conn, error) {
+		return net.DialTimeout(network, addr, timeout)
+	}
+	return &timeoutTransport{transport: transport, timeout: timeout}
+}
+
+type timeoutTransport struct {
+	transport *http.Transport
+	timeout   time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timer := time.AfterFunc(t.timeout, func() {
+		t.transport.CancelRequest(req)
+	})
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		timer.Stop()
+		return nil, err
+	}
+	resp.Body = &timeoutBody{ReadCloser: resp.Body, timer: timer}
+	return resp, nil
+}
+
+// timeoutBody stops the timer of its request once it is closed.
+type timeoutBody struct {
+	io.ReadCloser
+	timer *time.Timer
+}
+
+func (b *timeoutBody) Close() error {
+	b.timer.Stop()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutTransport(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow" {
+			<-block
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	// Unblock the slow handler before closing the server.
+	defer close(block)
+	client := &http.Client{Transport: NewTimeoutTransport(100 * time.Millisecond)}
+
+	resp, err := client.Get(server.URL + "/fast")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(body) != "ok" {
+		t.Errorf("expected ok, got %q, %v", body, err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := client.Get(server.URL + "/slow")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("expected the slow request to time out")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the slow request was not cancelled")
+	}
+}
@@ -18,6 +18,7 @@ package main
 
 import (
 	"flag"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"strconv"
@@ -31,14 +32,18 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/version/verflag"
 	"github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler"
 	_ "github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler/algorithmprovider"
+	schedulerapi "github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler/api"
 	"github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler/factory"
 	"github.com/golang/glog"
 )
 
 var (
-	port         = flag.Int("port", ports.SchedulerPort, "The port that the scheduler's http service runs on")
-	address      = util.IP(net.ParseIP("127.0.0.1"))
-	clientConfig = &client.Config{}
+	port              = flag.Int("port", ports.SchedulerPort, "The port that the scheduler's http service runs on")
+	address           = util.IP(net.ParseIP("127.0.0.1"))
+	clientConfig      = &client.Config{}
+	algorithmProvider = flag.String("algorithm_provider", factory.DefaultProvider, "The scheduling algorithm provider to use, ignored if a policy file is set")
+	policyConfigFile  = flag.String("policy_config_file", "", "File with the scheduling policy in JSON or YAML, listing the predicates and priorities to use")
+	groupTimeout      = flag.Duration("group_timeout", factory.DefaultGroupTimeout, "How long the members of a pod group are held until enough of them are queued to schedule the group; 0 to hold them indefinitely")
 )
 
 func init() {
@@ -63,7 +68,8 @@ func main() {
 	go http.ListenAndServe(net.JoinHostPort(address.String(), strconv.Itoa(*port)), nil)
 
 	configFactory := factory.NewConfigFactory(kubeClient)
-	config, err := configFactory.Create()
+	configFactory.GroupTimeout = *groupTimeout
+	config, err := createConfig(configFactory)
 	if err != nil {
 		glog.Fatalf("Failed to create scheduler configuration: %v", err)
 	}
@@ -72,3 +78,18 @@ func main() {
 
 	select {}
 }
+
+func createConfig(configFactory *factory.ConfigFactory) (*scheduler.Config, error) {
+	if *policyConfigFile == "" {
+		return configFactory.CreateFromProvider(*algorithmProvider)
+	}
+	data, err := ioutil.ReadFile(*policyConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := schedulerapi.ParsePolicy(data)
+	if err != nil {
+		return nil, err
+	}
+	return configFactory.CreateFromConfig(policy)
+}
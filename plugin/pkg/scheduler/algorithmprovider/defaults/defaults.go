@@ -39,6 +39,8 @@ func defaultPredicates() util.StringSet {
 		factory.RegisterFitPredicate("MatchNodeSelector", algorithm.NewSelectorMatchPredicate(factory.MinionLister)),
 		// Fit is determined by the presence of the Host parameter and a string match
 		factory.RegisterFitPredicate("HostName", algorithm.PodFitsHost),
+		// Fit is determined by the required pod affinity and anti-affinity rules.
+		factory.RegisterFitPredicate("MatchPodAffinity", algorithm.PodFitsAffinity),
 	)
 }
 
@@ -48,6 +50,8 @@ func defaultPriorities() util.StringSet {
 		factory.RegisterPriorityFunction("LeastRequestedPriority", algorithm.LeastRequestedPriority, 1),
 		// spreads pods by minimizing the number of pods on the same minion with the same labels.
 		factory.RegisterPriorityFunction("SpreadingPriority", algorithm.CalculateSpreadPriority, 1),
+		// favors minions satisfying the preferred pod affinity and anti-affinity rules.
+		factory.RegisterPriorityFunction("PodAffinityPriority", algorithm.CalculatePodAffinityPriority, 1),
 		// EqualPriority is a prioritizer function that gives an equal weight of one to all minions
 		factory.RegisterPriorityFunction("EqualPriority", algorithm.EqualPriority, 0),
 	)
@@ -0,0 +1,201 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package api contains the policy file format of the scheduler. A policy lists
+// the fit predicates and priority functions to schedule with, by their
+// registered names, and may configure new ones from the arguments it provides.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// Policy describes the algorithms used by the scheduler.
+type Policy struct {
+	// Predicates a node must satisfy to run a pod.
+	Predicates []PredicatePolicy `json:"predicates"`
+	// Priorities used to rank the nodes that fit.
+	Priorities []PriorityPolicy `json:"priorities"`
+	// Extenders called in order after the predicates to filter and rank the nodes further.
+	Extenders []ExtenderConfig `json:"extenders,omitempty"`
+}
+
+type PredicatePolicy struct {
+	// Name of a registered fit predicate, or the name to register a new one under
+	// if Argument is set.
+	Name string `json:"name"`
+	// Configures a new predicate. Exactly one of its fields must be set.
+	Argument *PredicateArgument `json:"argument,omitempty"`
+}
+
+type PriorityPolicy struct {
+	// Name of a registered priority function, or the name to register a new one
+	// under if Argument is set.
+	Name string `json:"name"`
+	// Multiplier of the scores of the function. Must be positive.
+	Weight int `json:"weight"`
+	// Configures a new priority function. Exactly one of its fields must be set.
+	Argument *PriorityArgument `json:"argument,omitempty"`
+}
+
+type PredicateArgument struct {
+	// Only fit nodes depending on the presence of node labels.
+	LabelsPresence *LabelsPresence `json:"labelsPresence,omitempty"`
+}
+
+type PriorityArgument struct {
+	// Spread pods with the same labels across the values of a node label.
+	LabelSpreading *LabelSpreading `json:"labelSpreading,omitempty"`
+	// Prefer nodes depending on the presence of a node label.
+	LabelPreference *LabelPreference `json:"labelPreference,omitempty"`
+}
+
+// LabelsPresence fits nodes that have all the labels if Presence is true, and
+// nodes that have none of them otherwise.
+type LabelsPresence struct {
+	Labels   []string `json:"labels"`
+	Presence bool     `json:"presence"`
+}
+
+// LabelSpreading spreads the pods sharing the labels of the scheduled pod across
+// the nodes with different values of Label, e.g. a zone.
+type LabelSpreading struct {
+	Label string `json:"label"`
+}
+
+// LabelPreference favors nodes that have Label if Presence is true, and nodes
+// that do not have it otherwise.
+type LabelPreference struct {
+	Label    string `json:"label"`
+	Presence bool   `json:"presence"`
+}
+
+// ExtenderConfig configures an HTTP extender, which receives the pod and the nodes that
+// fit the predicates in POST requests to URLPrefix/FilterVerb and URLPrefix/PrioritizeVerb.
+type ExtenderConfig struct {
+	// URL prefix of the extender, e.g. http://127.0.0.1:12346/scheduler.
+	URLPrefix string `json:"urlPrefix"`
+	// Verb of filter requests. The extender does not filter nodes if empty.
+	FilterVerb string `json:"filterVerb,omitempty"`
+	// Verb of prioritize requests. The extender does not score nodes if empty.
+	PrioritizeVerb string `json:"prioritizeVerb,omitempty"`
+	// Multiplier of the scores of the extender. Must be positive if PrioritizeVerb is set.
+	Weight int `json:"weight,omitempty"`
+	// Timeout of each request in seconds. Defaults to 5.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+	// Schedule without the extender when it fails or times out, instead of failing.
+	Ignorable bool `json:"ignorable,omitempty"`
+}
+
+// ParsePolicy decodes a policy in JSON or YAML. Unknown fields are rejected so
+// that typos do not silently change the scheduling algorithm.
+func ParsePolicy(data []byte) (*Policy, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy: %v", err)
+	}
+	policy := &Policy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid policy: %v", err)
+	}
+	var fields interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid policy: %v", err)
+	}
+	if err := checkFields(fields, reflect.TypeOf(policy), ""); err != nil {
+		return nil, fmt.Errorf("invalid policy: %v", err)
+	}
+	return policy, nil
+}
+
+// checkFields returns an error if the decoded JSON 'value' has object keys that
+// do not match a field of type 't', like encoding/json matches them.
+func checkFields(value interface{}, t reflect.Type, path string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for key, fieldValue := range object {
+			field, ok := jsonField(t, key)
+			if !ok {
+				return fmt.Errorf("unknown field %q", path+key)
+			}
+			if err := checkFields(fieldValue, field.Type, path+key+"."); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			if err := checkFields(item, t.Elem(), fmt.Sprintf("%s[%d].", strings.TrimSuffix(path, "."), i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for key, item := range object {
+			if err := checkFields(item, t.Elem(), path+key+"."); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonField returns the field of struct type 't' that encoding/json decodes the
+// key 'key' into, preferring an exact match of the name to a case-insensitive one.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var match *reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if name == key {
+			return field, true
+		}
+		if match == nil && strings.EqualFold(name, key) {
+			match = &field
+		}
+	}
+	if match == nil {
+		return reflect.StructField{}, false
+	}
+	return *match, true
+}
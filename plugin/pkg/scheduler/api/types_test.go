@@ -0,0 +1,99 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePolicy(t *testing.T) {
+	expected := &Policy{
+		Predicates: []PredicatePolicy{
+			{Name: "PodFitsPorts"},
+			{Name: "RackAware", Argument: &PredicateArgument{LabelsPresence: &LabelsPresence{Labels: []string{"rack"}, Presence: true}}},
+		},
+		Priorities: []PriorityPolicy{
+			{Name: "LeastRequestedPriority", Weight: 1},
+			{Name: "ZoneSpreading", Weight: 2, Argument: &PriorityArgument{LabelSpreading: &LabelSpreading{Label: "zone"}}},
+		},
+	}
+	json := `{
+		"predicates": [
+			{"name": "PodFitsPorts"},
+			{"name": "RackAware", "argument": {"labelsPresence": {"labels": ["rack"], "presence": true}}}
+		],
+		"priorities": [
+			{"name": "LeastRequestedPriority", "weight": 1},
+			{"name": "ZoneSpreading", "weight": 2, "argument": {"labelSpreading": {"label": "zone"}}}
+		]
+	}`
+	yaml := `
+predicates:
+- name: PodFitsPorts
+- name: RackAware
+  argument:
+    labelsPresence:
+      labels: [rack]
+      presence: true
+priorities:
+- name: LeastRequestedPriority
+  weight: 1
+- name: ZoneSpreading
+  weight: 2
+  argument:
+    labelSpreading:
+      label: zone
+`
+	for _, data := range []string{json, yaml} {
+		policy, err := ParsePolicy([]byte(data))
+		if err != nil {
+			t.Errorf("unexpected error parsing %s: %v", data, err)
+			continue
+		}
+		if !reflect.DeepEqual(expected, policy) {
+			t.Errorf("expected %#v, got %#v", expected, policy)
+		}
+	}
+}
+
+func TestParsePolicyRejectsUnknownFields(t *testing.T) {
+	for data, field := range map[string]string{
+		`{"predicates": [{"nmae": "PodFitsPorts"}]}`:                           "predicates[0].nmae",
+		`priorities: [{name: EqualPriority, wieght: 1}]`:                       "priorities[0].wieght",
+		`extenders: [{urlPrefix: "http://127.0.0.1", ignorable: false, x: 1}]`: "extenders[0].x",
+		`predicates: [{name: a, argument: {labelsPresence: {label: [a]}}}]`:    "predicates[0].argument.labelsPresence.label",
+		`predicates: {name: PodFitsPorts}`:                                     "",
+	} {
+		_, err := ParsePolicy([]byte(data))
+		if err == nil {
+			t.Errorf("expected an error parsing %s", data)
+		} else if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected an error about %q parsing %s, got %v", field, data, err)
+		}
+	}
+	// Keys match the fields like in encoding/json, and fields left to their
+	// default values are still known.
+	policy, err := ParsePolicy([]byte(`{"Predicates": [{"name": "PodFitsPorts"}], "extenders": [{"urlPrefix": "http://127.0.0.1", "ignorable": false}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(policy.Predicates) != 1 || len(policy.Extenders) != 1 {
+		t.Errorf("unexpected policy %#v", policy)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validation checks scheduler policies.
+package validation
+
+import (
+	"fmt"
+	"net/url"
+
+	errs "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	schedulerapi "github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler/api"
+)
+
+// ValidatePolicy checks that the policy is well formed. Whether the names it
+// refers to are registered is only known to the factory.
+func ValidatePolicy(policy *schedulerapi.Policy) error {
+	allErrs := errs.ValidationErrorList{}
+	names := util.StringSet{}
+	for i, predicate := range policy.Predicates {
+		allErrs = append(allErrs, validateName(predicate.Name, names).PrefixIndex(i).Prefix("predicates")...)
+		if predicate.Argument != nil {
+			allErrs = append(allErrs, validatePredicateArgument(predicate.Argument).Prefix("argument").PrefixIndex(i).Prefix("predicates")...)
+		}
+	}
+	names = util.StringSet{}
+	for i, priority := range policy.Priorities {
+		priorityErrs := validateName(priority.Name, names)
+		if priority.Weight <= 0 {
+			priorityErrs = append(priorityErrs, errs.NewFieldInvalid("weight", priority.Weight, "must be positive"))
+		}
+		if priority.Argument != nil {
+			priorityErrs = append(priorityErrs, validatePriorityArgument(priority.Argument).Prefix("argument")...)
+		}
+		allErrs = append(allErrs, priorityErrs.PrefixIndex(i).Prefix("priorities")...)
+	}
+	for i, extender := range policy.Extenders {
+		allErrs = append(allErrs, validateExtender(&extender).PrefixIndex(i).Prefix("extenders")...)
+	}
+	return util.SliceToError(allErrs)
+}
+
+func validateName(name string, seen util.StringSet) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if name == "" {
+		allErrs = append(allErrs, errs.NewFieldRequired("name", name))
+	} else if seen.Has(name) {
+		allErrs = append(allErrs, errs.NewFieldDuplicate("name", name))
+	}
+	seen.Insert(name)
+	return allErrs
+}
+
+func validatePredicateArgument(argument *schedulerapi.PredicateArgument) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if argument.LabelsPresence == nil {
+		return append(allErrs, errs.NewFieldRequired("labelsPresence", nil))
+	}
+	if len(argument.LabelsPresence.Labels) == 0 {
+		allErrs = append(allErrs, errs.NewFieldRequired("labelsPresence.labels", argument.LabelsPresence.Labels))
+	}
+	for i, label := range argument.LabelsPresence.Labels {
+		if label == "" {
+			allErrs = append(allErrs, errs.NewFieldRequired(fmt.Sprintf("labelsPresence.labels[%d]", i), label))
+		}
+	}
+	return allErrs
+}
+
+func validatePriorityArgument(argument *schedulerapi.PriorityArgument) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	set := 0
+	if argument.LabelSpreading != nil {
+		set++
+		if argument.LabelSpreading.Label == "" {
+			allErrs = append(allErrs, errs.NewFieldRequired("labelSpreading.label", ""))
+		}
+	}
+	if argument.LabelPreference != nil {
+		set++
+		if argument.LabelPreference.Label == "" {
+			allErrs = append(allErrs, errs.NewFieldRequired("labelPreference.label", ""))
+		}
+	}
+	if set != 1 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("", set, "exactly one of labelSpreading and labelPreference must be set"))
+	}
+	return allErrs
+}
+
+func validateExtender(extender *schedulerapi.ExtenderConfig) errs.ValidationErrorList {
+	allErrs := errs.ValidationErrorList{}
+	if extender.URLPrefix == "" {
+		allErrs = append(allErrs, errs.NewFieldRequired("urlPrefix", extender.URLPrefix))
+	} else if u, err := url.Parse(extender.URLPrefix); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		allErrs = append(allErrs, errs.NewFieldInvalid("urlPrefix", extender.URLPrefix, "must be an http or https URL"))
+	}
+	if extender.FilterVerb == "" && extender.PrioritizeVerb == "" {
+		allErrs = append(allErrs, errs.NewFieldRequired("filterVerb", extender.FilterVerb))
+	}
+	if extender.PrioritizeVerb != "" && extender.Weight <= 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("weight", extender.Weight, "must be positive"))
+	}
+	if extender.TimeoutSeconds < 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("timeoutSeconds", extender.TimeoutSeconds, "must not be negative"))
+	}
+	return allErrs
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	schedulerapi "github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler/api"
+)
+
+func TestValidatePolicy(t *testing.T) {
+	valid := &schedulerapi.Policy{
+		Predicates: []schedulerapi.PredicatePolicy{
+			{Name: "PodFitsPorts"},
+			{Name: "RackAware", Argument: &schedulerapi.PredicateArgument{LabelsPresence: &schedulerapi.LabelsPresence{Labels: []string{"rack"}}}},
+		},
+		Priorities: []schedulerapi.PriorityPolicy{
+			{Name: "LeastRequestedPriority", Weight: 1},
+			{Name: "ZoneSpreading", Weight: 2, Argument: &schedulerapi.PriorityArgument{LabelSpreading: &schedulerapi.LabelSpreading{Label: "zone"}}},
+		},
+		Extenders: []schedulerapi.ExtenderConfig{
+			{URLPrefix: "http://127.0.0.1:12346/scheduler", FilterVerb: "filter"},
+			{URLPrefix: "https://licenses.example.com", PrioritizeVerb: "prioritize", Weight: 2, TimeoutSeconds: 1, Ignorable: true},
+		},
+	}
+	if err := ValidatePolicy(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidatePolicy(&schedulerapi.Policy{}); err != nil {
+		t.Errorf("unexpected error for an empty policy: %v", err)
+	}
+
+	tests := []struct {
+		policy schedulerapi.Policy
+		fields []string
+	}{
+		{
+			policy: schedulerapi.Policy{Predicates: []schedulerapi.PredicatePolicy{{Name: "PodFitsPorts"}, {}, {Name: "PodFitsPorts"}}},
+			fields: []string{"predicates[1].name", "predicates[2].name"},
+		},
+		{
+			policy: schedulerapi.Policy{Predicates: []schedulerapi.PredicatePolicy{
+				{Name: "NoArgument", Argument: &schedulerapi.PredicateArgument{}},
+				{Name: "NoLabels", Argument: &schedulerapi.PredicateArgument{LabelsPresence: &schedulerapi.LabelsPresence{Labels: []string{""}}}},
+			}},
+			fields: []string{"predicates[0].argument.labelsPresence", "predicates[1].argument.labelsPresence.labels[0]"},
+		},
+		{
+			policy: schedulerapi.Policy{Priorities: []schedulerapi.PriorityPolicy{{Name: "EqualPriority"}, {Name: "LeastRequestedPriority", Weight: -1}}},
+			fields: []string{"priorities[0].weight", "priorities[1].weight"},
+		},
+		{
+			policy: schedulerapi.Policy{Priorities: []schedulerapi.PriorityPolicy{
+				{Name: "NoArgument", Weight: 1, Argument: &schedulerapi.PriorityArgument{}},
+				{Name: "TwoArguments", Weight: 1, Argument: &schedulerapi.PriorityArgument{
+					LabelSpreading:  &schedulerapi.LabelSpreading{Label: "zone"},
+					LabelPreference: &schedulerapi.LabelPreference{Label: "ssd"},
+				}},
+				{Name: "NoLabel", Weight: 1, Argument: &schedulerapi.PriorityArgument{LabelPreference: &schedulerapi.LabelPreference{}}},
+			}},
+			fields: []string{"priorities[0].argument", "priorities[1].argument", "priorities[2].argument.labelPreference.label"},
+		},
+		{
+			policy: schedulerapi.Policy{Extenders: []schedulerapi.ExtenderConfig{
+				{FilterVerb: "filter"},
+				{URLPrefix: "127.0.0.1:12346", FilterVerb: "filter"},
+				{URLPrefix: "http://127.0.0.1:12346"},
+				{URLPrefix: "http://127.0.0.1:12346", PrioritizeVerb: "prioritize", TimeoutSeconds: -1},
+			}},
+			fields: []string{"extenders[0].urlPrefix", "extenders[1].urlPrefix", "extenders[2].filterVerb", "extenders[3].weight", "extenders[3].timeoutSeconds"},
+		},
+	}
+	for _, test := range tests {
+		err := ValidatePolicy(&test.policy)
+		if err == nil {
+			t.Errorf("expected an error for %+v", test.policy)
+			continue
+		}
+		for _, field := range test.fields {
+			if !strings.Contains(err.Error(), field) {
+				t.Errorf("expected error %q to mention %s", err, field)
+			}
+		}
+	}
+}
@@ -33,10 +33,16 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	"github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler"
+	schedulerapi "github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler/api"
+	"github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler/api/validation"
 
 	"github.com/golang/glog"
 )
 
+// DefaultGroupTimeout is how long the members of a group are held by default until enough
+// of them are queued.
+const DefaultGroupTimeout = time.Minute
+
 var (
 	PodLister    = &storeToPodLister{cache.NewStore()}
 	MinionLister = &storeToNodeLister{cache.NewStore()}
@@ -51,6 +57,8 @@ type ConfigFactory struct {
 	PodLister *storeToPodLister
 	// a means to list all minions
 	MinionLister *storeToNodeLister
+	// how long the members of a group are held until enough of them are queued
+	GroupTimeout time.Duration
 }
 
 // NewConfigFactory initializes the factory.
@@ -60,6 +68,7 @@ func NewConfigFactory(client *client.Client) *ConfigFactory {
 		PodQueue:     cache.NewFIFO(),
 		PodLister:    PodLister,
 		MinionLister: MinionLister,
+		GroupTimeout: DefaultGroupTimeout,
 	}
 }
 
@@ -92,6 +101,37 @@ func (f *ConfigFactory) CreateFromKeys(predicateKeys, priorityKeys util.StringSe
 		return nil, err
 	}
 
+	return f.createFromAlgorithms(predicateFuncs, priorityConfigs, nil)
+}
+
+// CreateFromConfig creates a scheduler from a policy. The policy is validated, and all the
+// names it refers to must be registered unless the policy configures them.
+func (f *ConfigFactory) CreateFromConfig(policy *schedulerapi.Policy) (*scheduler.Config, error) {
+	glog.V(2).Infof("creating scheduler from policy %+v", policy)
+	if err := validation.ValidatePolicy(policy); err != nil {
+		return nil, fmt.Errorf("invalid policy: %v", err)
+	}
+
+	predicateFuncs, err := getPolicyFitPredicates(policy.Predicates)
+	if err != nil {
+		return nil, err
+	}
+
+	priorityConfigs, err := getPolicyPriorityConfigs(policy.Priorities)
+	if err != nil {
+		return nil, err
+	}
+
+	extenders := []algorithm.SchedulerExtender{}
+	for _, extender := range policy.Extenders {
+		timeout := time.Duration(extender.TimeoutSeconds) * time.Second
+		extenders = append(extenders, algorithm.NewHTTPExtender(extender.URLPrefix, extender.FilterVerb, extender.PrioritizeVerb, extender.Weight, timeout, extender.Ignorable))
+	}
+
+	return f.createFromAlgorithms(predicateFuncs, priorityConfigs, extenders)
+}
+
+func (f *ConfigFactory) createFromAlgorithms(predicateFuncs []algorithm.FitPredicate, priorityConfigs []algorithm.PriorityConfig, extenders []algorithm.SchedulerExtender) (*scheduler.Config, error) {
 	// Watch and queue pods that need scheduling.
 	cache.NewReflector(f.createUnassignedPodLW(), &api.Pod{}, f.PodQueue).Run()
 
@@ -110,17 +150,27 @@ func (f *ConfigFactory) CreateFromKeys(predicateKeys, priorityKeys util.StringSe
 
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 
-	algo := algorithm.NewGenericScheduler(predicateFuncs, priorityConfigs, f.PodLister, r)
+	algo := algorithm.NewGenericScheduler(predicateFuncs, priorityConfigs, extenders, f.PodLister, r)
 
 	podBackoff := podBackoff{
 		perPodBackoff: map[string]*backoffEntry{},
 		clock:         realClock{},
 	}
 
+	// Preempt only with an algorithm that can choose the pods to preempt.
+	preemptor, _ := algo.(algorithm.Preemptor)
+	// Schedule groups like other pods with an algorithm that cannot schedule them together.
+	groupAlgorithm, _ := algo.(algorithm.GroupScheduler)
+
 	return &scheduler.Config{
-		MinionLister: f.MinionLister,
-		Algorithm:    algo,
-		Binder:       &binder{f.Client},
+		MinionLister:   f.MinionLister,
+		Algorithm:      algo,
+		Binder:         &binder{f.Client},
+		Preemptor:      preemptor,
+		Evictor:        &evictor{f.Client},
+		GroupAlgorithm: groupAlgorithm,
+		GroupTimeout:   f.GroupTimeout,
+		PodLister:      f.PodLister,
 		NextPod: func() *api.Pod {
 			pod := f.PodQueue.Pop().(*api.Pod)
 			glog.V(2).Infof("glog.v2 --> About to try and schedule pod %v", pod.Name)
@@ -291,6 +341,16 @@ func (b *binder) Bind(binding *api.Binding) error {
 	return b.Post().Namespace(api.Namespace(ctx)).Resource("bindings").Body(binding).Do().Error()
 }
 
+type evictor struct {
+	*client.Client
+}
+
+// Evict deletes the pod.
+func (e *evictor) Evict(pod *api.Pod) error {
+	glog.V(2).Infof("Attempting to evict %v from %v", pod.Name, pod.Status.Host)
+	return e.Pods(pod.Namespace).Delete(pod.Name)
+}
+
 type clock interface {
 	Now() time.Time
 }
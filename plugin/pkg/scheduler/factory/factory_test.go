@@ -21,6 +21,7 @@ import (
 	"net/http/httptest"
 	"path"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -31,7 +32,9 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/cache"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	algorithm "github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	schedulerapi "github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler/api"
 )
 
 func TestCreate(t *testing.T) {
@@ -47,6 +50,72 @@ func TestCreate(t *testing.T) {
 	factory.Create()
 }
 
+func TestCreateFromConfig(t *testing.T) {
+	handler := util.FakeHandler{
+		StatusCode:   500,
+		ResponseBody: "",
+		T:            t,
+	}
+	server := httptest.NewServer(&handler)
+	defer server.Close()
+	client := client.NewOrDie(&client.Config{Host: server.URL, Version: testapi.Version()})
+	factory := NewConfigFactory(client)
+
+	RegisterFitPredicate("PredicateOne", func(pod api.Pod, existingPods []api.Pod, node string, cluster *algorithm.ClusterSnapshot) (bool, error) { return true, nil })
+	RegisterPriorityFunction("PriorityOne", algorithm.EqualPriority, 1)
+	policy := &schedulerapi.Policy{
+		Predicates: []schedulerapi.PredicatePolicy{
+			{Name: "PredicateOne"},
+			{Name: "RackAware", Argument: &schedulerapi.PredicateArgument{LabelsPresence: &schedulerapi.LabelsPresence{Labels: []string{"rack"}, Presence: true}}},
+		},
+		Priorities: []schedulerapi.PriorityPolicy{
+			{Name: "PriorityOne", Weight: 2},
+			{Name: "ZoneSpreading", Weight: 3, Argument: &schedulerapi.PriorityArgument{LabelSpreading: &schedulerapi.LabelSpreading{Label: "zone"}}},
+		},
+		Extenders: []schedulerapi.ExtenderConfig{
+			{URLPrefix: "http://127.0.0.1:12346/scheduler", FilterVerb: "filter", PrioritizeVerb: "prioritize", Weight: 1},
+		},
+	}
+	if _, err := factory.CreateFromConfig(policy); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := []*schedulerapi.Policy{
+		{Predicates: []schedulerapi.PredicatePolicy{{Name: "PredicateOne"}, {Name: "NoSuchPredicate"}}},
+		{Priorities: []schedulerapi.PriorityPolicy{{Name: "NoSuchPriority", Weight: 1}}},
+		{Priorities: []schedulerapi.PriorityPolicy{{Name: "PriorityOne"}}},
+		{Extenders: []schedulerapi.ExtenderConfig{{FilterVerb: "filter"}}},
+	}
+	for _, policy := range invalid {
+		if _, err := factory.CreateFromConfig(policy); err == nil {
+			t.Errorf("expected an error creating a scheduler from %+v", policy)
+		}
+	}
+}
+
+func TestGetPolicyPriorityConfigs(t *testing.T) {
+	RegisterPriorityFunction("PriorityTwo", algorithm.EqualPriority, 1)
+	configs, err := getPolicyPriorityConfigs([]schedulerapi.PriorityPolicy{
+		{Name: "PriorityTwo", Weight: 5},
+		{Name: "PreferSSD", Weight: 2, Argument: &schedulerapi.PriorityArgument{LabelPreference: &schedulerapi.LabelPreference{Label: "ssd", Presence: true}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(configs) != 2 || configs[0].Weight != 5 || configs[1].Weight != 2 {
+		t.Errorf("unexpected priority configs %+v", configs)
+	}
+	// The registered weight is left unchanged.
+	if config := priorityFunctionMap["PriorityTwo"]; config.Weight != 1 {
+		t.Errorf("expected the registered weight to be 1, got %d", config.Weight)
+	}
+
+	_, err = getPolicyPriorityConfigs([]schedulerapi.PriorityPolicy{{Name: "Missing1", Weight: 1}, {Name: "Missing2", Weight: 1}})
+	if err == nil || !strings.Contains(err.Error(), "Missing1") || !strings.Contains(err.Error(), "Missing2") {
+		t.Errorf("expected an error listing both missing priorities, got %v", err)
+	}
+}
+
 func TestCreateLists(t *testing.T) {
 	factory := NewConfigFactory(nil)
 	table := []struct {
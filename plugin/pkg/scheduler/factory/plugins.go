@@ -22,6 +22,7 @@ import (
 
 	algorithm "github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	schedulerapi "github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler/api"
 
 	"github.com/golang/glog"
 )
@@ -145,3 +146,63 @@ func getPriorityFunctionConfigs(keys util.StringSet) ([]algorithm.PriorityConfig
 	}
 	return configs, nil
 }
+
+// getPolicyFitPredicates returns the fit predicates listed in a policy. Predicates with
+// an argument are configured from it, the others must be registered.
+func getPolicyFitPredicates(policies []schedulerapi.PredicatePolicy) ([]algorithm.FitPredicate, error) {
+	schedulerFactoryMutex.Lock()
+	defer schedulerFactoryMutex.Unlock()
+
+	predicates := []algorithm.FitPredicate{}
+	errs := []error{}
+	for _, policy := range policies {
+		if policy.Argument != nil {
+			presence := policy.Argument.LabelsPresence
+			predicates = append(predicates, algorithm.NewNodeLabelPredicate(MinionLister, presence.Labels, presence.Presence))
+			continue
+		}
+		function, ok := fitPredicateMap[policy.Name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("Invalid predicate key %q specified - no corresponding function found", policy.Name))
+			continue
+		}
+		predicates = append(predicates, function)
+	}
+	if len(errs) > 0 {
+		return nil, util.SliceToError(errs)
+	}
+	return predicates, nil
+}
+
+// getPolicyPriorityConfigs returns the priority functions listed in a policy, with the
+// weights of the policy. Functions with an argument are configured from it, the others
+// must be registered.
+func getPolicyPriorityConfigs(policies []schedulerapi.PriorityPolicy) ([]algorithm.PriorityConfig, error) {
+	schedulerFactoryMutex.Lock()
+	defer schedulerFactoryMutex.Unlock()
+
+	configs := []algorithm.PriorityConfig{}
+	errs := []error{}
+	for _, policy := range policies {
+		var function algorithm.PriorityFunction
+		switch {
+		case policy.Argument != nil && policy.Argument.LabelSpreading != nil:
+			function = algorithm.NewLabelSpreadPriority(policy.Argument.LabelSpreading.Label)
+		case policy.Argument != nil && policy.Argument.LabelPreference != nil:
+			preference := policy.Argument.LabelPreference
+			function = algorithm.NewNodeLabelPriority(preference.Label, preference.Presence)
+		default:
+			config, ok := priorityFunctionMap[policy.Name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("Invalid priority key %q specified - no corresponding function found", policy.Name))
+				continue
+			}
+			function = config.Function
+		}
+		configs = append(configs, algorithm.PriorityConfig{Function: function, Weight: policy.Weight})
+	}
+	if len(errs) > 0 {
+		return nil, util.SliceToError(errs)
+	}
+	return configs, nil
+}
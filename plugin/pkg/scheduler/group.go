@@ -0,0 +1,202 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/record"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
+	"github.com/golang/glog"
+)
+
+// How often the held groups are checked for expiry.
+const groupExpiryPeriod = time.Second
+
+// podGroup holds the queued members of a group until there are enough of them.
+type podGroup struct {
+	minMembers int
+	members    []*api.Pod
+	// When the first member was held.
+	since time.Time
+}
+
+// podGroups holds the queued members of the groups by namespace and group name.
+type podGroups struct {
+	lock   sync.Mutex
+	groups map[string]*podGroup
+	clock  util.Clock
+}
+
+func newPodGroups() *podGroups {
+	return &podGroups{
+		groups: map[string]*podGroup{},
+		clock:  util.RealClock{},
+	}
+}
+
+func groupKey(pod *api.Pod) string {
+	return pod.Namespace + "/" + pod.Spec.Group.Name
+}
+
+// add holds the pod with the other queued members of its group. Once the queued members
+// and the 'running' ones reach the minimum of the pod, the group is no longer held and its
+// queued members are returned.
+func (g *podGroups) add(pod *api.Pod, running int) []*api.Pod {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	key := groupKey(pod)
+	group, ok := g.groups[key]
+	if !ok {
+		group = &podGroup{since: g.clock.Now()}
+		g.groups[key] = group
+	}
+	group.minMembers = pod.Spec.Group.MinMembers
+	replaced := false
+	for i, member := range group.members {
+		if member.Name == pod.Name {
+			group.members[i] = pod
+			replaced = true
+		}
+	}
+	if !replaced {
+		group.members = append(group.members, pod)
+	}
+	if len(group.members)+running < group.minMembers {
+		return nil
+	}
+	delete(g.groups, key)
+	return group.members
+}
+
+// expire stops holding the groups held for longer than 'timeout' and returns them.
+func (g *podGroups) expire(timeout time.Duration) map[string]*podGroup {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	expired := map[string]*podGroup{}
+	now := g.clock.Now()
+	for key, group := range g.groups {
+		if now.Sub(group.since) > timeout {
+			expired[key] = group
+			delete(g.groups, key)
+		}
+	}
+	return expired
+}
+
+// scheduleGroupMember holds the pod until enough members of its group are queued, then
+// schedules them together.
+func (s *Scheduler) scheduleGroupMember(pod *api.Pod) {
+	running, err := s.runningGroupMembers(pod)
+	if err != nil {
+		glog.Errorf("Failed to count the scheduled members of group %v: %v", groupKey(pod), err)
+		s.config.Error(pod, err)
+		return
+	}
+	members := s.groups.add(pod, running)
+	if members == nil {
+		glog.V(3).Infof("Holding %v until enough members of group %v are queued", pod.Name, groupKey(pod))
+		return
+	}
+	s.scheduleGroup(groupKey(pod), members)
+}
+
+// Returns the number of scheduled members of the group of the pod.
+func (s *Scheduler) runningGroupMembers(pod *api.Pod) (int, error) {
+	pods, err := s.config.PodLister.ListPods(labels.Everything())
+	if err != nil {
+		return 0, err
+	}
+	running := 0
+	for _, scheduled := range pods {
+		if scheduled.Namespace == pod.Namespace && scheduled.Spec.Group != nil && scheduled.Spec.Group.Name == pod.Spec.Group.Name {
+			running++
+		}
+	}
+	return running, nil
+}
+
+// scheduleGroup schedules the members together and binds each of them. The group is bound
+// together or not at all: there is no way to unbind a pod, so if a binding is rejected the
+// members bound before it are deleted with Evictor, and every member of the group is retried
+// through Error. The deleted members are only scheduled again once they are recreated, e.g.
+// by their replication controller, and until then the retried members are held, up to
+// GroupTimeout.
+func (s *Scheduler) scheduleGroup(name string, members []*api.Pod) {
+	pods := make([]api.Pod, len(members))
+	for i := range members {
+		pods[i] = *members[i]
+	}
+	dests, err := s.config.GroupAlgorithm.ScheduleGroup(pods, s.config.MinionLister)
+	if err != nil {
+		glog.V(1).Infof("Failed to schedule group %v: %v", name, err)
+		for _, member := range members {
+			record.Eventf(member, string(api.PodPending), "failedScheduling", "Error scheduling group %v: %v", name, err)
+			s.config.Error(member, err)
+		}
+		return
+	}
+	for i, member := range members {
+		b := &api.Binding{
+			ObjectMeta: api.ObjectMeta{Namespace: member.Namespace},
+			PodID:      member.Name,
+			Host:       dests[i],
+		}
+		if err := s.config.Binder.Bind(b); err != nil {
+			glog.V(1).Infof("Failed to bind pod %v of group %v: %v", member.Name, name, err)
+			record.Eventf(member, string(api.PodPending), "failedScheduling", "Binding rejected for group %v: %v", name, err)
+			s.unbindGroup(name, members[:i], dests[:i], err)
+			for _, member := range members {
+				s.config.Error(member, err)
+			}
+			return
+		}
+	}
+	for i, member := range members {
+		record.Eventf(member, string(api.PodPending), "scheduled", "Successfully assigned %v to %v with group %v", member.Name, dests[i], name)
+	}
+}
+
+// unbindGroup deletes the members of the group that were bound before the binding of
+// another member was rejected with 'err'.
+func (s *Scheduler) unbindGroup(name string, bound []*api.Pod, dests []string, err error) {
+	for i, member := range bound {
+		pod := *member
+		pod.Status.Host = dests[i]
+		record.Eventf(member, string(api.PodPending), "failedScheduling", "Deleting %v from %v: binding rejected for another member of group %v: %v", member.Name, dests[i], name, err)
+		if err := s.config.Evictor.Evict(&pod); err != nil {
+			glog.Errorf("Failed to delete pod %v of group %v: %v", member.Name, name, err)
+		}
+	}
+}
+
+// expireGroups releases the members of the groups held for longer than GroupTimeout.
+func (s *Scheduler) expireGroups() {
+	for name, group := range s.groups.expire(s.config.GroupTimeout) {
+		err := fmt.Errorf("group %v has %d of %d members queued after %v", name, len(group.members), group.minMembers, s.config.GroupTimeout)
+		glog.V(1).Infof("Releasing the members of group %v: %v", name, err)
+		for _, member := range group.members {
+			record.Eventf(member, string(api.PodPending), "failedScheduling", "Error scheduling: %v", err)
+			s.config.Error(member, err)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/record"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+func groupPod(id, group string, minMembers int) *api.Pod {
+	pod := podWithID(id)
+	pod.Spec.Group = &api.PodGroup{Name: group, MinMembers: minMembers}
+	return pod
+}
+
+// flushEvents waits until the events recorded so far are delivered, so that they do not
+// reach the watchers of the following tests.
+func flushEvents() {
+	flushed := make(chan struct{})
+	w := record.GetEvents(func(e *api.Event) {
+		if e.Reason == "flush" {
+			close(flushed)
+		}
+	})
+	record.Eventf(podWithID("flush"), "", "flush", "")
+	<-flushed
+	w.Stop()
+}
+
+type mockGroupScheduler struct {
+	machines []string
+	err      error
+}
+
+func (ms mockGroupScheduler) ScheduleGroup(pods []api.Pod, ml scheduler.MinionLister) ([]string, error) {
+	if ms.err != nil {
+		return nil, ms.err
+	}
+	return ms.machines[:len(pods)], nil
+}
+
+// groupTest records what the scheduler does with the pods of a group.
+type groupTest struct {
+	scheduler *Scheduler
+	queue     []*api.Pod
+	bound     []string
+	retried   []string
+	evictor   *fakeEvictor
+}
+
+func newGroupTest(algo scheduler.GroupScheduler, scheduled []api.Pod, bindErrs map[string]error) *groupTest {
+	test := &groupTest{evictor: &fakeEvictor{}}
+	test.scheduler = New(&Config{
+		MinionLister: scheduler.FakeMinionLister(
+			api.NodeList{Items: []api.Node{{ObjectMeta: api.ObjectMeta{Name: "machine1"}}}},
+		),
+		Algorithm: mockScheduler{"machine1", nil},
+		Binder: fakeBinder{func(b *api.Binding) error {
+			if err := bindErrs[b.PodID]; err != nil {
+				return err
+			}
+			test.bound = append(test.bound, b.PodID+"@"+b.Host)
+			return nil
+		}},
+		Evictor:        test.evictor,
+		GroupAlgorithm: algo,
+		GroupTimeout:   time.Minute,
+		PodLister:      scheduler.FakePodLister(scheduled),
+		Error: func(p *api.Pod, err error) {
+			test.retried = append(test.retried, p.Name)
+		},
+		NextPod: func() *api.Pod {
+			pod := test.queue[0]
+			test.queue = test.queue[1:]
+			return pod
+		},
+	})
+	return test
+}
+
+func (g *groupTest) schedule(pods ...*api.Pod) {
+	g.queue = append(g.queue, pods...)
+	for range pods {
+		g.scheduler.scheduleOne()
+	}
+}
+
+func TestScheduleGroup(t *testing.T) {
+	defer record.StartLogging(t.Logf).Stop()
+	defer flushEvents()
+	algo := mockGroupScheduler{machines: []string{"machine1", "machine2", "machine3"}}
+
+	test := newGroupTest(algo, nil, nil)
+	test.schedule(groupPod("a", "job", 3), groupPod("b", "job", 3), groupPod("a", "job", 3))
+	if len(test.bound) != 0 || len(test.retried) != 0 {
+		t.Errorf("expected the members to be held, got bound %v and retried %v", test.bound, test.retried)
+	}
+	test.schedule(groupPod("c", "job", 3))
+	if expected := []string{"a@machine1", "b@machine2", "c@machine3"}; !reflect.DeepEqual(test.bound, expected) {
+		t.Errorf("expected %v to be bound, got %v", expected, test.bound)
+	}
+
+	// Members of the group that are already scheduled count towards the minimum.
+	running := *groupPod("running", "job", 3)
+	other := *groupPod("other", "job", 3)
+	other.Namespace = "other"
+	test = newGroupTest(algo, []api.Pod{running, other}, nil)
+	test.schedule(groupPod("a", "job", 3), groupPod("b", "job", 3))
+	if expected := []string{"a@machine1", "b@machine2"}; !reflect.DeepEqual(test.bound, expected) {
+		t.Errorf("expected %v to be bound, got %v", expected, test.bound)
+	}
+
+	// Pods without a group are not held.
+	test = newGroupTest(algo, nil, nil)
+	test.schedule(podWithID("a"))
+	if expected := []string{"a@machine1"}; !reflect.DeepEqual(test.bound, expected) {
+		t.Errorf("expected %v to be bound, got %v", expected, test.bound)
+	}
+}
+
+func TestScheduleGroupFailures(t *testing.T) {
+	defer record.StartLogging(t.Logf).Stop()
+	defer flushEvents()
+
+	test := newGroupTest(mockGroupScheduler{err: errors.New("no fit")}, nil, nil)
+	test.schedule(groupPod("a", "job", 2), groupPod("b", "job", 2))
+	if len(test.bound) != 0 || !reflect.DeepEqual(test.retried, []string{"a", "b"}) {
+		t.Errorf("expected no member to be bound and all to be retried, got bound %v and retried %v", test.bound, test.retried)
+	}
+
+	algo := mockGroupScheduler{machines: []string{"machine1", "machine2", "machine3"}}
+	bindErrs := map[string]error{"c": errors.New("binder")}
+	test = newGroupTest(algo, nil, bindErrs)
+	test.schedule(groupPod("a", "job", 3), groupPod("b", "job", 3), groupPod("c", "job", 3))
+	if expected := []string{"a", "b"}; !reflect.DeepEqual(test.evictor.evicted, expected) {
+		t.Errorf("expected the bound members %v to be deleted, got %v", expected, test.evictor.evicted)
+	}
+	if expected := []string{"a", "b", "c"}; !reflect.DeepEqual(test.retried, expected) {
+		t.Errorf("expected the whole group %v to be retried, got %v", expected, test.retried)
+	}
+
+	// The retried group is held until all of its members are queued again.
+	delete(bindErrs, "c")
+	test.bound = nil
+	test.schedule(groupPod("c", "job", 3), groupPod("a", "job", 3))
+	if len(test.bound) != 0 {
+		t.Errorf("expected the members to be held, got bound %v", test.bound)
+	}
+	test.schedule(groupPod("b", "job", 3))
+	if expected := []string{"c@machine1", "a@machine2", "b@machine3"}; !reflect.DeepEqual(test.bound, expected) {
+		t.Errorf("expected %v to be bound, got %v", expected, test.bound)
+	}
+
+	// A member that fails to be deleted does not stop the group from being retried.
+	test = newGroupTest(algo, nil, map[string]error{"b": errors.New("binder")})
+	test.evictor.err = errors.New("evictor")
+	test.schedule(groupPod("a", "job", 2), groupPod("b", "job", 2))
+	if expected := []string{"a", "b"}; !reflect.DeepEqual(test.retried, expected) {
+		t.Errorf("expected the whole group %v to be retried, got %v", expected, test.retried)
+	}
+}
+
+func TestExpireGroups(t *testing.T) {
+	defer record.StartLogging(t.Logf).Stop()
+	defer flushEvents()
+	test := newGroupTest(mockGroupScheduler{machines: []string{"machine1", "machine2"}}, nil, nil)
+	clock := &util.FakeClock{Time: time.Date(2014, 10, 1, 0, 0, 0, 0, time.UTC)}
+	test.scheduler.groups.clock = clock
+
+	test.schedule(groupPod("a", "job", 2))
+	clock.Time = clock.Time.Add(30 * time.Second)
+	test.schedule(groupPod("b", "other", 2))
+	clock.Time = clock.Time.Add(31 * time.Second)
+	test.scheduler.expireGroups()
+	if !reflect.DeepEqual(test.retried, []string{"a"}) {
+		t.Errorf("expected the members of the expired group to be retried, got %v", test.retried)
+	}
+
+	// A member of the expired group starts a new one.
+	test.schedule(groupPod("c", "job", 2))
+	if len(test.bound) != 0 {
+		t.Errorf("expected the member to be held, got bound %v", test.bound)
+	}
+	test.schedule(groupPod("d", "other", 2))
+	if expected := []string{"b@machine1", "d@machine2"}; !reflect.DeepEqual(test.bound, expected) {
+		t.Errorf("expected %v to be bound, got %v", expected, test.bound)
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package scheduler
 
 import (
+	"time"
+
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client/record"
 	// TODO: move everything from pkg/scheduler into this package. Remove references from registry.
@@ -31,10 +33,16 @@ type Binder interface {
 	Bind(binding *api.Binding) error
 }
 
+// Evictor knows how to evict a pod from its machine.
+type Evictor interface {
+	Evict(pod *api.Pod) error
+}
+
 // Scheduler watches for new unscheduled pods. It attempts to find
 // minions that they fit on and writes bindings back to the api server.
 type Scheduler struct {
 	config *Config
+	groups *podGroups
 }
 
 type Config struct {
@@ -42,6 +50,20 @@ type Config struct {
 	Algorithm    scheduler.Scheduler
 	Binder       Binder
 
+	// Preemptor, if set, is called when a pod fits on no minion to find pods with a
+	// lower priority to evict with Evictor to make room for it.
+	Preemptor scheduler.Preemptor
+	Evictor   Evictor
+
+	// GroupAlgorithm, if set, schedules the pods that are members of a group together.
+	// Members are held until enough of them are queued, counting the scheduled ones listed
+	// by PodLister, and for at most GroupTimeout if it is positive. A group is bound together
+	// or not at all: if a member fails to bind, the members bound before it are deleted with
+	// Evictor and the whole group is retried.
+	GroupAlgorithm scheduler.GroupScheduler
+	GroupTimeout   time.Duration
+	PodLister      scheduler.PodLister
+
 	// NextPod should be a function that blocks until the next pod
 	// is available. We don't use a channel for this, because scheduling
 	// a pod may take some amount of time and we don't want pods to get
@@ -58,21 +80,34 @@ func New(c *Config) *Scheduler {
 	s := &Scheduler{
 		config: c,
 	}
+	if c.GroupAlgorithm != nil {
+		s.groups = newPodGroups()
+	}
 	return s
 }
 
 // Run begins watching and scheduling. It starts a goroutine and returns immediately.
 func (s *Scheduler) Run() {
 	go util.Forever(s.scheduleOne, 0)
+	if s.groups != nil && s.config.GroupTimeout > 0 {
+		go util.Forever(s.expireGroups, groupExpiryPeriod)
+	}
 }
 
 func (s *Scheduler) scheduleOne() {
 	pod := s.config.NextPod()
+	if pod.Spec.Group != nil && s.groups != nil {
+		s.scheduleGroupMember(pod)
+		return
+	}
 	glog.V(3).Infof("Attempting to schedule: %v", pod)
 	dest, err := s.config.Algorithm.Schedule(*pod, s.config.MinionLister)
 	if err != nil {
 		glog.V(1).Infof("Failed to schedule: %v", pod)
 		record.Eventf(pod, string(api.PodPending), "failedScheduling", "Error scheduling: %v", err)
+		if _, ok := err.(*scheduler.FitError); ok && s.config.Preemptor != nil {
+			s.preempt(pod)
+		}
 		s.config.Error(pod, err)
 		return
 	}
@@ -89,3 +124,27 @@ func (s *Scheduler) scheduleOne() {
 	}
 	record.Eventf(pod, string(api.PodPending), "scheduled", "Successfully assigned %v to %v", pod.Name, dest)
 }
+
+// preempt evicts the pods with a lower priority that keep the pod from fitting. The pod
+// itself is retried through Error like after any failure, by which time the room is free.
+func (s *Scheduler) preempt(pod *api.Pod) {
+	dest, victims, err := s.config.Preemptor.Preempt(*pod, s.config.MinionLister)
+	if err != nil {
+		glog.Errorf("Failed to find pods to preempt for %v: %v", pod.Name, err)
+		return
+	}
+	if dest == "" {
+		glog.V(2).Infof("No pods to preempt for %v", pod.Name)
+		return
+	}
+	for i := range victims {
+		victim := &victims[i]
+		if err := s.config.Evictor.Evict(victim); err != nil {
+			glog.Errorf("Failed to preempt %v for %v: %v", victim.Name, pod.Name, err)
+			record.Eventf(pod, string(api.PodPending), "failedPreemption", "Failed to preempt %v on %v: %v", victim.Name, dest, err)
+			return
+		}
+		record.Eventf(victim, string(victim.Status.Phase), "preempted", "Preempted by %v with priority %d on %v", pod.Name, pod.Spec.Priority, dest)
+	}
+	record.Eventf(pod, string(api.PodPending), "preempting", "Preempted %d pods with a lower priority on %v", len(victims), dest)
+}
@@ -125,3 +125,95 @@ func TestScheduler(t *testing.T) {
 		events.Stop()
 	}
 }
+
+type fakePreemptor struct {
+	machine string
+	victims []api.Pod
+}
+
+func (fp fakePreemptor) Preempt(pod api.Pod, ml scheduler.MinionLister) (string, []api.Pod, error) {
+	return fp.machine, fp.victims, nil
+}
+
+type fakeEvictor struct {
+	evicted []string
+	err     error
+}
+
+func (fe *fakeEvictor) Evict(pod *api.Pod) error {
+	if fe.err != nil {
+		return fe.err
+	}
+	fe.evicted = append(fe.evicted, pod.Name)
+	return nil
+}
+
+func TestSchedulerPreemption(t *testing.T) {
+	defer record.StartLogging(t.Logf).Stop()
+	errE := errors.New("evictor")
+	victims := []api.Pod{*podWithID("low1"), *podWithID("low2")}
+
+	table := []struct {
+		algo          scheduler.Scheduler
+		preemptor     fakePreemptor
+		evictErr      error
+		expectEvicted []string
+		eventReasons  []string
+	}{
+		{
+			algo:          mockScheduler{"", &scheduler.FitError{Pod: *podWithID("foo")}},
+			preemptor:     fakePreemptor{"machine1", victims},
+			expectEvicted: []string{"low1", "low2"},
+			eventReasons:  []string{"failedScheduling", "preempted", "preempted", "preempting"},
+		}, {
+			algo:         mockScheduler{"", &scheduler.FitError{Pod: *podWithID("foo")}},
+			preemptor:    fakePreemptor{"machine1", victims},
+			evictErr:     errE,
+			eventReasons: []string{"failedScheduling", "failedPreemption"},
+		}, {
+			algo:         mockScheduler{"", &scheduler.FitError{Pod: *podWithID("foo")}},
+			preemptor:    fakePreemptor{},
+			eventReasons: []string{"failedScheduling"},
+		}, {
+			algo:         mockScheduler{"", errors.New("scheduler")},
+			preemptor:    fakePreemptor{"machine1", victims},
+			eventReasons: []string{"failedScheduling"},
+		},
+	}
+
+	for i, item := range table {
+		var gotPod *api.Pod
+		evictor := &fakeEvictor{err: item.evictErr}
+		c := &Config{
+			MinionLister: scheduler.FakeMinionLister(
+				api.NodeList{Items: []api.Node{{ObjectMeta: api.ObjectMeta{Name: "machine1"}}}},
+			),
+			Algorithm: item.algo,
+			Preemptor: item.preemptor,
+			Evictor:   evictor,
+			Error: func(p *api.Pod, err error) {
+				gotPod = p
+			},
+			NextPod: func() *api.Pod {
+				return podWithID("foo")
+			},
+		}
+		reasons := make(chan string, 10)
+		events := record.GetEvents(func(e *api.Event) {
+			reasons <- e.Reason
+		})
+		New(c).scheduleOne()
+		for _, expected := range item.eventReasons {
+			if got := <-reasons; got != expected {
+				t.Errorf("%v: expected event %v, got %v", i, expected, got)
+			}
+		}
+		events.Stop()
+		if gotPod == nil {
+			t.Errorf("%v: expected the pod to be retried", i)
+		}
+		if !reflect.DeepEqual(evictor.evicted, item.expectEvicted) {
+			t.Errorf("%v: expected %v to be evicted, got %v", i, item.expectEvicted, evictor.evicted)
+		}
+	}
+}